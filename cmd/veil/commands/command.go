@@ -28,6 +28,10 @@ type Command interface {
 	// NeedsMasterKey returns true if the command requires the master key.
 	// Some commands like 'reset' need the store but not the master key.
 	NeedsMasterKey() bool
+
+	// UsesProject returns true if the command takes defaults from the
+	// project file, and so must fail if the project file is invalid.
+	UsesProject() bool
 }
 
 // Dependencies holds all injectable dependencies for commands.
@@ -37,6 +41,19 @@ type Dependencies struct {
 	Config *config.Config
 	App    *app.App
 
+	// Project is the project file found above the working directory, or
+	// nil if there is none.
+	Project *config.Project
+
+	// ProjectErr is why the project file could not be loaded, if it
+	// could not.
+	ProjectErr error
+
+	// Profile is the config profile given with --profile, if any.
+	// Commands that load the configuration themselves pass it on to
+	// config.LoadConfig.
+	Profile string
+
 	// IO dependencies for testability
 	Stdout io.Writer
 	Stderr io.Writer
@@ -60,3 +77,6 @@ func (b BaseCommand) Usage() string { return b.usage }
 // Default: most commands need full dependencies
 func (b BaseCommand) NeedsDeps() bool      { return true }
 func (b BaseCommand) NeedsMasterKey() bool { return true }
+
+// Default: the project file is only used by the commands that opt in
+func (b BaseCommand) UsesProject() bool { return false }
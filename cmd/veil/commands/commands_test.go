@@ -2,12 +2,19 @@ package commands_test
 
 import (
 	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/ossydotpy/veil/cmd/veil/commands"
+	"github.com/ossydotpy/veil/cmd/veil/flags"
 	"github.com/ossydotpy/veil/internal/app"
+	"github.com/ossydotpy/veil/internal/config"
 	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/store/sqlite"
 	"github.com/ossydotpy/veil/internal/testhelpers"
 )
 
@@ -88,6 +95,83 @@ func TestInitCommand_Execute(t *testing.T) {
 	}
 }
 
+func TestInitCommand_ExecutePassphrase(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "veil.db")
+	passFile := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passFile, []byte("correct horse\n"), 0600); err != nil {
+		t.Fatalf("failed to write passphrase file: %v", err)
+	}
+	t.Setenv("VEIL_DB_PATH", dbPath)
+
+	cmd := commands.NewInitCommand()
+	var stdout, stderr bytes.Buffer
+	deps := commands.Dependencies{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	if err := cmd.Execute([]string{"--passphrase-file", passFile}, deps); err != nil {
+		t.Fatalf("Execute() error = %v, want nil", err)
+	}
+	if strings.Contains(stdout.String(), "export MASTER_KEY") {
+		t.Error("passphrase init should not print a MASTER_KEY")
+	}
+
+	s, err := sqlite.NewSqliteStore(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer s.Close()
+
+	params, err := app.LoadKDFParams(s)
+	if err != nil || params == nil {
+		t.Fatalf("LoadKDFParams() = %v, %v; want stored parameters", params, err)
+	}
+	if _, err := crypto.NewEngineFromPassphrase("correct horse", params); err != nil {
+		t.Errorf("stored parameters do not accept the passphrase: %v", err)
+	}
+
+	// A second passphrase init must not overwrite the salt.
+	if err := cmd.Execute([]string{"--passphrase-file", passFile}, deps); err == nil {
+		t.Error("Execute() on an initialised database should fail")
+	}
+}
+
+func TestInitCommand_PassphraseFileFromEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "veil.db")
+	passFile := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passFile, []byte("correct horse\n"), 0600); err != nil {
+		t.Fatalf("failed to write passphrase file: %v", err)
+	}
+	t.Setenv("VEIL_DB_PATH", dbPath)
+	t.Setenv("VEIL_PASSPHRASE_FILE", passFile)
+
+	var stdout, stderr bytes.Buffer
+	deps := commands.Dependencies{
+		Stdin:  strings.NewReader("wrong passphrase\n"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+	if err := commands.NewInitCommand().Execute([]string{"--passphrase"}, deps); err != nil {
+		t.Fatalf("Execute() error = %v, want nil", err)
+	}
+
+	s, err := sqlite.NewSqliteStore(dbPath)
+	if err != nil {
+		t.Fatalf("failed to open store: %v", err)
+	}
+	defer s.Close()
+	params, err := app.LoadKDFParams(s)
+	if err != nil || params == nil {
+		t.Fatalf("LoadKDFParams() = %v, %v; want stored parameters", params, err)
+	}
+	if _, err := crypto.NewEngineFromPassphrase("correct horse", params); err != nil {
+		t.Errorf("stored parameters do not accept the passphrase from VEIL_PASSPHRASE_FILE: %v", err)
+	}
+}
+
 func TestQuickCommand_Metadata(t *testing.T) {
 	cmd := commands.NewQuickCommand()
 
@@ -195,3 +279,236 @@ func TestGetCommand_NoTrailingNewline(t *testing.T) {
 		t.Errorf("unexpected output; got %q, want %q", got, secret)
 	}
 }
+
+func TestKeysCommand_Execute(t *testing.T) {
+	oldKey, newKey := strings.Repeat("0", 64), strings.Repeat("1", 64)
+	st := testhelpers.NewMemStore()
+
+	oldEngine, _ := crypto.NewEngine(oldKey)
+	if err := app.New(st, oldEngine).Set("vault", "OLD", "a"); err != nil {
+		t.Fatalf("Set error: %v", err)
+	}
+
+	ring, err := crypto.NewKeyRing([]crypto.NamedKey{{ID: "next", Hex: newKey}, {ID: "prev", Hex: oldKey}})
+	if err != nil {
+		t.Fatalf("NewKeyRing error: %v", err)
+	}
+	a := app.New(st, ring)
+	a.Set("vault", "NEW", "b")
+	a.Set("vault", "NEW", "c")
+
+	var stdout bytes.Buffer
+	deps := commands.Dependencies{App: a, Engine: ring, Stdout: &stdout}
+	if err := commands.NewKeysCommand().Execute(nil, deps); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	out := stdout.String()
+	for _, want := range []string{"next                 primary  0 vault keys", "prev                          1 vault keys"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not in the ring") {
+		t.Errorf("fingerprint alias reported as unknown key:\n%s", out)
+	}
+}
+
+func TestListCommand_Long(t *testing.T) {
+	engine, _ := crypto.NewEngine(strings.Repeat("0", 64))
+	a := app.New(testhelpers.NewMemStore(), engine)
+	a.Set("prod", "DB_PASSWORD", "secret")
+	a.Set("prod", "API_KEY", "key")
+
+	deps := commands.Dependencies{App: a, Engine: engine, Stdout: &bytes.Buffer{}}
+	if err := commands.NewDescribeCommand().Execute([]string{"prod", "DB_PASSWORD", "--owner", "platform", "--description", "Primary database"}, deps); err != nil {
+		t.Fatalf("describe Execute() error = %v", err)
+	}
+	if err := commands.NewTagCommand().Execute([]string{"prod", "DB_PASSWORD", "db", "prod"}, deps); err != nil {
+		t.Fatalf("tag Execute() error = %v", err)
+	}
+
+	var stdout bytes.Buffer
+	deps.Stdout = &stdout
+	if err := commands.NewListCommand().Execute([]string{"prod", "--long"}, deps); err != nil {
+		t.Fatalf("list Execute() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") {
+		t.Fatalf("list --long output = %q, want header and 2 rows", stdout.String())
+	}
+	if !strings.HasPrefix(lines[1], "API_KEY") || !strings.Contains(lines[1], " - ") {
+		t.Errorf("row without metadata = %q", lines[1])
+	}
+	for _, want := range []string{"DB_PASSWORD", "platform", "db,prod", "Primary database"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("row %q missing %q", lines[2], want)
+		}
+	}
+	if strings.Contains(stdout.String(), "secret") {
+		t.Error("list --long printed a secret value")
+	}
+}
+
+func TestDueCommand_FailsWhenOverdue(t *testing.T) {
+	engine, _ := crypto.NewEngine(strings.Repeat("0", 64))
+	a := app.New(testhelpers.NewMemStore(), engine)
+	var stdout, stderr bytes.Buffer
+	deps := commands.Dependencies{App: a, Engine: engine, Stdout: &stdout, Stderr: &stderr}
+
+	if err := commands.NewSetCommand().Execute([]string{"prod", "TOKEN", "t", "--expires", "2000-01-01"}, deps); err != nil {
+		t.Fatalf("set Execute() error = %v", err)
+	}
+
+	if err := commands.NewDueCommand().Execute(nil, deps); err == nil {
+		t.Error("due should fail when a secret has expired")
+	}
+	if !strings.Contains(stdout.String(), "prod/TOKEN") || !strings.Contains(stdout.String(), "expired 2000-01-01") {
+		t.Errorf("due output = %q, want expired prod/TOKEN", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := commands.NewGetCommand().Execute([]string{"prod", "TOKEN"}, deps); err != nil {
+		t.Fatalf("get Execute() error = %v", err)
+	}
+	if stdout.String() != "t" || !strings.Contains(stderr.String(), "Warning: prod/TOKEN expired") {
+		t.Errorf("get stdout = %q, stderr = %q; want value and warning", stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	if err := commands.NewGetCommand().Execute([]string{"prod", "TOKEN", "--fail-expired"}, deps); !errors.Is(err, app.ErrSecretExpired) {
+		t.Errorf("get --fail-expired error = %v, want ErrSecretExpired", err)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("get --fail-expired printed %q", stdout.String())
+	}
+}
+
+func TestProjectFile_SuppliesVaultAndFilters(t *testing.T) {
+	st := testhelpers.NewMemStore()
+	engine, err := crypto.NewEngine(strings.Repeat("0", 64))
+	if err != nil {
+		t.Fatalf("failed to create crypto engine: %v", err)
+	}
+	a := app.New(st, engine)
+	a.Set("dev", "DB_URL", "postgres://dev")
+	a.Set("dev", "LOCAL_ONLY", "x")
+
+	dir := t.TempDir()
+	project := &config.Project{
+		Path:    filepath.Join(dir, config.ProjectFileName),
+		Vault:   "dev",
+		Exclude: []string{"LOCAL_*"},
+		Export:  config.ProjectExport{To: "out/.env"},
+	}
+	os.Mkdir(filepath.Join(dir, "out"), 0700)
+
+	var stdout bytes.Buffer
+	deps := commands.Dependencies{App: a, Project: project, Stdout: &stdout, Stderr: &bytes.Buffer{}}
+
+	if err := commands.NewGetCommand().Execute([]string{"DB_URL"}, deps); err != nil {
+		t.Fatalf("get error: %v", err)
+	}
+	if stdout.String() != "postgres://dev" {
+		t.Errorf("get output = %q", stdout.String())
+	}
+
+	if err := commands.NewExportCommand().Execute(nil, deps); err != nil {
+		t.Fatalf("export error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "out", ".env"))
+	if err != nil {
+		t.Fatalf("export did not write to the project target: %v", err)
+	}
+	if string(data) != "DB_URL=postgres://dev\n" {
+		t.Errorf("exported %q", data)
+	}
+
+	project.Vault = ""
+	if err := commands.NewGetCommand().Execute([]string{"DB_URL"}, deps); err == nil {
+		t.Error("get without a vault succeeded with a project file that sets none")
+	}
+}
+
+func TestProjectFile_InvalidOnlyFailsProjectCommands(t *testing.T) {
+	st := testhelpers.NewMemStore()
+	engine, err := crypto.NewEngine(strings.Repeat("0", 64))
+	if err != nil {
+		t.Fatalf("failed to create crypto engine: %v", err)
+	}
+	a := app.New(st, engine)
+	a.Set("dev", "DB_URL", "postgres://dev")
+
+	projectErr := errors.New("invalid project file .veil.toml: unknown settings vualt")
+	deps := commands.Dependencies{App: a, ProjectErr: projectErr, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+
+	if err := commands.NewGetCommand().Execute([]string{"dev", "DB_URL"}, deps); !errors.Is(err, projectErr) {
+		t.Errorf("get error = %v, want the project file error", err)
+	}
+	if err := commands.NewSetCommand().Execute([]string{"dev", "API_KEY", "x"}, deps); err != nil {
+		t.Errorf("set error = %v, want the project file ignored", err)
+	}
+
+	for _, cmd := range []commands.Command{commands.NewGetCommand(), commands.NewRunCommand(), commands.NewExportCommand(), commands.NewEnvCommand(), commands.NewDirenvCommand()} {
+		if !cmd.UsesProject() {
+			t.Errorf("%s: UsesProject() = false, want true", cmd.Name())
+		}
+	}
+	if commands.NewSetCommand().UsesProject() {
+		t.Error("set: UsesProject() = true, want false")
+	}
+}
+
+func TestHostEnv(t *testing.T) {
+	current := []string{
+		"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C.UTF-8", "EDITOR=vim", "AWS_REGION=eu-west-1",
+		"MASTER_KEY=0123", "MASTER_KEYS=old:0123", "VEIL_DB_PATH=/tmp/veil.db", "VEIL_MASTER_KEY_FILE=/tmp/key",
+	}
+
+	tests := []struct {
+		name string
+		opts flags.RunOptions
+		want []string
+	}{
+		{
+			name: "veil configuration stripped by default",
+			opts: flags.RunOptions{},
+			want: []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C.UTF-8", "EDITOR=vim", "AWS_REGION=eu-west-1"},
+		},
+		{
+			name: "keep veil env",
+			opts: flags.RunOptions{KeepVeilEnv: true},
+			want: current,
+		},
+		{
+			name: "clean env keeps the baseline",
+			opts: flags.RunOptions{CleanEnv: true},
+			want: []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C.UTF-8"},
+		},
+		{
+			name: "clean env with allow-listed variables",
+			opts: flags.RunOptions{CleanEnv: true, AllowEnv: []string{"AWS_*"}},
+			want: []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C.UTF-8", "AWS_REGION=eu-west-1"},
+		},
+		{
+			name: "allow-env cannot pass veil configuration",
+			opts: flags.RunOptions{CleanEnv: true, AllowEnv: []string{"VEIL_*", "MASTER_KEY"}},
+			want: []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C.UTF-8"},
+		},
+		{
+			name: "clean env and keep veil env",
+			opts: flags.RunOptions{CleanEnv: true, KeepVeilEnv: true},
+			want: []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C.UTF-8", "MASTER_KEY=0123", "MASTER_KEYS=old:0123", "VEIL_DB_PATH=/tmp/veil.db", "VEIL_MASTER_KEY_FILE=/tmp/key"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := commands.HostEnv(current, tt.opts)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("hostEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
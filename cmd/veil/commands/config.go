@@ -0,0 +1,236 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/config"
+	"github.com/ossydotpy/veil/internal/fsutil"
+)
+
+// ConfigCommand shows and changes the global config file and its
+// profiles.
+type ConfigCommand struct {
+	BaseCommand
+}
+
+func NewConfigCommand() *ConfigCommand {
+	return &ConfigCommand{
+		BaseCommand: NewBaseCommand("config", "Show or change settings and profiles"),
+	}
+}
+
+func (c *ConfigCommand) NeedsDeps() bool      { return false }
+func (c *ConfigCommand) NeedsMasterKey() bool { return false }
+
+func (c *ConfigCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := deps.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	opts, err := flags.ParseConfigFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = deps.Profile
+	}
+
+	switch opts.Action {
+	case "list":
+		cfg, err := config.LoadConfig(profile)
+		if err != nil {
+			return err
+		}
+		c.list(stdout, cfg)
+
+	case "get":
+		cfg, err := config.LoadConfig(profile)
+		if err != nil {
+			return err
+		}
+		if opts.Args[0] == "profile" {
+			fmt.Fprintln(stdout, cfg.Profile)
+			return nil
+		}
+		value, err := cfg.Get(opts.Args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, value)
+
+	case "set":
+		return c.set(stdout, stderr, profile, opts.Args[0], opts.Args[1])
+	}
+	return nil
+}
+
+// list prints the settings in use, where each comes from, and the
+// profiles defined in the config file.
+func (c *ConfigCommand) list(w io.Writer, cfg *config.Config) {
+	path := cfg.File.Path
+	if !fsutil.FileExists(path) {
+		path += " (not created yet)"
+	}
+	fmt.Fprintf(w, "Config file: %s\n", path)
+	if cfg.Project != nil {
+		fmt.Fprintf(w, "Project file: %s\n", cfg.Project.Path)
+	} else if cfg.ProjectErr != nil {
+		fmt.Fprintf(w, "Project file: %v\n", cfg.ProjectErr)
+	}
+	fmt.Fprintf(w, "Profile: %s (from %s)\n", cfg.Profile, cfg.ProfileSource)
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tFROM")
+	for _, s := range config.Settings {
+		value, _ := cfg.Get(s.Name)
+		from := cfg.Sources[s.Name]
+		if value == "" {
+			value, from = "-", "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Name, value, from)
+	}
+	tw.Flush()
+
+	names := cfg.File.ProfileNames()
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Profiles:")
+	for _, name := range names {
+		marker := " "
+		if name == cfg.Profile {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s %s\n", marker, name)
+	}
+}
+
+// set changes a setting of the selected profile, or the default profile
+// if key is "profile", and saves the config file. A profile is created by
+// setting something in it.
+func (c *ConfigCommand) set(stdout, stderr io.Writer, profile, key, value string) error {
+	file, err := config.LoadFile(config.FilePath())
+	if err != nil {
+		return err
+	}
+
+	if key == "profile" {
+		if _, ok := file.Profiles[value]; !ok && value != "" && value != config.DefaultProfile {
+			return fmt.Errorf("%w %q: set something in it first, e.g. veil config set db_path <path> --profile %s", config.ErrUnknownProfile, value, value)
+		}
+		file.Profile = value
+		if err := file.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Default profile set to %s in %s.\n", value, file.Path)
+		return nil
+	}
+
+	setting, err := config.LookupSetting(key)
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	project, err := config.FindProject(wd)
+	if err != nil {
+		return err
+	}
+	name, _ := file.SelectProfile(profile, project)
+
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]config.Profile)
+	}
+	// Relative paths given on the command line are relative to the working
+	// directory, not to the config file.
+	if setting.IsPath && value != "" && !strings.HasPrefix(value, "~") && !filepath.IsAbs(value) {
+		if value, err = filepath.Abs(value); err != nil {
+			return err
+		}
+	}
+
+	p := file.Profiles[name]
+	setting.Set(&p, value)
+	if p == (config.Profile{}) {
+		delete(file.Profiles, name)
+	} else {
+		file.Profiles[name] = p
+	}
+	if err := file.Save(); err != nil {
+		return err
+	}
+
+	if value == "" {
+		fmt.Fprintf(stdout, "Removed %s from profile %s.\n", key, name)
+	} else {
+		fmt.Fprintf(stdout, "Set %s in profile %s.\n", key, name)
+	}
+	if os.Getenv(setting.Env) != "" {
+		fmt.Fprintf(stderr, "Warning: %s is set in the environment and overrides this setting.\n", setting.Env)
+	}
+	return nil
+}
+
+func (c *ConfigCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil config [list] [flags]")
+	fmt.Fprintln(w, "       veil config get <setting> [flags]")
+	fmt.Fprintln(w, "       veil config set <setting> <value> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Show or change the settings in the config file, by default")
+	fmt.Fprintln(w, "~/.config/veil/config.toml. Settings belong to named profiles, such as")
+	fmt.Fprintln(w, "one for a personal database and one for a shared team database. The")
+	fmt.Fprintln(w, "profile is chosen by --profile, then VEIL_PROFILE, then the project's")
+	fmt.Fprintln(w, ".veil.toml, then the 'profile' setting, and is otherwise 'default'.")
+	fmt.Fprintln(w, "Environment variables such as VEIL_DB_PATH override the profile.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Actions:")
+	fmt.Fprintln(w, "  list       Show the settings in use and where each comes from (default)")
+	fmt.Fprintln(w, "  get        Print the value in use of one setting")
+	fmt.Fprintln(w, "  set        Change a setting of the profile; an empty value removes it")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Settings:")
+	fmt.Fprintln(w, "  profile             The profile used by default")
+	fmt.Fprintln(w, "  db_path             Path to the database (VEIL_DB_PATH)")
+	fmt.Fprintln(w, "  store_type          Storage backend (VEIL_STORE_TYPE)")
+	fmt.Fprintln(w, "  master_key_file     File holding the master key (VEIL_MASTER_KEY_FILE)")
+	fmt.Fprintln(w, "  master_key_command  Command printing the master key (VEIL_MASTER_KEY_COMMAND)")
+	fmt.Fprintln(w, "  keyring_file        Key ring file (VEIL_KEYRING_FILE)")
+	fmt.Fprintln(w, "  passphrase_file     Passphrase file (VEIL_PASSPHRASE_FILE)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --profile <name>  Act on this profile instead of the selected one")
+	fmt.Fprintln(w, "  --help, -h        Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil config set db_path ~/.veil.db --profile personal")
+	fmt.Fprintln(w, "  veil config set db_path /mnt/team/veil.db --profile team")
+	fmt.Fprintln(w, "  veil config set master_key_command 'pass show veil/team' --profile team")
+	fmt.Fprintln(w, "  veil config set profile personal")
+	fmt.Fprintln(w, "  veil --profile team list production")
+}
+
+func init() {
+	Register(NewConfigCommand())
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+)
+
+// CpCommand copies a secret.
+type CpCommand struct {
+	BaseCommand
+}
+
+func NewCpCommand() *CpCommand {
+	return &CpCommand{
+		BaseCommand: NewBaseCommand("cp", "Copy a secret"),
+	}
+}
+
+func (c *CpCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	opts, err := flags.ParseMoveFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if err := deps.App.Copy(opts.Vault, opts.Name, opts.NewVault, opts.NewName, opts.Force); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Copied %s/%s to %s/%s.\n", opts.Vault, opts.Name, opts.NewVault, opts.NewName)
+	return nil
+}
+
+func (c *CpCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil cp <vault> <name> <new-vault> [<new-name>] [--force]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Copy the current value and metadata of a secret to another vault,")
+	fmt.Fprintln(w, "another name, or both. The copy starts with an empty history.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --force    Overwrite an existing destination; its old value is kept")
+	fmt.Fprintln(w, "             in its history")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil cp production SENTRY_DSN staging")
+}
+
+func init() {
+	Register(NewCpCommand())
+}
@@ -1,6 +1,11 @@
 package commands
 
-// DeleteCommand removes a secret from a vault.
+import (
+	"fmt"
+	"os"
+)
+
+// DeleteCommand moves a secret from a vault to the trash.
 type DeleteCommand struct {
 	BaseCommand
 }
@@ -12,6 +17,11 @@ func NewDeleteCommand() *DeleteCommand {
 }
 
 func (c *DeleteCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
 	if len(args) != 2 {
 		return &UsageError{
 			Command: "delete",
@@ -25,6 +35,7 @@ func (c *DeleteCommand) Execute(args []string, deps Dependencies) error {
 		return err
 	}
 
+	fmt.Fprintf(stdout, "Moved %s/%s to the trash. Undo with 'veil restore %s %s'.\n", vault, name, vault, name)
 	return nil
 }
 
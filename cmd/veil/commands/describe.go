@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// DescribeCommand shows or updates the metadata of a secret.
+type DescribeCommand struct {
+	BaseCommand
+}
+
+func NewDescribeCommand() *DescribeCommand {
+	return &DescribeCommand{
+		BaseCommand: NewBaseCommand("describe", "Show or set a secret's description and owner"),
+	}
+}
+
+func (c *DescribeCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if len(args) < 2 {
+		return &UsageError{
+			Command: "describe",
+			Usage:   "veil describe <vault> <name> [--description <text>] [--owner <name>]",
+		}
+	}
+
+	vault, name := args[0], args[1]
+	opts, err := flags.ParseDescribeFlags(args[2:])
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if opts.Description != nil || opts.Owner != nil {
+		_, err := deps.App.UpdateMetadata(vault, name, app.MetadataUpdate{
+			Description: opts.Description,
+			Owner:       opts.Owner,
+		})
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("secret not found")
+			}
+			return err
+		}
+		fmt.Fprintf(stdout, "Updated %s/%s\n", vault, name)
+		return nil
+	}
+
+	m, err := deps.App.Describe(vault, name)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("secret not found")
+		}
+		return err
+	}
+	versions, err := deps.App.History(vault, name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s/%s\n", vault, name)
+	fmt.Fprintf(stdout, "  Description: %s\n", orDash(m.Description))
+	fmt.Fprintf(stdout, "  Owner:       %s\n", orDash(m.Owner))
+	fmt.Fprintf(stdout, "  Tags:        %s\n", orDash(strings.Join(m.Tags, ", ")))
+	fmt.Fprintf(stdout, "  Expires:     %s\n", formatExpiry(m.ExpiresAt))
+	fmt.Fprintf(stdout, "  Rotate:      %s\n", formatRotation(m))
+	if m.Template {
+		fmt.Fprintln(stdout, "  Template:    yes")
+	}
+	if recipe, ok, err := app.RecipeOf(m); err == nil && ok {
+		fmt.Fprintf(stdout, "  Generator:   %s\n", recipe)
+		if recipe.EnvFile != "" {
+			fmt.Fprintf(stdout, "  Env file:    %s\n", recipe.EnvFile)
+		}
+	}
+	fmt.Fprintf(stdout, "  Created:     %s\n", formatTime(m.CreatedAt))
+	fmt.Fprintf(stdout, "  Updated:     %s\n", formatTime(m.UpdatedAt))
+	fmt.Fprintf(stdout, "  Versions:    %d previous\n", len(versions))
+	return nil
+}
+
+// formatTime formats a metadata timestamp in local time, or "unknown" if
+// it was not recorded.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), relativeDays(t, time.Now()))
+}
+
+func formatRotation(m store.Metadata) string {
+	if m.RotateEvery == 0 {
+		return "-"
+	}
+	every := "every " + flags.FormatInterval(m.RotateEvery)
+	if m.UpdatedAt.IsZero() {
+		return every + ", due now"
+	}
+	next := m.UpdatedAt.Add(m.RotateEvery)
+	return fmt.Sprintf("%s, next due %s", every, next.Local().Format("2006-01-02"))
+}
+
+func (c *DescribeCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil describe <vault> <name> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Show the metadata of a secret: its description, owner, tags, expiry,")
+	fmt.Fprintln(w, "rotation interval, when it was created and last updated, and how many")
+	fmt.Fprintln(w, "previous versions are kept.")
+	fmt.Fprintln(w, "With flags, update the description or owner instead. The value itself")
+	fmt.Fprintln(w, "is never shown.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --description <text>  Set the description (\"\" to clear)")
+	fmt.Fprintln(w, "  --owner <name>        Set the owner (\"\" to clear)")
+	fmt.Fprintln(w, "  --help, -h            Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil describe production DB_PASSWORD")
+	fmt.Fprintln(w, "  veil describe production DB_PASSWORD --description \"Primary Postgres\" --owner platform-team")
+}
+
+func init() {
+	Register(NewDescribeCommand())
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+)
+
+// DiffCommand compares two vaults, or a vault and an env file.
+type DiffCommand struct {
+	BaseCommand
+}
+
+func NewDiffCommand() *DiffCommand {
+	return &DiffCommand{
+		BaseCommand: NewBaseCommand("diff", "Compare two vaults or a vault and a .env file"),
+	}
+}
+
+func (c *DiffCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	opts, err := flags.ParseDiffFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	right := opts.Right
+	var entries []app.DiffEntry
+	if opts.File != "" {
+		right = opts.File
+		entries, err = deps.App.DiffFile(opts.Left, opts.File, opts.Include, opts.Exclude)
+	} else {
+		entries, err = deps.App.DiffVaults(opts.Left, opts.Right, opts.Include, opts.Exclude)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintf(stdout, "No differences between %s and %s.\n", opts.Left, right)
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "--- %s\n", opts.Left)
+	fmt.Fprintf(stdout, "+++ %s\n", right)
+	var onlyLeft, onlyRight, changed int
+	for _, e := range entries {
+		switch e.Kind {
+		case app.DiffOnlyLeft:
+			onlyLeft++
+			fmt.Fprintf(stdout, "- %s\n", diffLine(e.Name, e.Left, opts.Show))
+		case app.DiffOnlyRight:
+			onlyRight++
+			fmt.Fprintf(stdout, "+ %s\n", diffLine(e.Name, e.Right, opts.Show))
+		case app.DiffChanged:
+			changed++
+			if opts.Show {
+				fmt.Fprintf(stdout, "~ %s\n", e.Name)
+				fmt.Fprintf(stdout, "    - %s\n", e.Left)
+				fmt.Fprintf(stdout, "    + %s\n", e.Right)
+			} else {
+				fmt.Fprintf(stdout, "~ %s (value differs)\n", e.Name)
+			}
+		}
+	}
+	fmt.Fprintln(stdout)
+	fmt.Fprintf(stdout, "%d only in %s, %d only in %s, %d changed.\n", onlyLeft, opts.Left, onlyRight, right, changed)
+
+	return fmt.Errorf("%w: %d keys", ErrDifferences, len(entries))
+}
+
+// diffLine formats a key on one side of a diff, with its value if show is
+// set.
+func diffLine(name, value string, show bool) string {
+	if !show {
+		return name
+	}
+	return name + "=" + value
+}
+
+func (c *DiffCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil diff <vault> <other-vault> [flags]")
+	fmt.Fprintln(w, "       veil diff <vault> --file <path> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "List keys that exist on only one side and keys whose values differ.")
+	fmt.Fprintln(w, "Vaults are compared as 'veil export' would write them, with inherited")
+	fmt.Fprintln(w, "secrets and expanded references. Values are hidden unless --show is")
+	fmt.Fprintln(w, "given. Exits with status 1 if there are differences, so it can gate")
+	fmt.Fprintln(w, "deploys.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --file <path>        Compare against a .env file instead of a vault")
+	fmt.Fprintln(w, "  --include <pattern>  Compare only matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --exclude <pattern>  Skip matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --show               Print the values that differ")
+	fmt.Fprintln(w, "  --help, -h           Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil diff staging production")
+	fmt.Fprintln(w, "  veil diff production --file .env.production --exclude 'DEBUG*'")
+}
+
+func init() {
+	Register(NewDiffCommand())
+}
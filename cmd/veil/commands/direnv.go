@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/encoding/shell"
+)
+
+// direnvStdlib defines use_veil for direnvrc. The output of veil is
+// captured before it is evaluated, so a missing vault or a wrong key
+// fails the .envrc instead of loading nothing.
+const direnvStdlib = `# Load secrets from a veil vault: use veil [vault] [flags]
+use_veil() {
+  local out
+  if ! out="$(veil direnv "$@")"; then
+    log_error "veil: failed to load secrets"
+    return 1
+  fi
+  eval "$out"
+}
+`
+
+// DirenvCommand prints a vault's secrets for direnv to load.
+type DirenvCommand struct {
+	BaseCommand
+}
+
+func NewDirenvCommand() *DirenvCommand {
+	return &DirenvCommand{
+		BaseCommand: NewBaseCommand("direnv", "Load vault secrets with direnv"),
+	}
+}
+
+// UsesProject returns true because direnv takes its vault and filters
+// from the project file.
+func (c *DirenvCommand) UsesProject() bool { return true }
+
+func (c *DirenvCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := deps.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	if !slices.Contains(args, "--stdlib") {
+		var err error
+		if args, err = withProjectVault(args, 1, deps); err != nil {
+			return err
+		}
+	}
+	opts, err := flags.ParseDirenvFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if opts.Stdlib {
+		fmt.Fprint(stdout, direnvStdlib)
+		return nil
+	}
+
+	// A missing vault is an error rather than an empty environment.
+	include, exclude := projectFilters(deps, opts.Include, opts.Exclude)
+	secrets, err := deps.App.RunEnv(opts.Vault, include, exclude)
+	if err != nil {
+		return err
+	}
+	if err := checkExpired(stderr, deps, opts.Vault, slices.Sorted(maps.Keys(secrets)), opts.FailExpired); err != nil {
+		return err
+	}
+	for name := range secrets {
+		if !shell.ValidName(name) {
+			fmt.Fprintf(stderr, "Warning: skipping %s/%s, not a valid environment variable name\n", opts.Vault, name)
+			delete(secrets, name)
+		}
+	}
+	if len(secrets) == 0 {
+		fmt.Fprintf(stderr, "Warning: no secrets to load from %s\n", opts.Vault)
+	}
+
+	// direnv runs .envrc with bash. Watching the database reloads the
+	// environment whenever a secret changes.
+	if deps.Config != nil && deps.Config.DbPath != "" {
+		if path, err := filepath.Abs(deps.Config.DbPath); err == nil {
+			fmt.Fprintf(stdout, "watch_file %s\n", shell.QuotePOSIX(path))
+		}
+	}
+	out, err := shell.Marshal("bash", secrets)
+	if err != nil {
+		return err
+	}
+
+	_, err = stdout.Write(out)
+	return err
+}
+
+func (c *DirenvCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil direnv <vault> [flags]")
+	fmt.Fprintln(w, "       veil direnv --stdlib")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Print a vault's secrets for direnv, so entering a project directory")
+	fmt.Fprintln(w, "loads them. Fails if the vault does not exist. The output also asks")
+	fmt.Fprintln(w, "direnv to watch the database, so changed secrets are picked up.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Setup, once:")
+	fmt.Fprintln(w, "  veil direnv --stdlib >> ~/.config/direnv/direnvrc")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Then in a project's .envrc:")
+	fmt.Fprintln(w, "  use veil dev")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Inside a project with a .veil.toml, <vault> and the filters default to")
+	fmt.Fprintln(w, "its settings, so .envrc can just say 'use veil'.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --stdlib             Print the use_veil function for direnvrc")
+	fmt.Fprintln(w, "  --include <pattern>  Include only matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --exclude <pattern>  Exclude matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --fail-expired       Refuse to load expired secrets")
+	fmt.Fprintln(w, "  --help, -h           Show this help message")
+}
+
+func init() {
+	Register(NewDirenvCommand())
+}
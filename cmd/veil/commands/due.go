@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+)
+
+// DueCommand lists secrets that have expired or are due for rotation.
+type DueCommand struct {
+	BaseCommand
+}
+
+func NewDueCommand() *DueCommand {
+	return &DueCommand{
+		BaseCommand: NewBaseCommand("due", "List secrets that are expired or due for rotation"),
+	}
+}
+
+func (c *DueCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	opts, err := flags.ParseDueFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	now := time.Now()
+	due, err := deps.App.Due(now, opts.Within)
+	if err != nil {
+		return err
+	}
+
+	var overdue, upcoming []app.DueSecret
+	for _, d := range due {
+		if d.Overdue {
+			overdue = append(overdue, d)
+		} else {
+			upcoming = append(upcoming, d)
+		}
+	}
+
+	if len(due) == 0 {
+		fmt.Fprintln(stdout, "No secrets are expired or due for rotation.")
+		return nil
+	}
+
+	if len(overdue) > 0 {
+		fmt.Fprintln(stdout, "Overdue:")
+		printDue(stdout, overdue, now)
+	}
+	if len(upcoming) > 0 {
+		if len(overdue) > 0 {
+			fmt.Fprintln(stdout)
+		}
+		fmt.Fprintf(stdout, "Due within %s:\n", flags.FormatInterval(opts.Within))
+		printDue(stdout, upcoming, now)
+	}
+
+	if len(overdue) > 0 {
+		return fmt.Errorf("%d secrets are expired or overdue for rotation", len(overdue))
+	}
+	return nil
+}
+
+func printDue(w io.Writer, due []app.DueSecret, now time.Time) {
+	for _, d := range due {
+		fmt.Fprintf(w, "  %-40s %s\n", d.Vault+"/"+d.Name, describeDue(d, now))
+	}
+}
+
+// describeDue explains when and why a secret needs attention, e.g.
+// "expired 2026-03-01 (12 days ago)".
+func describeDue(d app.DueSecret, now time.Time) string {
+	if d.Reason == app.DueRotation {
+		every := flags.FormatInterval(d.RotateEvery)
+		if d.Date.IsZero() {
+			return fmt.Sprintf("rotation due, last update unknown (every %s)", every)
+		}
+		return fmt.Sprintf("rotation due %s, %s (every %s)", d.Date.Local().Format("2006-01-02"), relativeDays(d.Date, now), every)
+	}
+
+	verb := "expires"
+	if d.Overdue {
+		verb = "expired"
+	}
+	return fmt.Sprintf("%s %s, %s", verb, d.Date.Local().Format("2006-01-02"), relativeDays(d.Date, now))
+}
+
+// relativeDays describes t relative to now in whole days.
+func relativeDays(t, now time.Time) string {
+	days := int(math.Round(t.Sub(now).Hours() / 24))
+	switch {
+	case days == 0:
+		return "today"
+	case days == 1:
+		return "in 1 day"
+	case days > 1:
+		return fmt.Sprintf("in %d days", days)
+	case days == -1:
+		return "1 day ago"
+	default:
+		return fmt.Sprintf("%d days ago", -days)
+	}
+}
+
+// checkExpired warns on w about expired secrets among names, or fails if
+// fail is set, before their values are handed out.
+func checkExpired(w io.Writer, deps Dependencies, vault string, names []string, fail bool) error {
+	expired, err := deps.App.Expired(vault, names, time.Now())
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+
+	if fail {
+		if len(expired) == 1 {
+			return fmt.Errorf("%w: %s/%s expired on %s", app.ErrSecretExpired, vault, expired[0].Name, expired[0].Date.Local().Format("2006-01-02"))
+		}
+		return fmt.Errorf("%w: %d secrets in %s have expired (see 'veil due')", app.ErrSecretExpired, len(expired), vault)
+	}
+	for _, d := range expired {
+		fmt.Fprintf(w, "Warning: %s/%s expired on %s\n", vault, d.Name, d.Date.Local().Format("2006-01-02"))
+	}
+	return nil
+}
+
+func (c *DueCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil due [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "List secrets in all vaults that have expired or are due for rotation,")
+	fmt.Fprintln(w, "and those that will be soon. Exits with status 1 if any are overdue,")
+	fmt.Fprintln(w, "so it can run in CI or cron.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Set expiry dates and rotation intervals with 'veil set' or 'veil generate'")
+	fmt.Fprintln(w, "and the --expires and --rotate-every flags.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --within <interval>  Also list secrets due within this window (default: 14d)")
+	fmt.Fprintln(w, "  --help, -h           Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil due")
+	fmt.Fprintln(w, "  veil due --within 30d")
+}
+
+func init() {
+	Register(NewDueCommand())
+}
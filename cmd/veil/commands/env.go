@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/encoding/shell"
+)
+
+// EnvCommand prints shell statements that export a vault's secrets.
+type EnvCommand struct {
+	BaseCommand
+}
+
+func NewEnvCommand() *EnvCommand {
+	return &EnvCommand{
+		BaseCommand: NewBaseCommand("env", "Print shell statements that export vault secrets"),
+	}
+}
+
+// UsesProject returns true because env takes its vault and filters
+// from the project file.
+func (c *EnvCommand) UsesProject() bool { return true }
+
+func (c *EnvCommand) Execute(args []string, deps Dependencies) error {
+	args, err := withProjectVault(args, 1, deps)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return &UsageError{
+			Command: "env",
+			Usage:   "veil env <vault> [--shell bash|zsh|fish|powershell]",
+		}
+	}
+
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := deps.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	if args[0] == "--help" || args[0] == "-h" {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	vault := args[0]
+	opts, err := flags.ParseEnvFlags(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	include, exclude := projectFilters(deps, opts.Include, opts.Exclude)
+	secrets, err := deps.App.RunEnv(vault, include, exclude)
+	if err != nil {
+		return err
+	}
+
+	// Everything but the statements goes to stderr, since stdout is
+	// evaluated by the shell.
+	if err := checkExpired(stderr, deps, vault, slices.Sorted(maps.Keys(secrets)), opts.FailExpired); err != nil {
+		return err
+	}
+	for name := range secrets {
+		if !shell.ValidName(name) {
+			fmt.Fprintf(stderr, "Warning: skipping %s/%s, not a valid environment variable name\n", vault, name)
+			delete(secrets, name)
+		}
+	}
+
+	sh := opts.Shell
+	if sh == "" {
+		sh = detectShell(os.Getenv("SHELL"))
+	}
+	out, err := shell.Marshal(sh, secrets)
+	if err != nil {
+		return err
+	}
+
+	_, err = stdout.Write(out)
+	return err
+}
+
+// detectShell returns the shell named by $SHELL if it is supported, and
+// bash otherwise.
+func detectShell(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	if slices.Contains(shell.Shells, name) {
+		return name
+	}
+	return "bash"
+}
+
+func (c *EnvCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil env <vault> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Print statements that set the vault's secrets as environment variables,")
+	fmt.Fprintln(w, "for a shell to evaluate. Unlike 'veil run', this loads secrets into the")
+	fmt.Fprintln(w, "current shell. Values are quoted so they are set exactly, including")
+	fmt.Fprintln(w, "quotes and newlines. Secrets whose names are not valid variable names")
+	fmt.Fprintln(w, "are skipped with a warning.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Inside a project with a .veil.toml, <vault> and the filters default to")
+	fmt.Fprintln(w, "its settings.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --shell <shell>      bash, zsh, sh, fish, powershell or pwsh (default: from $SHELL, else bash)")
+	fmt.Fprintln(w, "  --include <pattern>  Include only matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --exclude <pattern>  Exclude matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --fail-expired       Refuse to print expired secrets")
+	fmt.Fprintln(w, "  --help, -h           Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  eval \"$(veil env dev)\"")
+	fmt.Fprintln(w, "  veil env dev --shell fish | source")
+	fmt.Fprintln(w, "  veil env dev --shell powershell | Out-String | Invoke-Expression")
+}
+
+func init() {
+	Register(NewEnvCommand())
+}
@@ -7,6 +7,10 @@ import (
 
 var (
 	ErrUnknownCommand = errors.New("unknown command")
+
+	// ErrDifferences is returned by diff when the two sides differ, so the
+	// command exits with a non-zero status.
+	ErrDifferences = errors.New("differences found")
 )
 
 // UsageError indicates incorrect command usage.
@@ -7,6 +7,7 @@ import (
 
 	"github.com/ossydotpy/veil/cmd/veil/flags"
 	"github.com/ossydotpy/veil/internal/exporter"
+	"github.com/ossydotpy/veil/internal/fsutil"
 )
 
 // ExportCommand exports vault secrets to a file.
@@ -20,7 +21,15 @@ func NewExportCommand() *ExportCommand {
 	}
 }
 
+// UsesProject returns true because export takes its vault and filters
+// from the project file.
+func (c *ExportCommand) UsesProject() bool { return true }
+
 func (c *ExportCommand) Execute(args []string, deps Dependencies) error {
+	args, err := withProjectVault(args, 1, deps)
+	if err != nil {
+		return err
+	}
 	if len(args) < 1 {
 		return &UsageError{
 			Command: "export",
@@ -34,16 +43,27 @@ func (c *ExportCommand) Execute(args []string, deps Dependencies) error {
 	}
 
 	vault := args[0]
-	opts, err := flags.ParseExportFlags(args[1:])
+	var defaults flags.ExportDefaults
+	if deps.Project != nil {
+		defaults = flags.ExportDefaults{TargetPath: deps.Project.ExportPath(), Format: deps.Project.Export.Format}
+	}
+	opts, err := flags.ParseExportFlags(args[1:], defaults)
 	if err != nil {
 		return err
 	}
+	opts.Include, opts.Exclude = projectFilters(deps, opts.Include, opts.Exclude)
 
 	if opts.ShowHelp {
 		c.printHelp(stdout)
 		return nil
 	}
 
+	// A new Secret is named after the vault; when appending, the name
+	// in the existing manifest is kept.
+	if opts.Format == "k8s" && opts.Manifest.Name == "" && !(opts.Append && fsutil.FileExists(opts.TargetPath)) {
+		opts.Manifest.Name = vault
+	}
+
 	preview, err := deps.App.Export(vault, opts.ExportOptions)
 	if err != nil {
 		return err
@@ -106,15 +126,27 @@ func printExportResult(w io.Writer, preview *exporter.Preview, opts exporter.Exp
 func (c *ExportCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w, "Usage: veil export <vault> [flags]")
 	fmt.Fprintln(w)
-	fmt.Fprintln(w, "Export vault secrets to a file.")
+	fmt.Fprintln(w, "Export vault secrets to a file. With --append, json and yaml files are")
+	fmt.Fprintln(w, "merged with the keys they already hold.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Inside a project with a .veil.toml, <vault>, the filters, --to and")
+	fmt.Fprintln(w, "--format default to its settings.")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Flags:")
-	fmt.Fprintln(w, "  --to <path>      Output file path (default: .env)")
-	fmt.Fprintln(w, "  --format <fmt>   Output format: env (default: env)")
+	fmt.Fprintln(w, "  --to <path>      Output file path (default: .env, secrets.json, secrets.yaml or secret.yaml)")
+	fmt.Fprintln(w, "  --format <fmt>   Output format: env, json, yaml or k8s (default: from --to, else env)")
 	fmt.Fprintln(w, "  --force          Overwrite existing file")
 	fmt.Fprintln(w, "  --append         Append to existing file")
 	fmt.Fprintln(w, "  --dry-run        Preview without writing")
 	fmt.Fprintln(w, "  --backup         Create backup before overwriting")
+	fmt.Fprintln(w, "  --raw            Export values without expanding references")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Kubernetes flags (--format k8s):")
+	fmt.Fprintln(w, "  --name <name>             Secret name (default: the vault name)")
+	fmt.Fprintln(w, "  --namespace <namespace>   Secret namespace")
+	fmt.Fprintln(w, "  --label <key=value>       Add a label (can be repeated)")
+	fmt.Fprintln(w, "  --annotation <key=value>  Add an annotation (can be repeated)")
+	fmt.Fprintln(w, "  --string-data             Write plain values under stringData instead of base64 data")
 	fmt.Fprintln(w, "  --include        Include only matching keys (can be repeated)")
 	fmt.Fprintln(w, "  --exclude        Exclude matching keys (can be repeated)")
 	fmt.Fprintln(w, "  --help, -h       Show this help message")
@@ -124,6 +156,8 @@ func (c *ExportCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w, "  veil export production --to .env.production")
 	fmt.Fprintln(w, "  veil export production --append --to .env")
 	fmt.Fprintln(w, "  veil export production --dry-run")
+	fmt.Fprintln(w, "  veil export production --to config/secrets.json")
+	fmt.Fprintln(w, "  veil export production --format k8s --to secret.yaml --namespace web")
 	fmt.Fprintln(w, "  veil export production --include 'DB_*' --include 'API_*'")
 }
 
@@ -49,7 +49,10 @@ func (c *GenerateCommand) Execute(args []string, deps Dependencies) error {
 		return nil
 	}
 
-	secret, err := deps.App.Generate(vault, name, opts.Options)
+	secret, err := deps.App.Generate(vault, name, opts.Options, app.MetadataUpdate{
+		ExpiresAt:   opts.ExpiresAt,
+		RotateEvery: opts.RotateEvery,
+	})
 	if err != nil {
 		// Check if it's a warning about existing key in .env
 		if errors.Is(err, app.ErrKeyExistsInEnv) {
@@ -90,6 +93,9 @@ func (c *GenerateCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w, "  --bits N         JWT secret bits: 128-512 (default: 256)")
 	fmt.Fprintln(w, "  --to-env <path>  Append generated secret to .env file")
 	fmt.Fprintln(w, "  --force          Overwrite existing key in .env file")
+	fmt.Fprintln(w, "  --expires <when> Expiry of the new value: a date, or an interval from now (90d)")
+	fmt.Fprintln(w, "  --rotate-every <interval>")
+	fmt.Fprintln(w, "                   How often the secret should be rotated (90d, 12w, none)")
 	fmt.Fprintln(w, "  --help, -h       Show this help message")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Examples:")
@@ -97,6 +103,7 @@ func (c *GenerateCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w, "  veil generate production API_KEY --type apikey --length 48")
 	fmt.Fprintln(w, "  veil generate production JWT_SECRET --type jwt --bits 512")
 	fmt.Fprintln(w, "  veil generate production DB_PASSWORD --to-env .env --force")
+	fmt.Fprintln(w, "  veil generate production DB_PASSWORD --rotate-every 90d")
 }
 
 func init() {
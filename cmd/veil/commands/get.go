@@ -3,8 +3,10 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/ossydotpy/veil/cmd/veil/flags"
 	"github.com/ossydotpy/veil/internal/crypto"
 	"github.com/ossydotpy/veil/internal/store"
 )
@@ -20,11 +22,19 @@ func NewGetCommand() *GetCommand {
 	}
 }
 
+// UsesProject returns true because get takes its vault and filters
+// from the project file.
+func (c *GetCommand) UsesProject() bool { return true }
+
 func (c *GetCommand) Execute(args []string, deps Dependencies) error {
-	if len(args) != 2 {
+	args, err := withProjectVault(args, 2, deps)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
 		return &UsageError{
 			Command: "get",
-			Usage:   "veil get <vault> <name>",
+			Usage:   "veil get <vault> <name> [--fail-expired] [--raw]",
 		}
 	}
 
@@ -32,10 +42,27 @@ func (c *GetCommand) Execute(args []string, deps Dependencies) error {
 	if stdout == nil {
 		stdout = os.Stdout
 	}
+	stderr := deps.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
 
 	vault, name := args[0], args[1]
+	opts, err := flags.ParseGetFlags(args[2:])
+	if err != nil {
+		return err
+	}
 
-	val, err := deps.App.Get(vault, name)
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	get := deps.App.Get
+	if opts.Raw {
+		get = deps.App.GetRaw
+	}
+	val, err := get(vault, name)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			return fmt.Errorf("secret not found")
@@ -46,17 +73,41 @@ func (c *GetCommand) Execute(args []string, deps Dependencies) error {
 		return err
 	}
 
+	if err := checkExpired(stderr, deps, vault, []string{name}, opts.FailExpired); err != nil {
+		return err
+	}
+
 	fmt.Fprint(stdout, val)
 	return nil
 }
 
+func (c *GetCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil get <vault> <name> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Print a secret's value, without a trailing newline. A warning is")
+	fmt.Fprintln(w, "printed to stderr if the value has expired.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "If the secret was stored with 'veil set --template', references to")
+	fmt.Fprintln(w, "other secrets, ${NAME} or ${vault/NAME}, are expanded unless --raw is")
+	fmt.Fprintln(w, "given. Other values are always printed as stored.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Inside a project with a .veil.toml, <vault> defaults to its vault.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --fail-expired   Refuse to print an expired value")
+	fmt.Fprintln(w, "  --raw            Print the stored value without expanding references")
+	fmt.Fprintln(w, "  --help, -h       Show this help message")
+}
+
 // isCryptoError checks if an error is related to cryptographic operations.
 func isCryptoError(err error) bool {
 	if err == nil {
 		return false
 	}
 	return errors.Is(err, crypto.ErrDecryptionFailed) ||
-		errors.Is(err, crypto.ErrCiphertextTooShort)
+		errors.Is(err, crypto.ErrCiphertextTooShort) ||
+		errors.Is(err, crypto.ErrInvalidEnvelope) ||
+		errors.Is(err, crypto.ErrUnknownKeyID)
 }
 
 func init() {
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// HistoryCommand lists the archived versions of a secret.
+type HistoryCommand struct {
+	BaseCommand
+}
+
+func NewHistoryCommand() *HistoryCommand {
+	return &HistoryCommand{
+		BaseCommand: NewBaseCommand("history", "Show previous versions of a secret"),
+	}
+}
+
+func (c *HistoryCommand) Execute(args []string, deps Dependencies) error {
+	if len(args) < 2 {
+		return &UsageError{
+			Command: "history",
+			Usage:   "veil history <vault> <name> [--show]",
+		}
+	}
+
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	vault, name := args[0], args[1]
+	opts, err := flags.ParseHistoryFlags(args[2:])
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	versions, err := deps.App.History(vault, name)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("secret not found")
+		}
+		return err
+	}
+
+	if len(versions) == 0 {
+		fmt.Fprintf(stdout, "No previous versions of %s/%s\n", vault, name)
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "History of %s/%s (newest first):\n", vault, name)
+	for _, v := range versions {
+		replaced := v.ArchivedAt.Local().Format("2006-01-02 15:04:05")
+		if !opts.Show {
+			fmt.Fprintf(stdout, "  v%-4d replaced %s\n", v.Number, replaced)
+			continue
+		}
+
+		value, err := deps.App.GetVersion(vault, name, v.Number)
+		if err != nil {
+			if isCryptoError(err) {
+				return fmt.Errorf("decryption failed (check your MASTER_KEY)")
+			}
+			return err
+		}
+		fmt.Fprintf(stdout, "  v%-4d replaced %s  %s\n", v.Number, replaced, value)
+	}
+
+	return nil
+}
+
+func (c *HistoryCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil history <vault> <name> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Show previous versions of a secret. Every time a secret is")
+	fmt.Fprintln(w, "overwritten (set, generate, import --force) the old value is kept.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --show           Print the decrypted value of each version")
+	fmt.Fprintln(w, "  --help, -h       Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil history production DB_PASSWORD")
+	fmt.Fprintln(w, "  veil history production DB_PASSWORD --show")
+}
+
+func init() {
+	Register(NewHistoryCommand())
+}
@@ -113,7 +113,7 @@ func (c *ImportCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Flags:")
 	fmt.Fprintln(w, "  --from <path>    Source file path (required)")
-	fmt.Fprintln(w, "  --format <fmt>   Input format: env (default: env)")
+	fmt.Fprintln(w, "  --format <fmt>   Input format: env, json, yaml or k8s (default: from --from)")
 	fmt.Fprintln(w, "  --force          Overwrite existing vault keys")
 	fmt.Fprintln(w, "  --dry-run        Preview without importing")
 	fmt.Fprintln(w, "  --include        Include only matching keys (can be repeated)")
@@ -125,6 +125,8 @@ func (c *ImportCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w, "  veil import production --from .env --include 'DB_*' --include 'API_*'")
 	fmt.Fprintln(w, "  veil import production --from .env --dry-run")
 	fmt.Fprintln(w, "  veil import production --from .env --force")
+	fmt.Fprintln(w, "  veil import production --from config.yaml")
+	fmt.Fprintln(w, "  veil import production --from secret.yaml --format k8s")
 }
 
 func init() {
@@ -2,11 +2,16 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
 
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
 	"github.com/ossydotpy/veil/internal/config"
 	"github.com/ossydotpy/veil/internal/crypto"
 	"github.com/ossydotpy/veil/internal/fsutil"
+	"github.com/ossydotpy/veil/internal/prompt"
+	"github.com/ossydotpy/veil/internal/store/factory"
 )
 
 // InitCommand generates a new master key for the vault.
@@ -33,7 +38,28 @@ func (c *InitCommand) Execute(args []string, deps Dependencies) error {
 		stderr = os.Stderr
 	}
 
-	cfg := config.LoadConfig()
+	opts, err := flags.ParseInitFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig(deps.Profile)
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+	if opts.Passphrase {
+		stdin := deps.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+		return c.initPassphrase(cfg, opts, stdin, stdout, stderr)
+	}
+
 	if fsutil.FileExists(cfg.DbPath) {
 		fmt.Fprintf(stderr, "Warning: A database already exists at %s\n", cfg.DbPath)
 		fmt.Fprintf(stderr, "Generating a new key and using it will make all existing secrets UNREADABLE.\n\n")
@@ -51,6 +77,85 @@ func (c *InitCommand) Execute(args []string, deps Dependencies) error {
 	return nil
 }
 
+// initPassphrase stores fresh KDF parameters in the database so that the
+// master key is derived from a passphrase instead of read from MASTER_KEY.
+func (c *InitCommand) initPassphrase(cfg *config.Config, opts flags.InitOptions, stdin io.Reader, stdout, stderr io.Writer) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	s, err := factory.NewStore(cfg.StoreType, cfg.DbPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer s.Close()
+
+	existing, err := app.LoadKDFParams(s)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("database at %s is already protected by a passphrase", cfg.DbPath)
+	}
+	for _, err := range s.ListVaults() {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("database at %s already contains secrets; run 'veil rekey --passphrase' to switch it to a passphrase", cfg.DbPath)
+	}
+
+	// Like every other command, init reads VEIL_PASSPHRASE_FILE unless a
+	// file is given on the command line.
+	path := opts.PassphraseFile
+	if path == "" {
+		path = cfg.PassphraseFile
+	}
+	passphrase, err := prompt.Passphrase(path, stdin, stderr, true)
+	if err != nil {
+		return err
+	}
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		return err
+	}
+	engine, err := crypto.NewEngineFromPassphrase(passphrase, params)
+	if err != nil {
+		return err
+	}
+	if err := params.Seal(engine); err != nil {
+		return err
+	}
+	if err := app.SaveKDFParams(s, params); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout, Logo)
+	fmt.Fprintf(stdout, "\nDatabase at %s is now protected by your passphrase.\n", cfg.DbPath)
+	fmt.Fprintln(stdout, "Leave MASTER_KEY unset; veil will ask for the passphrase when it needs it.")
+	fmt.Fprintln(stdout, "Set VEIL_PASSPHRASE_FILE to read it from a file instead (use - for stdin).")
+	fmt.Fprintln(stdout, "\nREMEMBER THIS PASSPHRASE! If you lose it, your secrets are gone forever.")
+
+	return nil
+}
+
+func (c *InitCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil init [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Generate a new master key, or protect the database with a passphrase.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --passphrase              Derive the master key from a passphrase (Argon2id)")
+	fmt.Fprintln(w, "  --passphrase-file <path>  Read the passphrase from a file (- for stdin);")
+	fmt.Fprintln(w, "                            defaults to VEIL_PASSPHRASE_FILE")
+	fmt.Fprintln(w, "  --help, -h                Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil init")
+	fmt.Fprintln(w, "  veil init --passphrase")
+	fmt.Fprintln(w, "  veil init --passphrase-file ~/.config/veil/passphrase")
+}
+
 func init() {
 	Register(NewInitCommand())
 }
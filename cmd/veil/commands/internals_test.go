@@ -0,0 +1,4 @@
+package commands
+
+// HostEnv exposes hostEnv to the external test package.
+var HostEnv = hostEnv
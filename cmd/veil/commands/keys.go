@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/ossydotpy/veil/internal/app"
+)
+
+// KeysCommand lists the keys in the key ring and what each one protects.
+type KeysCommand struct {
+	BaseCommand
+}
+
+func NewKeysCommand() *KeysCommand {
+	return &KeysCommand{
+		BaseCommand: NewBaseCommand("keys", "List master keys and what each one encrypts"),
+	}
+}
+
+func (c *KeysCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	for _, arg := range args {
+		if arg == "--help" || arg == "-h" {
+			c.printHelp(stdout)
+			return nil
+		}
+		return &UsageError{
+			Command: "keys",
+			Usage:   "veil keys",
+		}
+	}
+
+	usage, err := deps.App.KeyUsage()
+	if err != nil {
+		return err
+	}
+
+	ids := deps.Engine.KeyIDs()
+	for i, id := range ids {
+		status := ""
+		if i == 0 {
+			status = "primary"
+		}
+		printKeyUse(stdout, id, status, usage[id])
+		delete(usage, id)
+	}
+
+	legacy, hasLegacy := usage[""]
+	delete(usage, "")
+
+	if len(usage) > 0 {
+		fmt.Fprintln(stdout)
+		fmt.Fprintln(stdout, "Encrypted with keys not in the ring (cannot be decrypted):")
+		unknown := slices.Sorted(maps.Keys(usage))
+		for _, id := range unknown {
+			printKeyUse(stdout, id, "", usage[id])
+		}
+	}
+
+	if hasLegacy {
+		fmt.Fprintln(stdout)
+		fmt.Fprintf(stdout, "%d values use an older format that does not record a key.\n", legacy.Values)
+	}
+
+	return nil
+}
+
+func printKeyUse(w io.Writer, id, status string, use app.KeyUse) {
+	nameKey := ""
+	if use.NameKey {
+		nameKey = ", name key"
+	}
+	fmt.Fprintf(w, "  %-20s %-8s %d vault keys, %d values%s\n", id, status, use.VaultKeys, use.Values, nameKey)
+}
+
+func (c *KeysCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil keys")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "List the master keys in use, primary first, with the number of vault")
+	fmt.Fprintln(w, "keys each one wraps and of values still encrypted directly with it.")
+	fmt.Fprintln(w, "New vault keys are always wrapped with the primary key.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "To retire a key, make another key primary, run 'veil upgrade' to")
+	fmt.Fprintln(w, "re-wrap everything with it, then remove the old key once it")
+	fmt.Fprintln(w, "protects nothing.")
+}
+
+func init() {
+	Register(NewKeysCommand())
+}
@@ -2,7 +2,13 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
 )
 
 // ListCommand lists all secret names in a vault.
@@ -17,28 +23,117 @@ func NewListCommand() *ListCommand {
 }
 
 func (c *ListCommand) Execute(args []string, deps Dependencies) error {
-	if len(args) != 1 {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if len(args) < 1 {
 		return &UsageError{
 			Command: "list",
-			Usage:   "veil list <vault>",
+			Usage:   "veil list <vault> [--long] [--tag <tag>] [--resolved]",
 		}
 	}
 
-	stdout := deps.Stdout
-	if stdout == nil {
-		stdout = os.Stdout
+	vault := args[0]
+	opts, err := flags.ParseListFlags(args[1:])
+	if err != nil {
+		return err
 	}
 
-	vault := args[0]
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
 
-	for name, err := range deps.App.List(vault) {
+	if opts.Resolved {
+		refs, err := deps.App.ResolvedRefs(vault)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintln(stdout, name)
+		tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tFROM")
+		for _, ref := range refs {
+			fmt.Fprintf(tw, "%s\t%s\n", ref.Name, ref.Vault)
+		}
+		return tw.Flush()
+	}
+
+	if !opts.Long && opts.Tag == "" {
+		for name, err := range deps.App.List(vault) {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, name)
+		}
+		return nil
+	}
+
+	infos, err := deps.App.ListMetadata(vault, opts.Tag)
+	if err != nil {
+		return err
+	}
+
+	if !opts.Long {
+		for _, info := range infos {
+			fmt.Fprintln(stdout, info.Name)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tUPDATED\tOWNER\tTAGS\tDESCRIPTION")
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			info.Name,
+			formatDate(info.UpdatedAt),
+			orDash(info.Owner),
+			orDash(strings.Join(info.Tags, ",")),
+			info.Description,
+		)
+	}
+	return tw.Flush()
+}
+
+// formatDate formats a metadata timestamp in local time, or "-" if it was
+// not recorded.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
 	}
+	return t.Local().Format("2006-01-02")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
 
-	return nil
+func (c *ListCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil list <vault> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "List the secret names in a vault.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --long, -l       Show when each secret was last updated, its owner,")
+	fmt.Fprintln(w, "                   tags and description")
+	fmt.Fprintln(w, "  --tag <tag>      List only secrets with this tag")
+	fmt.Fprintln(w, "  --resolved       Include secrets inherited from parent vaults, with")
+	fmt.Fprintln(w, "                   the vault each value comes from")
+	fmt.Fprintln(w, "  --help, -h       Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil list production")
+	fmt.Fprintln(w, "  veil list production --long")
+	fmt.Fprintln(w, "  veil list production --tag payments")
+	fmt.Fprintln(w, "  veil list production --resolved")
 }
 
 func init() {
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+)
+
+// MvCommand moves or renames a secret.
+type MvCommand struct {
+	BaseCommand
+}
+
+func NewMvCommand() *MvCommand {
+	return &MvCommand{
+		BaseCommand: NewBaseCommand("mv", "Move or rename a secret"),
+	}
+}
+
+func (c *MvCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	opts, err := flags.ParseMoveFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if err := deps.App.Move(opts.Vault, opts.Name, opts.NewVault, opts.NewName, opts.Force); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Moved %s/%s to %s/%s.\n", opts.Vault, opts.Name, opts.NewVault, opts.NewName)
+	return nil
+}
+
+func (c *MvCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil mv <vault> <name> <new-vault> [<new-name>] [--force]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Move a secret with its history and metadata to another vault, another")
+	fmt.Fprintln(w, "name, or both. The name is kept if no new name is given.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --force    Move an existing destination secret to the trash first")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil mv staging API_KEY production")
+	fmt.Fprintln(w, "  veil mv production DB_PASS production DB_PASSWORD")
+}
+
+func init() {
+	Register(NewMvCommand())
+}
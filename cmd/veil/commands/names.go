@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/internal/app"
+)
+
+// NamesCommand shows or changes whether vault and secret names are
+// encrypted at rest.
+type NamesCommand struct {
+	BaseCommand
+}
+
+func NewNamesCommand() *NamesCommand {
+	return &NamesCommand{
+		BaseCommand: NewBaseCommand("names", "Encrypt or decrypt vault and secret names at rest"),
+	}
+}
+
+func (c *NamesCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	usage := &UsageError{
+		Command: "names",
+		Usage:   "veil names [status|encrypt|decrypt]",
+	}
+
+	action := "status"
+	switch len(args) {
+	case 0:
+	case 1:
+		action = args[0]
+	default:
+		return usage
+	}
+
+	switch action {
+	case "--help", "-h":
+		c.printHelp(stdout)
+		return nil
+
+	case "status":
+		if deps.App.NamesEncrypted() {
+			fmt.Fprintln(stdout, "Vault and secret names are encrypted.")
+		} else {
+			fmt.Fprintln(stdout, "Vault and secret names are stored in plaintext. Run 'veil names encrypt' to encrypt them.")
+		}
+		return nil
+
+	case "encrypt":
+		stats, err := deps.App.EncryptNames()
+		if err != nil {
+			if errors.Is(err, app.ErrNamesEncrypted) {
+				fmt.Fprintln(stdout, "Vault and secret names are already encrypted.")
+				return nil
+			}
+			return err
+		}
+		fmt.Fprintf(stdout, "Encrypted the names of %d secrets in %d vaults.\n", stats.Secrets, stats.Vaults)
+		return nil
+
+	case "decrypt":
+		stats, err := deps.App.DecryptNames()
+		if err != nil {
+			if errors.Is(err, app.ErrNamesNotEncrypted) {
+				fmt.Fprintln(stdout, "Vault and secret names are already stored in plaintext.")
+				return nil
+			}
+			return err
+		}
+		fmt.Fprintf(stdout, "Decrypted the names of %d secrets in %d vaults.\n", stats.Secrets, stats.Vaults)
+		return nil
+
+	default:
+		return usage
+	}
+}
+
+func (c *NamesCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil names [status|encrypt|decrypt]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "By default vault and secret names are stored in plaintext, so a copy of")
+	fmt.Fprintln(w, "the database reveals which credentials it holds. With names encrypted,")
+	fmt.Fprintln(w, "each name is stored as a deterministic token that only the master key")
+	fmt.Fprintln(w, "can reverse; get, list, vaults and search work as before. Descriptions,")
+	fmt.Fprintln(w, "owners, tags, expiry and rotation settings are encrypted along with them.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Actions:")
+	fmt.Fprintln(w, "  status     Show whether names are encrypted (default)")
+	fmt.Fprintln(w, "  encrypt    Encrypt all names in one transaction")
+	fmt.Fprintln(w, "  decrypt    Store all names in plaintext again")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Search decrypts every name to match it, so it is slower on large")
+	fmt.Fprintln(w, "databases. The database file is compacted afterwards so old names do")
+	fmt.Fprintln(w, "not linger in free pages.")
+}
+
+func init() {
+	Register(NewNamesCommand())
+}
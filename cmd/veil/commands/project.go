@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// withProjectVault prepends the vault set in the project file to args
+// when the vault was left out, that is when args start with fewer than
+// positionals arguments before the first flag. Without a project file
+// args, or a request for help, are returned unchanged; an invalid project
+// file is an error.
+func withProjectVault(args []string, positionals int, deps Dependencies) ([]string, error) {
+	if slices.Contains(args, "--help") || slices.Contains(args, "-h") {
+		return args, nil
+	}
+	if deps.ProjectErr != nil {
+		return nil, deps.ProjectErr
+	}
+	if deps.Project == nil {
+		return args, nil
+	}
+
+	given := 0
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			break
+		}
+		given++
+	}
+	if given >= positionals {
+		return args, nil
+	}
+
+	if deps.Project.Vault == "" {
+		return nil, fmt.Errorf("no vault given and %s does not set one", deps.Project.Path)
+	}
+	return append([]string{deps.Project.Vault}, args...), nil
+}
+
+// projectFilters returns include and exclude, or the project file's
+// filters if neither is given on the command line.
+func projectFilters(deps Dependencies, include, exclude []string) ([]string, []string) {
+	if deps.Project == nil || len(include) > 0 || len(exclude) > 0 {
+		return include, exclude
+	}
+	return deps.Project.Include, deps.Project.Exclude
+}
@@ -25,6 +25,10 @@ func TestRegistry_Get(t *testing.T) {
 		{name: "generate command exists", cmdName: "generate", wantErr: false},
 		{name: "run command exists", cmdName: "run", wantErr: false},
 		{name: "reset command exists", cmdName: "reset", wantErr: false},
+		{name: "history command exists", cmdName: "history", wantErr: false},
+		{name: "rollback command exists", cmdName: "rollback", wantErr: false},
+		{name: "rekey command exists", cmdName: "rekey", wantErr: false},
+		{name: "upgrade command exists", cmdName: "upgrade", wantErr: false},
 		{name: "unknown command", cmdName: "nonexistent", wantErr: true},
 	}
 
@@ -48,10 +52,13 @@ func TestRegistry_Get(t *testing.T) {
 func TestRegistry_All(t *testing.T) {
 	all := commands.All()
 
-	// Should have all 13 commands registered
+	// Should have all commands registered
 	expectedCommands := []string{
 		"version", "init", "quick", "set", "get", "delete",
 		"list", "vaults", "search", "export", "generate", "reset", "import", "run",
+		"history", "rollback", "rekey", "upgrade", "keys", "names",
+		"describe", "tag", "due", "rotate", "trash", "restore",
+		"vault", "mv", "cp", "diff", "sync", "env", "direnv", "config",
 	}
 
 	if len(all) != len(expectedCommands) {
@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/prompt"
+)
+
+// RekeyCommand switches the database to a new master key, or rotates the
+// key of a single vault.
+type RekeyCommand struct {
+	BaseCommand
+}
+
+func NewRekeyCommand() *RekeyCommand {
+	return &RekeyCommand{
+		BaseCommand: NewBaseCommand("rekey", "Switch to a new master key or rotate a vault key"),
+	}
+}
+
+func (c *RekeyCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	stderr := deps.Stderr
+	stdin := deps.Stdin
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	opts, err := flags.ParseRekeyFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if opts.Vault != "" {
+		return c.rotateVault(opts.Vault, deps.App, stdout)
+	}
+
+	var (
+		next   *crypto.Engine
+		params *crypto.KDFParams
+		newKey string
+	)
+
+	switch {
+	case opts.Generate:
+		newKey, err = crypto.GenerateRandomKey()
+		if err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+	case opts.NewKeyFile != "":
+		newKey, err = prompt.Passphrase(opts.NewKeyFile, stdin, stderr, false)
+		if err != nil {
+			return err
+		}
+	case opts.Passphrase:
+		passphrase, err := prompt.Passphrase(opts.PassphraseFile, stdin, stderr, true)
+		if err != nil {
+			return err
+		}
+		params, err = crypto.NewKDFParams()
+		if err != nil {
+			return err
+		}
+		next, err = crypto.NewEngineFromPassphrase(passphrase, params)
+		if err != nil {
+			return err
+		}
+		if err := params.Seal(next); err != nil {
+			return err
+		}
+	default:
+		return &UsageError{
+			Command: "rekey",
+			Usage:   "veil rekey --generate | --new-key-file <path> | --passphrase | --vault <vault>",
+		}
+	}
+
+	if newKey != "" {
+		next, err = crypto.NewEngine(newKey)
+		if err != nil {
+			return fmt.Errorf("invalid new key: %w", err)
+		}
+	}
+
+	// Show a generated key before touching the database so it cannot be
+	// lost if veil is interrupted after the commit.
+	if opts.Generate {
+		fmt.Fprintf(stdout, "Your new MASTER_KEY is:\n\n%s\n\nSAVE THIS KEY! If you lose it, your secrets are gone forever.\n\n", newKey)
+	}
+
+	stats, err := deps.App.Rekey(next, params)
+	if err != nil {
+		if opts.Generate {
+			fmt.Fprintln(stderr, "Rekey failed; the database still uses the old key. Discard the key shown above.")
+		}
+		if isCryptoError(err) {
+			return fmt.Errorf("rekey aborted, a secret could not be decrypted with the current key: %w", err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Rotated %d vault keys; re-encrypted %d secrets and %d previous versions.\n", stats.VaultKeys, stats.Secrets, stats.Versions)
+	switch {
+	case params != nil:
+		fmt.Fprintln(stdout, "The database is now protected by the new passphrase. Unset MASTER_KEY if it is set.")
+	case opts.Generate:
+		fmt.Fprintln(stdout, "Update your environment:\nexport MASTER_KEY="+newKey)
+	default:
+		fmt.Fprintln(stdout, "Update MASTER_KEY everywhere the old key was used.")
+	}
+
+	return nil
+}
+
+// rotateVault replaces the data key of a single vault.
+func (c *RekeyCommand) rotateVault(vault string, a *app.App, stdout io.Writer) error {
+	stats, err := a.RotateVaultKey(vault)
+	if err != nil {
+		if errors.Is(err, app.ErrVaultNotFound) {
+			return fmt.Errorf("vault %q not found", vault)
+		}
+		if isCryptoError(err) {
+			return fmt.Errorf("rekey aborted, a secret could not be decrypted with the current key: %w", err)
+		}
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Rotated the key of vault %s; re-encrypted %d secrets and %d previous versions.\n", vault, stats.Secrets, stats.Versions)
+	return nil
+}
+
+func (c *RekeyCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil rekey [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Switch the database to a new master key. Each vault's secrets are")
+	fmt.Fprintln(w, "encrypted with its own vault key, which is stored wrapped by the master")
+	fmt.Fprintln(w, "key. Every vault gets a new vault key wrapped by the new master key and")
+	fmt.Fprintln(w, "all values are re-encrypted with it, so neither the old master key nor")
+	fmt.Fprintln(w, "an old vault key opens the database afterwards. The current key")
+	fmt.Fprintln(w, "(MASTER_KEY or passphrase) is needed to decrypt the values. Everything is")
+	fmt.Fprintln(w, "rewritten in one transaction: if anything fails, the database keeps using")
+	fmt.Fprintln(w, "the old key.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Secrets someone already read stay known to them; rotate those as well.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "With --vault, the master key is kept and the named vault gets a new")
+	fmt.Fprintln(w, "vault key instead; only that vault's secrets are re-encrypted.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags (choose one):")
+	fmt.Fprintln(w, "  --generate                Generate a new random MASTER_KEY")
+	fmt.Fprintln(w, "  --new-key-file <path>     Read the new 64-hex-character key from a file (- for stdin)")
+	fmt.Fprintln(w, "  --passphrase              Switch to a new passphrase (prompted twice)")
+	fmt.Fprintln(w, "  --passphrase-file <path>  Read the new passphrase from a file (- for stdin)")
+	fmt.Fprintln(w, "  --vault <vault>           Rotate the key of one vault")
+	fmt.Fprintln(w, "  --help, -h                Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil rekey --generate")
+	fmt.Fprintln(w, "  veil rekey --new-key-file ./new.key")
+	fmt.Fprintln(w, "  veil rekey --passphrase")
+	fmt.Fprintln(w, "  veil rekey --vault production")
+}
+
+func init() {
+	Register(NewRekeyCommand())
+}
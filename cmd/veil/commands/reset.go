@@ -2,9 +2,15 @@ package commands
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"time"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/store"
 )
 
 // ResetCommand deletes all secrets from the database.
@@ -38,8 +44,22 @@ func (c *ResetCommand) Execute(args []string, deps Dependencies) error {
 		stdin = os.Stdin
 	}
 
-	fmt.Fprintf(stderr, "⚠️  WARNING: This will permanently DELETE ALL SECRETS in the database.\n")
-	fmt.Fprintf(stderr, "⚠️  Ensure you have backups before proceeding. This cannot be undone.\n")
+	opts, err := flags.ParseResetFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	fmt.Fprintf(stderr, "⚠️  WARNING: This will permanently DELETE ALL SECRETS in the database, including the trash.\n")
+	if opts.Snapshot {
+		fmt.Fprintf(stderr, "⚠️  A snapshot of the database will be saved first.\n")
+	} else {
+		fmt.Fprintf(stderr, "⚠️  Ensure you have backups before proceeding. This cannot be undone.\n")
+	}
 	fmt.Fprintf(stderr, "Are you sure? (type 'yes' to confirm): ")
 
 	reader := bufio.NewReader(stdin)
@@ -54,6 +74,15 @@ func (c *ResetCommand) Execute(args []string, deps Dependencies) error {
 		return nil
 	}
 
+	if opts.Snapshot {
+		path, err := snapshot(deps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Saved a snapshot of the database to %s.\n", path)
+		fmt.Fprintln(stdout, "To recover, move it back in place of the database; it opens with the same master key.")
+	}
+
 	if err := deps.Store.Nuke(); err != nil {
 		return err
 	}
@@ -62,6 +91,32 @@ func (c *ResetCommand) Execute(args []string, deps Dependencies) error {
 	return nil
 }
 
+// snapshot saves a copy of the database next to it, named after the time
+// it was taken, and returns its path.
+func snapshot(deps Dependencies) (string, error) {
+	s, ok := deps.Store.(store.Snapshotter)
+	if !ok || deps.Config == nil {
+		return "", errors.New("this store does not support snapshots")
+	}
+
+	path := fmt.Sprintf("%s.snapshot-%s", deps.Config.DbPath, time.Now().Format("20060102-150405"))
+	if err := s.Snapshot(path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (c *ResetCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil reset [--snapshot]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Delete every secret, vault key and setting, including the trash, after")
+	fmt.Fprintln(w, "asking for confirmation.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --snapshot    Save a copy of the database first, next to it as")
+	fmt.Fprintln(w, "                <database>.snapshot-<time>, so the reset can be undone")
+}
+
 func init() {
 	Register(NewResetCommand())
 }
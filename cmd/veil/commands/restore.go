@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+)
+
+// RestoreCommand moves a deleted secret back out of the trash.
+type RestoreCommand struct {
+	BaseCommand
+}
+
+func NewRestoreCommand() *RestoreCommand {
+	return &RestoreCommand{
+		BaseCommand: NewBaseCommand("restore", "Restore a deleted secret from the trash"),
+	}
+}
+
+func (c *RestoreCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	if len(args) != 2 {
+		return &UsageError{
+			Command: "restore",
+			Usage:   "veil restore <vault> <name>",
+		}
+	}
+
+	vault, name := args[0], args[1]
+
+	if err := deps.App.Restore(vault, name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Restored %s/%s.\n", vault, name)
+	return nil
+}
+
+func init() {
+	Register(NewRestoreCommand())
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// RollbackCommand restores a previous version of a secret.
+type RollbackCommand struct {
+	BaseCommand
+}
+
+func NewRollbackCommand() *RollbackCommand {
+	return &RollbackCommand{
+		BaseCommand: NewBaseCommand("rollback", "Restore a previous version of a secret"),
+	}
+}
+
+func (c *RollbackCommand) Execute(args []string, deps Dependencies) error {
+	if len(args) < 2 {
+		return &UsageError{
+			Command: "rollback",
+			Usage:   "veil rollback <vault> <name> --to <version>",
+		}
+	}
+
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	vault, name := args[0], args[1]
+	opts, err := flags.ParseRollbackFlags(args[2:])
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if opts.Version == 0 {
+		return &UsageError{
+			Command: "rollback",
+			Usage:   "veil rollback <vault> <name> --to <version>",
+		}
+	}
+
+	if err := deps.App.Rollback(vault, name, opts.Version); err != nil {
+		if errors.Is(err, store.ErrVersionNotFound) {
+			return fmt.Errorf("version %d of %s/%s not found (see 'veil history %s %s')", opts.Version, vault, name, vault, name)
+		}
+		if isCryptoError(err) {
+			return fmt.Errorf("decryption failed (check your MASTER_KEY)")
+		}
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Rolled back %s/%s to version %d\n", vault, name, opts.Version)
+	fmt.Fprintln(stdout, "The replaced value was kept as a new version.")
+	return nil
+}
+
+func (c *RollbackCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil rollback <vault> <name> --to <version>")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Restore a previous version of a secret. The current value is")
+	fmt.Fprintln(w, "archived first, so a rollback can itself be rolled back.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --to N           Version to restore (see 'veil history')")
+	fmt.Fprintln(w, "  --help, -h       Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil history production DB_PASSWORD")
+	fmt.Fprintln(w, "  veil rollback production DB_PASSWORD --to 2")
+}
+
+func init() {
+	Register(NewRollbackCommand())
+}
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// RotateCommand regenerates secrets with the settings they were generated
+// with.
+type RotateCommand struct {
+	BaseCommand
+}
+
+func NewRotateCommand() *RotateCommand {
+	return &RotateCommand{
+		BaseCommand: NewBaseCommand("rotate", "Regenerate secrets with the settings they were generated with"),
+	}
+}
+
+func (c *RotateCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := deps.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if len(args) < 1 {
+		return &UsageError{
+			Command: "rotate",
+			Usage:   "veil rotate <vault> <name|pattern> | --all [--update-env] [--show]",
+		}
+	}
+
+	vault := args[0]
+	opts, err := flags.ParseRotateFlags(args[1:])
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	result, err := deps.App.Rotate(vault, opts.Pattern, opts.UpdateEnv)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("secret not found")
+		}
+		return err
+	}
+
+	for _, r := range result.Rotated {
+		fmt.Fprintf(stdout, "Rotated %s/%s (%s), previous value kept as v%d\n", vault, r.Name, r.Recipe, r.Version)
+		if opts.Show {
+			fmt.Fprintf(stdout, "  New value: %s\n", r.Value)
+		}
+		switch {
+		case r.EnvErr != nil:
+			fmt.Fprintf(stderr, "Warning: %s not updated: %v\n", r.Recipe.EnvFile, r.EnvErr)
+		case opts.UpdateEnv && r.Recipe.EnvFile != "":
+			fmt.Fprintf(stdout, "  Updated in %s\n", r.Recipe.EnvFile)
+		case r.Recipe.EnvFile != "":
+			fmt.Fprintf(stdout, "  Linked to %s (not updated, use --update-env)\n", r.Recipe.EnvFile)
+		}
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(stdout, "Skipped %d secrets not generated by veil\n", len(result.Skipped))
+	}
+	if len(result.Rotated) == 0 {
+		fmt.Fprintln(stdout, "No secrets rotated.")
+	}
+	return nil
+}
+
+func (c *RotateCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil rotate <vault> <name|pattern> [flags]")
+	fmt.Fprintln(w, "       veil rotate <vault> --all [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Give secrets a new value made the same way 'veil generate' made the")
+	fmt.Fprintln(w, "current one: same type, length, format, prefix and bits. The previous")
+	fmt.Fprintln(w, "value is kept in the history, so 'veil rollback' can undo a rotation.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "A pattern (DB_*, *_SECRET) or --all rotates every matching secret that")
+	fmt.Fprintln(w, "was generated by veil and skips the others. All secrets are replaced")
+	fmt.Fprintln(w, "in one transaction.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --all            Rotate every generated secret in the vault")
+	fmt.Fprintln(w, "  --update-env     Also write new values to the .env file they were")
+	fmt.Fprintln(w, "                   generated into with --to-env")
+	fmt.Fprintln(w, "  --show           Print the new values")
+	fmt.Fprintln(w, "  --help, -h       Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil rotate production JWT_SECRET")
+	fmt.Fprintln(w, "  veil rotate production 'DB_*' --update-env")
+	fmt.Fprintln(w, "  veil rotate staging --all")
+}
+
+func init() {
+	Register(NewRotateCommand())
+}
@@ -11,8 +11,14 @@ import (
 	"syscall"
 
 	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/config"
+	"github.com/ossydotpy/veil/internal/filter"
 )
 
+// cleanEnvBaseline lists the host variables kept by --clean-env in addition
+// to those named with --allow-env.
+var cleanEnvBaseline = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "LANG", "LC_*", "TZ", "TMPDIR"}
+
 type RunCommand struct {
 	BaseCommand
 }
@@ -23,6 +29,10 @@ func NewRunCommand() *RunCommand {
 	}
 }
 
+// UsesProject returns true because run takes its vault and filters
+// from the project file.
+func (c *RunCommand) UsesProject() bool { return true }
+
 func (c *RunCommand) Execute(args []string, deps Dependencies) error {
 	sepIdx := findSeparator(args)
 	if sepIdx < 0 {
@@ -32,7 +42,10 @@ func (c *RunCommand) Execute(args []string, deps Dependencies) error {
 		}
 	}
 
-	veilArgs := args[:sepIdx]
+	veilArgs, err := withProjectVault(args[:sepIdx], 1, deps)
+	if err != nil {
+		return err
+	}
 	cmdArgs := args[sepIdx+1:]
 
 	if len(veilArgs) < 1 {
@@ -65,17 +78,22 @@ func (c *RunCommand) Execute(args []string, deps Dependencies) error {
 		return nil
 	}
 
-	secrets, err := deps.App.RunEnv(vault, opts.Include, opts.Exclude)
+	include, exclude := projectFilters(deps, opts.Include, opts.Exclude)
+	secrets, err := deps.App.RunEnv(vault, include, exclude)
 	if err != nil {
 		return err
 	}
 
+	if err := checkExpired(stderr, deps, vault, slices.Sorted(maps.Keys(secrets)), opts.FailExpired); err != nil {
+		return err
+	}
+
 	if len(secrets) == 0 {
 		fmt.Fprintf(stderr, "Warning: vault %q contains no secrets\n", vault)
 		fmt.Fprintf(stderr, "Running command with current environment (no secrets injected)\n")
 	}
 
-	mergedEnv := mergeEnv(os.Environ(), secrets)
+	mergedEnv := mergeEnv(hostEnv(os.Environ(), opts), secrets)
 
 	binary, err := exec.LookPath(cmdArgs[0])
 	if err != nil {
@@ -94,6 +112,24 @@ func findSeparator(args []string) int {
 	return -1
 }
 
+// hostEnv selects the variables of the current environment passed on to
+// the child. Veil's own configuration, the master key included, is removed
+// unless --keep-veil-env is given, and --clean-env keeps only the baseline
+// and allow-listed variables.
+func hostEnv(current []string, opts flags.RunOptions) []string {
+	var include, exclude []string
+	if opts.CleanEnv {
+		include = append(slices.Clone(cleanEnvBaseline), opts.AllowEnv...)
+		if opts.KeepVeilEnv {
+			include = append(include, config.EnvPatterns...)
+		}
+	}
+	if !opts.KeepVeilEnv {
+		exclude = config.EnvPatterns
+	}
+	return filter.FilterEnv(current, include, exclude)
+}
+
 func mergeEnv(current []string, secrets map[string]string) []string {
 	envMap := make(map[string]string, len(current)+len(secrets))
 	for _, entry := range current {
@@ -121,16 +157,28 @@ func (c *RunCommand) printHelp(w io.Writer) {
 	fmt.Fprintln(w, "The -- separator is required. It tells veil where flags end")
 	fmt.Fprintln(w, "and the command begins.")
 	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Inside a project with a .veil.toml, <vault> and the filters default to")
+	fmt.Fprintln(w, "its settings.")
+	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Flags:")
 	fmt.Fprintln(w, "  --include <pattern>  Include only matching keys (repeatable)")
 	fmt.Fprintln(w, "  --exclude <pattern>  Exclude matching keys (repeatable)")
+	fmt.Fprintln(w, "  --keep-veil-env      Pass MASTER_KEY and VEIL_* variables to the command")
+	fmt.Fprintln(w, "  --clean-env          Pass only PATH, HOME, USER, LOGNAME, SHELL, TERM,")
+	fmt.Fprintln(w, "                       LANG, LC_*, TZ and TMPDIR from the current environment")
+	fmt.Fprintln(w, "  --allow-env <name>   With --clean-env, also pass matching variables (repeatable)")
+	fmt.Fprintln(w, "  --fail-expired       Refuse to run if any injected secret has expired")
 	fmt.Fprintln(w, "  --help, -h           Show this help message")
 	fmt.Fprintln(w)
+	fmt.Fprintln(w, "MASTER_KEY, MASTER_KEYS and VEIL_* variables are removed from the")
+	fmt.Fprintln(w, "command's environment unless --keep-veil-env is given.")
+	fmt.Fprintln(w)
 	fmt.Fprintln(w, "Examples:")
 	fmt.Fprintln(w, "  veil run production -- python app.py")
 	fmt.Fprintln(w, "  veil run staging --include 'DB_*' -- docker compose up")
 	fmt.Fprintln(w, "  veil run production --exclude 'DEBUG*' -- node server.js")
 	fmt.Fprintln(w, "  veil run development -- env")
+	fmt.Fprintln(w, "  veil run production --clean-env --allow-env 'AWS_*' -- ./deploy.sh")
 }
 
 func init() {
@@ -1,5 +1,14 @@
 package commands
 
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+)
+
 // SetCommand stores a secret in a vault.
 type SetCommand struct {
 	BaseCommand
@@ -12,20 +21,57 @@ func NewSetCommand() *SetCommand {
 }
 
 func (c *SetCommand) Execute(args []string, deps Dependencies) error {
-	if len(args) != 3 {
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		stdout := deps.Stdout
+		if stdout == nil {
+			stdout = os.Stdout
+		}
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if len(args) < 3 {
 		return &UsageError{
 			Command: "set",
-			Usage:   "veil set <vault> <name> <value>",
+			Usage:   "veil set <vault> <name> <value> [--expires <when>] [--rotate-every <interval>] [--template]",
 		}
 	}
 
 	vault, name, value := args[0], args[1], args[2]
-
-	if err := deps.App.Set(vault, name, value); err != nil {
+	opts, err := flags.ParseSetFlags(args[3:])
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return deps.App.SetWithMetadata(vault, name, value, app.MetadataUpdate{
+		ExpiresAt:   opts.ExpiresAt,
+		RotateEvery: opts.RotateEvery,
+		Template:    opts.Template,
+	})
+}
+
+func (c *SetCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil set <vault> <name> <value> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Store a secret. An existing value is kept as a previous version and")
+	fmt.Fprintln(w, "its expiry is cleared, since it belonged to the old value.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --expires <when>           When the value stops working: a date")
+	fmt.Fprintln(w, "                             (2026-12-31), a timestamp, or an interval")
+	fmt.Fprintln(w, "                             from now (90d)")
+	fmt.Fprintln(w, "  --rotate-every <interval>  How often the secret should get a new value")
+	fmt.Fprintln(w, "                             (90d, 12w, none to remove)")
+	fmt.Fprintln(w, "  --template                 Expand ${NAME} and ${vault/NAME} in the value")
+	fmt.Fprintln(w, "                             when it is read; $${ stays a literal ${")
+	fmt.Fprintln(w, "  --no-template              Use the value literally again")
+	fmt.Fprintln(w, "  --help, -h                 Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil set production DB_PASSWORD hunter2")
+	fmt.Fprintln(w, "  veil set production GITHUB_TOKEN ghp_... --expires 2026-12-31")
+	fmt.Fprintln(w, "  veil set production STRIPE_KEY sk_live_... --rotate-every 90d")
+	fmt.Fprintln(w, "  veil set production DATABASE_URL 'postgres://${DB_USER}@db/app' --template")
 }
 
 func init() {
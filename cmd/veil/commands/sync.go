@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+)
+
+// SyncCommand reconciles a vault with a .env file in both directions.
+type SyncCommand struct {
+	BaseCommand
+}
+
+func NewSyncCommand() *SyncCommand {
+	return &SyncCommand{
+		BaseCommand: NewBaseCommand("sync", "Sync a vault and a .env file in both directions"),
+	}
+}
+
+func (c *SyncCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	opts, err := flags.ParseSyncFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	result, err := deps.App.Sync(opts.Vault, opts.File, app.SyncOptions{
+		Prefer:  app.SyncPolicy(opts.Prefer),
+		Include: opts.Include,
+		Exclude: opts.Exclude,
+		DryRun:  opts.DryRun,
+		Backup:  opts.Backup,
+	})
+	if errors.Is(err, app.ErrSyncConflict) {
+		fmt.Fprintf(stdout, "Conflicting values in %s and %s:\n", opts.Vault, opts.File)
+		for _, key := range result.Conflicts {
+			fmt.Fprintf(stdout, "  ! %s\n", key)
+		}
+		fmt.Fprintln(stdout)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		fmt.Fprintln(stdout, "DRY RUN - Nothing will be changed")
+	}
+	if len(result.ToVault.NewKeys)+len(result.ToVault.UpdatedKeys)+len(result.ToFile.NewKeys)+len(result.ToFile.UpdatedKeys) == 0 {
+		if len(result.Conflicts) > 0 {
+			fmt.Fprintf(stdout, "Nothing to sync; skipped %d conflicting keys.\n", len(result.Conflicts))
+		} else {
+			fmt.Fprintf(stdout, "%s and %s are in sync.\n", opts.Vault, opts.File)
+		}
+		return nil
+	}
+
+	verb := "Updated"
+	if opts.DryRun {
+		verb = "Would update"
+	}
+	printSyncSide(stdout, fmt.Sprintf("%s vault %s:", verb, opts.Vault), result.ToVault.NewKeys, result.ToVault.UpdatedKeys)
+	printSyncSide(stdout, fmt.Sprintf("%s %s:", verb, opts.File), result.ToFile.NewKeys, result.ToFile.UpdatedKeys)
+	if opts.Prefer == string(app.SyncSkip) && len(result.Conflicts) > 0 {
+		fmt.Fprintf(stdout, "Skipped conflicting keys:\n")
+		for _, key := range result.Conflicts {
+			fmt.Fprintf(stdout, "  ! %s\n", key)
+		}
+	}
+
+	fmt.Fprintf(stdout, "Summary: vault %s; file %s\n", result.ToVault.Summary(), result.ToFile.Summary())
+	return nil
+}
+
+// printSyncSide lists the keys added to and updated on one side of a sync,
+// if any.
+func printSyncSide(w io.Writer, heading string, added, updated []string) {
+	if len(added)+len(updated) == 0 {
+		return
+	}
+	fmt.Fprintln(w, heading)
+	for _, key := range added {
+		fmt.Fprintf(w, "  + %s\n", key)
+	}
+	for _, key := range updated {
+		fmt.Fprintf(w, "  ~ %s\n", key)
+	}
+	fmt.Fprintln(w)
+}
+
+func (c *SyncCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil sync <vault> <file> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Bring a vault and a .env file up to date with each other. Keys only in")
+	fmt.Fprintln(w, "the vault are added to the file, and keys only in the file are stored in")
+	fmt.Fprintln(w, "the vault. Keys with different values on each side are conflicts: by")
+	fmt.Fprintln(w, "default the sync is refused and they are listed, so pick a side with")
+	fmt.Fprintln(w, "--prefer. The file is created if it does not exist.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --prefer <side>      Resolve conflicts: vault, file, or skip to leave them")
+	fmt.Fprintln(w, "  --include <pattern>  Sync only matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --exclude <pattern>  Skip matching keys (can be repeated)")
+	fmt.Fprintln(w, "  --dry-run            Preview without changing anything")
+	fmt.Fprintln(w, "  --backup             Back up the file before writing it")
+	fmt.Fprintln(w, "  --help, -h           Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil sync dev .env --dry-run")
+	fmt.Fprintln(w, "  veil sync dev .env --prefer file")
+}
+
+func init() {
+	Register(NewSyncCommand())
+}
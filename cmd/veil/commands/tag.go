@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+	"github.com/ossydotpy/veil/internal/app"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// TagCommand adds, removes or shows the tags of a secret.
+type TagCommand struct {
+	BaseCommand
+}
+
+func NewTagCommand() *TagCommand {
+	return &TagCommand{
+		BaseCommand: NewBaseCommand("tag", "Add or remove tags on a secret"),
+	}
+}
+
+func (c *TagCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h") {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	if len(args) < 2 {
+		return &UsageError{
+			Command: "tag",
+			Usage:   "veil tag <vault> <name> [tag...] [--remove <tag>]",
+		}
+	}
+
+	vault, name := args[0], args[1]
+	opts, err := flags.ParseTagFlags(args[2:])
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	var tags []string
+	if len(opts.Add) == 0 && len(opts.Remove) == 0 {
+		m, err := deps.App.Describe(vault, name)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("secret not found")
+			}
+			return err
+		}
+		tags = m.Tags
+	} else {
+		m, err := deps.App.UpdateMetadata(vault, name, app.MetadataUpdate{
+			AddTags:    opts.Add,
+			RemoveTags: opts.Remove,
+		})
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				return fmt.Errorf("secret not found")
+			}
+			return err
+		}
+		tags = m.Tags
+	}
+
+	if len(tags) == 0 {
+		fmt.Fprintf(stdout, "%s/%s has no tags\n", vault, name)
+		return nil
+	}
+	fmt.Fprintf(stdout, "%s/%s: %s\n", vault, name, strings.Join(tags, ", "))
+	return nil
+}
+
+func (c *TagCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil tag <vault> <name> [tag...] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Add tags to a secret, or remove them with --remove. Without tags or")
+	fmt.Fprintln(w, "flags, show the secret's current tags. Tags cannot contain commas or")
+	fmt.Fprintln(w, "whitespace. Use 'veil list <vault> --tag <tag>' to find tagged secrets.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags:")
+	fmt.Fprintln(w, "  --remove <tag>   Remove a tag (repeatable)")
+	fmt.Fprintln(w, "  --help, -h       Show this help message")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil tag production STRIPE_KEY payments pci")
+	fmt.Fprintln(w, "  veil tag production STRIPE_KEY --remove pci")
+	fmt.Fprintln(w, "  veil tag production STRIPE_KEY")
+}
+
+func init() {
+	Register(NewTagCommand())
+}
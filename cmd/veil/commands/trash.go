@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+)
+
+// TrashCommand lists and purges deleted secrets and sets how long they are
+// kept.
+type TrashCommand struct {
+	BaseCommand
+}
+
+func NewTrashCommand() *TrashCommand {
+	return &TrashCommand{
+		BaseCommand: NewBaseCommand("trash", "List or purge deleted secrets"),
+	}
+}
+
+func (c *TrashCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	opts, err := flags.ParseTrashFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	switch opts.Action {
+	case "purge":
+		purged, err := deps.App.PurgeTrash(opts.All)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Purged %d secrets from the trash.\n", purged)
+		return nil
+
+	case "retention":
+		if opts.Retention > 0 {
+			if err := deps.App.SetTrashRetention(opts.Retention); err != nil {
+				return err
+			}
+		}
+		retention, err := deps.App.TrashRetention()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Deleted secrets are kept in the trash for %s.\n", flags.FormatInterval(retention))
+		return nil
+	}
+
+	trashed, err := deps.App.ListTrash()
+	if err != nil {
+		return err
+	}
+	if len(trashed) == 0 {
+		fmt.Fprintln(stdout, "The trash is empty.")
+		return nil
+	}
+	retention, err := deps.App.TrashRetention()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SECRET\tDELETED\tPURGED AFTER")
+	for _, t := range trashed {
+		fmt.Fprintf(tw, "%s/%s\t%s\t%s\n", t.Vault, t.Name, formatTime(t.DeletedAt), formatDate(t.DeletedAt.Add(retention)))
+	}
+	return tw.Flush()
+}
+
+func (c *TrashCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil trash [list|purge|retention] [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "'veil delete' moves secrets to the trash together with their history")
+	fmt.Fprintln(w, "and metadata. Use 'veil restore <vault> <name>' to bring one back.")
+	fmt.Fprintln(w, "Secrets are purged for good once the retention period has passed.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Actions:")
+	fmt.Fprintln(w, "  list                  List trashed secrets, most recent first (default)")
+	fmt.Fprintln(w, "  purge                 Purge secrets past the retention period")
+	fmt.Fprintln(w, "  purge --all           Purge every secret in the trash")
+	fmt.Fprintln(w, "  retention [interval]  Show or set the retention period, e.g. 7d or 12w")
+	fmt.Fprintln(w, "                        (default: 30d)")
+}
+
+func init() {
+	Register(NewTrashCommand())
+}
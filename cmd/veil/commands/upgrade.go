@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// UpgradeCommand rewrites stored values in the current ciphertext format.
+type UpgradeCommand struct {
+	BaseCommand
+}
+
+func NewUpgradeCommand() *UpgradeCommand {
+	return &UpgradeCommand{
+		BaseCommand: NewBaseCommand("upgrade", "Upgrade stored secrets to the current encryption format"),
+	}
+}
+
+func (c *UpgradeCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+
+	for _, arg := range args {
+		if arg == "--help" || arg == "-h" {
+			c.printHelp(stdout)
+			return nil
+		}
+		return &UsageError{
+			Command: "upgrade",
+			Usage:   "veil upgrade",
+		}
+	}
+
+	stats, err := deps.App.Upgrade()
+	if err != nil {
+		if isCryptoError(err) {
+			return fmt.Errorf("upgrade aborted, a secret could not be decrypted (check your MASTER_KEY): %w", err)
+		}
+		return err
+	}
+
+	switch {
+	case stats.Secrets == 0 && stats.Versions == 0 && stats.VaultKeys == 0:
+		fmt.Fprintln(stdout, "All secrets already use the current format.")
+	case stats.VaultKeys > 0:
+		fmt.Fprintf(stdout, "Upgraded %d secrets and %d previous versions; re-wrapped %d vault keys.\n", stats.Secrets, stats.Versions, stats.VaultKeys)
+	default:
+		fmt.Fprintf(stdout, "Upgraded %d secrets and %d previous versions.\n", stats.Secrets, stats.Versions)
+	}
+	fmt.Fprintln(stdout, "Values in the legacy format will now be rejected.")
+	return nil
+}
+
+func (c *UpgradeCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil upgrade")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Re-encrypt secrets stored in an older format, or directly with the")
+	fmt.Fprintln(w, "master key, with their vault key in the current envelope, which records")
+	fmt.Fprintln(w, "the key and algorithm used and binds every value to its vault and name.")
+	fmt.Fprintln(w, "Values copied between secrets in the database then fail to decrypt")
+	fmt.Fprintln(w, "instead of being returned. With a key ring, vault keys wrapped by a")
+	fmt.Fprintln(w, "non-primary key are re-wrapped with the primary key.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "After the upgrade, legacy values are refused. The upgrade runs in a")
+	fmt.Fprintln(w, "single transaction and is safe to repeat.")
+}
+
+func init() {
+	Register(NewUpgradeCommand())
+}
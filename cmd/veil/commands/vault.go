@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ossydotpy/veil/cmd/veil/flags"
+)
+
+// VaultCommand renames, copies and deletes whole vaults and manages the
+// vaults they inherit from.
+type VaultCommand struct {
+	BaseCommand
+}
+
+func NewVaultCommand() *VaultCommand {
+	return &VaultCommand{
+		BaseCommand: NewBaseCommand("vault", "Rename, copy, delete or inherit vaults"),
+	}
+}
+
+func (c *VaultCommand) Execute(args []string, deps Dependencies) error {
+	stdout := deps.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := deps.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	stdin := deps.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	opts, err := flags.ParseVaultFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if opts.ShowHelp {
+		c.printHelp(stdout)
+		return nil
+	}
+
+	switch opts.Action {
+	case "rename":
+		from, to := opts.Vaults[0], opts.Vaults[1]
+		n, err := deps.App.RenameVault(from, to)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Renamed vault %s to %s (%d secrets).\n", from, to, n)
+
+	case "copy":
+		from, to := opts.Vaults[0], opts.Vaults[1]
+		n, err := deps.App.CopyVault(from, to, opts.Include, opts.Exclude, opts.Force)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Copied %d secrets from %s to %s.\n", n, from, to)
+
+	case "delete":
+		vault := opts.Vaults[0]
+		if !opts.Yes {
+			fmt.Fprintf(stderr, "This moves every secret in vault %s to the trash.\n", vault)
+			fmt.Fprintf(stderr, "Type the vault name to confirm: ")
+			confirmation, err := bufio.NewReader(stdin).ReadString('\n')
+			if err != nil && confirmation == "" {
+				return fmt.Errorf("failed to read confirmation: %w", err)
+			}
+			if strings.TrimSpace(confirmation) != vault {
+				fmt.Fprintln(stdout, "Aborted.")
+				return nil
+			}
+		}
+		n, err := deps.App.DeleteVault(vault)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Moved %d secrets from vault %s to the trash.\n", n, vault)
+
+	case "inherit":
+		vault, parents := opts.Vaults[0], opts.Vaults[1:]
+		if len(parents) > 0 || opts.None {
+			if err := deps.App.SetParents(vault, parents); err != nil {
+				return err
+			}
+		}
+		return printInheritance(stdout, deps, vault)
+	}
+
+	return nil
+}
+
+// printInheritance shows the parents of vault and the order in which its
+// secrets are looked up.
+func printInheritance(w io.Writer, deps Dependencies, vault string) error {
+	parents, err := deps.App.Parents(vault)
+	if err != nil {
+		return err
+	}
+	if len(parents) == 0 {
+		fmt.Fprintf(w, "Vault %s does not inherit from other vaults.\n", vault)
+		return nil
+	}
+
+	chain, err := deps.App.Chain(vault)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Vault %s inherits from %s.\n", vault, strings.Join(parents, ", "))
+	fmt.Fprintf(w, "Lookup order: %s\n", strings.Join(chain, " -> "))
+	return nil
+}
+
+func (c *VaultCommand) printHelp(w io.Writer) {
+	fmt.Fprintln(w, "Usage: veil vault <action> <vault>... [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Actions:")
+	fmt.Fprintln(w, "  rename <vault> <new-vault>   Move every secret to a new vault")
+	fmt.Fprintln(w, "  copy <vault> <new-vault>     Copy the current values and metadata")
+	fmt.Fprintln(w, "  delete <vault>               Move every secret to the trash")
+	fmt.Fprintln(w, "  inherit <vault> [parent...]  Show or set the vaults a vault inherits from")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options:")
+	fmt.Fprintln(w, "  --include <pattern>  Copy only matching names (repeatable)")
+	fmt.Fprintln(w, "  --exclude <pattern>  Skip matching names (repeatable)")
+	fmt.Fprintln(w, "  --force              Overwrite secrets that already exist (copy)")
+	fmt.Fprintln(w, "  --yes, -y            Delete without asking for confirmation")
+	fmt.Fprintln(w, "  --none               Stop inheriting (inherit)")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Each action runs in a single transaction: it either completes or changes")
+	fmt.Fprintln(w, "nothing. Rename keeps the history of each secret; copy starts a fresh")
+	fmt.Fprintln(w, "history. Secrets deleted from a vault before it was renamed are restored")
+	fmt.Fprintln(w, "under the old vault name.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "A vault that inherits sees the secrets of its parents that it does not")
+	fmt.Fprintln(w, "hold itself, in get, run and export. Parents are searched in the order")
+	fmt.Fprintln(w, "given, each followed by its own parents. Use 'veil list <vault> --resolved'")
+	fmt.Fprintln(w, "to see where each value comes from.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Examples:")
+	fmt.Fprintln(w, "  veil vault copy staging qa --exclude 'STRIPE_*'")
+	fmt.Fprintln(w, "  veil vault rename prod production")
+	fmt.Fprintln(w, "  veil vault delete qa")
+	fmt.Fprintln(w, "  veil vault inherit production base")
+}
+
+func init() {
+	Register(NewVaultCommand())
+}
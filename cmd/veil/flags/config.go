@@ -0,0 +1,69 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigOptions holds parsed arguments for the config command: the action
+// (list, get or set), its arguments and the profile it applies to.
+type ConfigOptions struct {
+	Action   string
+	Args     []string
+	Profile  string
+	ShowHelp bool
+}
+
+// configActionArgs is the number of arguments each config action takes.
+var configActionArgs = map[string]int{
+	"list": 0,
+	"get":  1,
+	"set":  2,
+}
+
+// ParseConfigFlags parses the action, arguments and flags of the config
+// command. Without an action it lists the configuration.
+func ParseConfigFlags(args []string) (ConfigOptions, error) {
+	opts := ConfigOptions{}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--profile":
+			if i+1 >= len(args) || args[i+1] == "" {
+				return opts, fmt.Errorf("--profile requires a profile name")
+			}
+			opts.Profile = args[i+1]
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	if len(positional) == 0 {
+		positional = []string{"list"}
+	}
+
+	opts.Action, opts.Args = positional[0], positional[1:]
+	want, ok := configActionArgs[opts.Action]
+	if !ok {
+		return opts, fmt.Errorf("unknown config action: %q (expected list, get or set)", opts.Action)
+	}
+	if len(opts.Args) != want {
+		return opts, fmt.Errorf("config %s takes %d arguments, got %d", opts.Action, want, len(opts.Args))
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,26 @@
+package flags
+
+import (
+	"testing"
+)
+
+func TestParseConfigFlags(t *testing.T) {
+	opts, err := ParseConfigFlags(nil)
+	if err != nil || opts.Action != "list" {
+		t.Errorf("ParseConfigFlags() = %+v, %v, want list", opts, err)
+	}
+
+	opts, err = ParseConfigFlags([]string{"set", "db_path", "/tmp/team.db", "--profile", "team"})
+	if err != nil {
+		t.Fatalf("ParseConfigFlags error: %v", err)
+	}
+	if opts.Action != "set" || opts.Profile != "team" || len(opts.Args) != 2 || opts.Args[1] != "/tmp/team.db" {
+		t.Errorf("ParseConfigFlags = %+v", opts)
+	}
+
+	for _, args := range [][]string{{"get"}, {"set", "db_path"}, {"list", "extra"}, {"unset", "db_path"}, {"list", "--profile"}} {
+		if _, err := ParseConfigFlags(args); err == nil {
+			t.Errorf("ParseConfigFlags(%v) succeeded, want error", args)
+		}
+	}
+}
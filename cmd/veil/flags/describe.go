@@ -0,0 +1,48 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DescribeOptions holds parsed flags for the describe command. Description
+// and Owner are nil unless the corresponding flag was given.
+type DescribeOptions struct {
+	Description *string
+	Owner       *string
+	ShowHelp    bool
+}
+
+// ParseDescribeFlags parses command-line flags for the describe command.
+func ParseDescribeFlags(args []string) (DescribeOptions, error) {
+	opts := DescribeOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--description":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--description requires a text argument")
+			}
+			opts.Description = &args[i+1]
+			i++
+		case "--owner":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--owner requires a name argument")
+			}
+			opts.Owner = &args[i+1]
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,76 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffOptions holds parsed arguments for the diff command. Right is empty
+// when File is set.
+type DiffOptions struct {
+	Left     string
+	Right    string
+	File     string
+	Include  []string
+	Exclude  []string
+	Show     bool
+	ShowHelp bool
+}
+
+// ParseDiffFlags parses '<vault> <other-vault>' or '<vault> --file <path>'
+// and the flags of the diff command.
+func ParseDiffFlags(args []string) (DiffOptions, error) {
+	opts := DiffOptions{}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--file":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--file requires a path")
+			}
+			opts.File = args[i+1]
+			i++
+		case "--include":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--include requires a pattern")
+			}
+			opts.Include = append(opts.Include, args[i+1])
+			i++
+		case "--exclude":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--exclude requires a pattern")
+			}
+			opts.Exclude = append(opts.Exclude, args[i+1])
+			i++
+		case "--show":
+			opts.Show = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	switch {
+	case opts.File != "" && len(positional) != 1:
+		return opts, fmt.Errorf("expected <vault> --file <path>, got %d arguments", len(positional))
+	case opts.File == "" && len(positional) != 2:
+		return opts, fmt.Errorf("expected <vault> <other-vault> or <vault> --file <path>, got %d arguments", len(positional))
+	}
+
+	opts.Left = positional[0]
+	if opts.File == "" {
+		opts.Right = positional[1]
+	}
+	return opts, nil
+}
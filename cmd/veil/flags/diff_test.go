@@ -0,0 +1,36 @@
+package flags
+
+import (
+	"testing"
+)
+
+func TestParseDiffFlags(t *testing.T) {
+	opts, err := ParseDiffFlags([]string{"staging", "--show", "production", "--exclude", "DEBUG"})
+	if err != nil {
+		t.Fatalf("ParseDiffFlags error: %v", err)
+	}
+	if opts.Left != "staging" || opts.Right != "production" || !opts.Show || len(opts.Exclude) != 1 {
+		t.Errorf("ParseDiffFlags = %+v", opts)
+	}
+
+	opts, err = ParseDiffFlags([]string{"production", "--file", ".env"})
+	if err != nil {
+		t.Fatalf("ParseDiffFlags --file error: %v", err)
+	}
+	if opts.Left != "production" || opts.File != ".env" || opts.Right != "" {
+		t.Errorf("ParseDiffFlags --file = %+v", opts)
+	}
+}
+
+func TestParseDiffFlags_Arguments(t *testing.T) {
+	for _, args := range [][]string{
+		{"staging"},
+		{"a", "b", "c"},
+		{"a", "b", "--file", ".env"},
+		{"a", "--file"},
+	} {
+		if _, err := ParseDiffFlags(args); err == nil {
+			t.Errorf("ParseDiffFlags(%v) succeeded, want error", args)
+		}
+	}
+}
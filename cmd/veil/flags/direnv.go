@@ -0,0 +1,70 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DirenvOptions holds parsed arguments for the direnv command. Vault is
+// empty when Stdlib is set.
+type DirenvOptions struct {
+	Vault       string
+	Stdlib      bool
+	Include     []string
+	Exclude     []string
+	FailExpired bool
+	ShowHelp    bool
+}
+
+// ParseDirenvFlags parses '<vault>' or '--stdlib' and the flags of the
+// direnv command.
+func ParseDirenvFlags(args []string) (DirenvOptions, error) {
+	opts := DirenvOptions{}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--stdlib":
+			opts.Stdlib = true
+		case "--include":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--include requires a pattern argument")
+			}
+			opts.Include = append(opts.Include, args[i+1])
+			i++
+		case "--exclude":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--exclude requires a pattern argument")
+			}
+			opts.Exclude = append(opts.Exclude, args[i+1])
+			i++
+		case "--fail-expired":
+			opts.FailExpired = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	switch {
+	case opts.Stdlib && len(positional) > 0:
+		return opts, fmt.Errorf("--stdlib takes no vault")
+	case !opts.Stdlib && len(positional) != 1:
+		return opts, fmt.Errorf("expected <vault> or --stdlib, got %d arguments", len(positional))
+	}
+
+	if !opts.Stdlib {
+		opts.Vault = positional[0]
+	}
+	return opts, nil
+}
@@ -0,0 +1,26 @@
+package flags
+
+import (
+	"testing"
+)
+
+func TestParseDirenvFlags(t *testing.T) {
+	opts, err := ParseDirenvFlags([]string{"dev", "--exclude", "LOCAL_*"})
+	if err != nil {
+		t.Fatalf("ParseDirenvFlags error: %v", err)
+	}
+	if opts.Vault != "dev" || opts.Stdlib || len(opts.Exclude) != 1 {
+		t.Errorf("ParseDirenvFlags = %+v", opts)
+	}
+
+	opts, err = ParseDirenvFlags([]string{"--stdlib"})
+	if err != nil || !opts.Stdlib {
+		t.Errorf("ParseDirenvFlags --stdlib = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{nil, {"dev", "--stdlib"}, {"dev", "prod"}} {
+		if _, err := ParseDirenvFlags(args); err == nil {
+			t.Errorf("ParseDirenvFlags(%v) succeeded, want error", args)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DueOptions holds parsed flags for the due command.
+type DueOptions struct {
+	Within   time.Duration
+	ShowHelp bool
+}
+
+// ParseDueFlags parses command-line flags for the due command.
+func ParseDueFlags(args []string) (DueOptions, error) {
+	opts := DueOptions{Within: 14 * 24 * time.Hour}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--within":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--within requires an interval")
+			}
+			if args[i+1] == "0" {
+				opts.Within = 0
+			} else {
+				within, err := ParseInterval(args[i+1])
+				if err != nil {
+					return opts, err
+				}
+				opts.Within = within
+			}
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,64 @@
+package flags
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ossydotpy/veil/internal/encoding/shell"
+)
+
+// EnvOptions holds parsed flags for the env command. Shell is empty when
+// it should be detected from $SHELL.
+type EnvOptions struct {
+	Shell       string
+	Include     []string
+	Exclude     []string
+	FailExpired bool
+	ShowHelp    bool
+}
+
+// ParseEnvFlags parses command-line flags for the env command.
+func ParseEnvFlags(args []string) (EnvOptions, error) {
+	opts := EnvOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--shell":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--shell requires one of %s", strings.Join(shell.Shells, ", "))
+			}
+			if !slices.Contains(shell.Shells, args[i+1]) {
+				return opts, fmt.Errorf("unsupported shell %q (use %s)", args[i+1], strings.Join(shell.Shells, ", "))
+			}
+			opts.Shell = args[i+1]
+			i++
+		case "--include":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--include requires a pattern argument")
+			}
+			opts.Include = append(opts.Include, args[i+1])
+			i++
+		case "--exclude":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--exclude requires a pattern argument")
+			}
+			opts.Exclude = append(opts.Exclude, args[i+1])
+			i++
+		case "--fail-expired":
+			opts.FailExpired = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,72 @@
+package flags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseInterval parses a rotation interval or time window. Besides Go
+// durations such as "36h", whole days ("90d") and weeks ("12w") are
+// accepted.
+func ParseInterval(s string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	var d time.Duration
+	if unit > 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid interval %q: expected a number of days (90d), weeks (12w) or hours (36h)", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid interval %q: expected a number of days (90d), weeks (12w) or hours (36h)", s)
+		}
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid interval %q: must be positive", s)
+	}
+	return d, nil
+}
+
+// FormatInterval formats d the way ParseInterval accepts it, preferring
+// whole days, then whole hours.
+func FormatInterval(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return d.String()
+}
+
+// ParseExpiry parses an expiry given as a date (2026-12-31, midnight local
+// time), an RFC 3339 timestamp, or an interval from now (90d). "none"
+// returns the zero time, which clears an expiry.
+func ParseExpiry(s string, now time.Time) (time.Time, error) {
+	if s == "none" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := ParseInterval(s); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q: expected a date (2026-12-31), a timestamp, an interval from now (90d) or none", s)
+}
@@ -0,0 +1,60 @@
+package flags
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"90d", 90 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"36h", 36 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, err := ParseInterval(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseInterval(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+		if back := FormatInterval(got); tt.in != "2w" && back != tt.in {
+			t.Errorf("FormatInterval(%v) = %q, want %q", got, back, tt.in)
+		}
+	}
+
+	for _, bad := range []string{"", "d", "xd", "-5d", "0d", "soon"} {
+		if _, err := ParseInterval(bad); err == nil {
+			t.Errorf("ParseInterval(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseExpiry("2026-12-31", now)
+	if err != nil || got.Year() != 2026 || got.Month() != 12 || got.Day() != 31 {
+		t.Errorf("ParseExpiry(date) = %v, %v", got, err)
+	}
+	if got, err := ParseExpiry("30d", now); err != nil || !got.Equal(now.Add(30*24*time.Hour)) {
+		t.Errorf("ParseExpiry(30d) = %v, %v; want 30 days from now", got, err)
+	}
+	if got, err := ParseExpiry("none", now); err != nil || !got.IsZero() {
+		t.Errorf("ParseExpiry(none) = %v, %v; want zero time", got, err)
+	}
+	if _, err := ParseExpiry("next tuesday", now); err == nil {
+		t.Error("ParseExpiry should reject free text")
+	}
+}
+
+func TestParseSetFlags_RotateEveryNone(t *testing.T) {
+	opts, err := ParseSetFlags([]string{"--rotate-every", "none"})
+	if err != nil {
+		t.Fatalf("ParseSetFlags error: %v", err)
+	}
+	if opts.RotateEvery == nil || *opts.RotateEvery != 0 || opts.ExpiresAt != nil {
+		t.Errorf("opts = %+v, want rotation cleared and expiry untouched", opts)
+	}
+}
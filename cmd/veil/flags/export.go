@@ -2,8 +2,10 @@ package flags
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/ossydotpy/veil/internal/encoding/k8s"
 	"github.com/ossydotpy/veil/internal/exporter"
 )
 
@@ -13,14 +15,18 @@ type ExportOptions struct {
 	ShowHelp bool
 }
 
+// ExportDefaults are the target path and format export uses when neither
+// is given on the command line, typically from a project file. Either may
+// be empty.
+type ExportDefaults struct {
+	TargetPath string
+	Format     string
+}
+
 // ParseExportFlags parses command-line flags for the export command.
-func ParseExportFlags(args []string) (ExportOptions, error) {
-	opts := ExportOptions{
-		ExportOptions: exporter.ExportOptions{
-			TargetPath: ".env",
-			Format:     "env",
-		},
-	}
+func ParseExportFlags(args []string, defaults ExportDefaults) (ExportOptions, error) {
+	opts := ExportOptions{}
+	var manifestFlags []string
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
@@ -44,6 +50,8 @@ func ParseExportFlags(args []string) (ExportOptions, error) {
 			opts.DryRun = true
 		case "--backup":
 			opts.Backup = true
+		case "--raw":
+			opts.Raw = true
 		case "--format":
 			if i+1 < len(args) {
 				opts.Format = args[i+1]
@@ -59,6 +67,18 @@ func ParseExportFlags(args []string) (ExportOptions, error) {
 				opts.Exclude = append(opts.Exclude, args[i+1])
 				i++
 			}
+		case "--name", "--namespace", "--label", "--annotation":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("%s requires a value", arg)
+			}
+			if err := setManifestOption(&opts.Manifest, arg, args[i+1]); err != nil {
+				return opts, err
+			}
+			manifestFlags = append(manifestFlags, arg)
+			i++
+		case "--string-data":
+			opts.Manifest.StringData = true
+			manifestFlags = append(manifestFlags, arg)
 		case "--help", "-h":
 			opts.ShowHelp = true
 		default:
@@ -66,5 +86,80 @@ func ParseExportFlags(args []string) (ExportOptions, error) {
 		}
 	}
 
+	// The format follows the file extension unless given, and the file
+	// name follows the format. The defaults are only used as a pair, so
+	// asking for another format does not overwrite the default file.
+	defaultFormat := defaults.Format
+	if defaultFormat == "" {
+		defaultFormat = formatFromPath(defaults.TargetPath)
+	}
+	if opts.Format == "" {
+		if opts.TargetPath != "" {
+			opts.Format = formatFromPath(opts.TargetPath)
+		} else {
+			opts.Format = defaultFormat
+		}
+	}
+	if opts.TargetPath == "" {
+		if defaults.TargetPath != "" && opts.Format == defaultFormat {
+			opts.TargetPath = defaults.TargetPath
+		} else {
+			opts.TargetPath = defaultExportPaths[opts.Format]
+		}
+	}
+
+	if len(manifestFlags) > 0 && opts.Format != "k8s" {
+		return opts, fmt.Errorf("%s only applies to --format k8s", manifestFlags[0])
+	}
+
 	return opts, nil
 }
+
+// setManifestOption applies one of the k8s metadata flags. Labels and
+// annotations are given as key=value.
+func setManifestOption(m *k8s.Options, flag, value string) error {
+	switch flag {
+	case "--name":
+		m.Name = value
+		return nil
+	case "--namespace":
+		m.Namespace = value
+		return nil
+	}
+
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("%s expects key=value, got %q", flag, value)
+	}
+	target := &m.Labels
+	if flag == "--annotation" {
+		target = &m.Annotations
+	}
+	if *target == nil {
+		*target = make(map[string]string)
+	}
+	(*target)[key] = val
+	return nil
+}
+
+// defaultExportPaths is the file export writes to for each format when no
+// --to is given.
+var defaultExportPaths = map[string]string{
+	"env":  ".env",
+	"json": "secrets.json",
+	"yaml": "secrets.yaml",
+	"k8s":  "secret.yaml",
+}
+
+// formatFromPath returns the export or import format matching the
+// extension of path, defaulting to env.
+func formatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return "env"
+	}
+}
@@ -0,0 +1,86 @@
+package flags
+
+import (
+	"testing"
+)
+
+func TestParseExportFlags_Format(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantFormat string
+		wantPath   string
+	}{
+		{nil, "env", ".env"},
+		{[]string{"--to", "config/app.json"}, "json", "config/app.json"},
+		{[]string{"--to", "app.YML"}, "yaml", "app.YML"},
+		{[]string{"--format", "yaml"}, "yaml", "secrets.yaml"},
+		{[]string{"--format", "env", "--to", "out.json"}, "env", "out.json"},
+	}
+
+	for _, tt := range tests {
+		opts, err := ParseExportFlags(tt.args, ExportDefaults{})
+		if err != nil {
+			t.Fatalf("ParseExportFlags(%v) error: %v", tt.args, err)
+		}
+		if opts.Format != tt.wantFormat || opts.TargetPath != tt.wantPath {
+			t.Errorf("ParseExportFlags(%v) = %s %s, want %s %s", tt.args, opts.Format, opts.TargetPath, tt.wantFormat, tt.wantPath)
+		}
+	}
+}
+
+func TestParseImportFlags_Format(t *testing.T) {
+	opts, err := ParseImportFlags([]string{"--from", "config.yaml"})
+	if err != nil {
+		t.Fatalf("ParseImportFlags error: %v", err)
+	}
+	if opts.Format != "yaml" {
+		t.Errorf("Format = %s, want yaml", opts.Format)
+	}
+}
+
+func TestParseExportFlags_Manifest(t *testing.T) {
+	opts, err := ParseExportFlags([]string{"--format", "k8s", "--name", "api", "--label", "app=api", "--label", "tier=web", "--annotation", "owner=payments", "--string-data"}, ExportDefaults{})
+	if err != nil {
+		t.Fatalf("ParseExportFlags error: %v", err)
+	}
+	m := opts.Manifest
+	if m.Name != "api" || m.Labels["app"] != "api" || m.Labels["tier"] != "web" || m.Annotations["owner"] != "payments" || !m.StringData {
+		t.Errorf("Manifest = %+v", m)
+	}
+	if opts.TargetPath != "secret.yaml" {
+		t.Errorf("TargetPath = %s, want secret.yaml", opts.TargetPath)
+	}
+
+	for _, args := range [][]string{
+		{"--namespace", "web"},
+		{"--format", "k8s", "--label", "novalue"},
+	} {
+		if _, err := ParseExportFlags(args, ExportDefaults{}); err == nil {
+			t.Errorf("ParseExportFlags(%v) succeeded, want error", args)
+		}
+	}
+}
+
+func TestParseExportFlags_Defaults(t *testing.T) {
+	defaults := ExportDefaults{TargetPath: "/app/config/secrets.json"}
+	tests := []struct {
+		args       []string
+		wantFormat string
+		wantPath   string
+	}{
+		{nil, "json", "/app/config/secrets.json"},
+		{[]string{"--to", "out.env"}, "env", "out.env"},
+		{[]string{"--format", "yaml"}, "yaml", "secrets.yaml"},
+		{[]string{"--format", "json"}, "json", "/app/config/secrets.json"},
+	}
+
+	for _, tt := range tests {
+		opts, err := ParseExportFlags(tt.args, defaults)
+		if err != nil {
+			t.Fatalf("ParseExportFlags(%v) error: %v", tt.args, err)
+		}
+		if opts.Format != tt.wantFormat || opts.TargetPath != tt.wantPath {
+			t.Errorf("ParseExportFlags(%v) = %s %s, want %s %s", tt.args, opts.Format, opts.TargetPath, tt.wantFormat, tt.wantPath)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ossydotpy/veil/internal/generator"
 )
@@ -18,7 +19,9 @@ const (
 // GenerateOptions holds parsed flags for the generate command.
 type GenerateOptions struct {
 	generator.Options
-	ShowHelp bool
+	ExpiresAt   *time.Time
+	RotateEvery *time.Duration
+	ShowHelp    bool
 }
 
 // ParseGenerateFlags parses command-line flags for the generate command.
@@ -98,6 +101,26 @@ func ParseGenerateFlags(args []string) (GenerateOptions, error) {
 			}
 		case "--force":
 			opts.Force = true
+		case "--expires":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--expires requires a date or interval")
+			}
+			expiresAt, err := ParseExpiry(args[i+1], time.Now())
+			if err != nil {
+				return opts, err
+			}
+			opts.ExpiresAt = &expiresAt
+			i++
+		case "--rotate-every":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--rotate-every requires an interval")
+			}
+			rotateEvery, err := parseRotateEvery(args[i+1])
+			if err != nil {
+				return opts, err
+			}
+			opts.RotateEvery = &rotateEvery
+			i++
 		case "--help", "-h":
 			opts.ShowHelp = true
 		default:
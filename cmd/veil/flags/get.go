@@ -0,0 +1,37 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GetOptions holds parsed flags for the get command.
+type GetOptions struct {
+	FailExpired bool
+	Raw         bool
+	ShowHelp    bool
+}
+
+// ParseGetFlags parses command-line flags for the get command.
+func ParseGetFlags(args []string) (GetOptions, error) {
+	opts := GetOptions{}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--fail-expired":
+			opts.FailExpired = true
+		case "--raw":
+			opts.Raw = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,34 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HistoryOptions holds parsed flags for the history command.
+type HistoryOptions struct {
+	Show     bool
+	ShowHelp bool
+}
+
+// ParseHistoryFlags parses command-line flags for the history command.
+func ParseHistoryFlags(args []string) (HistoryOptions, error) {
+	opts := HistoryOptions{}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--show":
+			opts.Show = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
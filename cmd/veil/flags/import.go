@@ -13,11 +13,7 @@ type ImportOptions struct {
 }
 
 func ParseImportFlags(args []string) (ImportOptions, error) {
-	opts := ImportOptions{
-		ImportOptions: importer.ImportOptions{
-			Format: "env",
-		},
-	}
+	opts := ImportOptions{}
 
 	for i := 0; i < len(args); i++ {
 		arg := args[i]
@@ -62,5 +58,9 @@ func ParseImportFlags(args []string) (ImportOptions, error) {
 		}
 	}
 
+	if opts.Format == "" {
+		opts.Format = formatFromPath(opts.SourcePath)
+	}
+
 	return opts, nil
 }
@@ -0,0 +1,44 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InitOptions holds parsed flags for the init command.
+type InitOptions struct {
+	Passphrase     bool
+	PassphraseFile string
+	ShowHelp       bool
+}
+
+// ParseInitFlags parses command-line flags for the init command.
+func ParseInitFlags(args []string) (InitOptions, error) {
+	opts := InitOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--passphrase":
+			opts.Passphrase = true
+		case "--passphrase-file":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--passphrase-file requires a path argument")
+			}
+			opts.PassphraseFile = args[i+1]
+			opts.Passphrase = true
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
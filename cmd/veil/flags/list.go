@@ -0,0 +1,50 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListOptions holds parsed flags for the list command.
+type ListOptions struct {
+	Long     bool
+	Tag      string
+	Resolved bool
+	ShowHelp bool
+}
+
+// ParseListFlags parses command-line flags for the list command.
+func ParseListFlags(args []string) (ListOptions, error) {
+	opts := ListOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--long", "-l":
+			opts.Long = true
+		case "--resolved":
+			opts.Resolved = true
+		case "--tag":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--tag requires a tag argument")
+			}
+			opts.Tag = args[i+1]
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.Resolved && (opts.Long || opts.Tag != "") {
+		return opts, fmt.Errorf("--resolved cannot be combined with --long or --tag")
+	}
+
+	return opts, nil
+}
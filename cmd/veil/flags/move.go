@@ -0,0 +1,53 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MoveOptions holds parsed arguments for the mv and cp commands. NewName
+// defaults to Name when only a destination vault is given.
+type MoveOptions struct {
+	Vault    string
+	Name     string
+	NewVault string
+	NewName  string
+	Force    bool
+	ShowHelp bool
+}
+
+// ParseMoveFlags parses '<vault> <name> <new-vault> [<new-name>]' and the
+// flags of the mv and cp commands.
+func ParseMoveFlags(args []string) (MoveOptions, error) {
+	opts := MoveOptions{}
+
+	var positional []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--force":
+			opts.Force = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	if len(positional) < 3 || len(positional) > 4 {
+		return opts, fmt.Errorf("expected <vault> <name> <new-vault> [<new-name>], got %d arguments", len(positional))
+	}
+
+	opts.Vault, opts.Name, opts.NewVault, opts.NewName = positional[0], positional[1], positional[2], positional[1]
+	if len(positional) == 4 {
+		opts.NewName = positional[3]
+	}
+	return opts, nil
+}
@@ -0,0 +1,71 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RekeyOptions holds parsed flags for the rekey command.
+type RekeyOptions struct {
+	Generate       bool
+	NewKeyFile     string
+	Passphrase     bool
+	PassphraseFile string
+	Vault          string
+	ShowHelp       bool
+}
+
+// ParseRekeyFlags parses command-line flags for the rekey command.
+func ParseRekeyFlags(args []string) (RekeyOptions, error) {
+	opts := RekeyOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--generate":
+			opts.Generate = true
+		case "--new-key-file":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--new-key-file requires a path argument")
+			}
+			opts.NewKeyFile = args[i+1]
+			i++
+		case "--passphrase":
+			opts.Passphrase = true
+		case "--passphrase-file":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--passphrase-file requires a path argument")
+			}
+			opts.PassphraseFile = args[i+1]
+			opts.Passphrase = true
+			i++
+		case "--vault":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--vault requires a vault name")
+			}
+			opts.Vault = args[i+1]
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	modes := 0
+	for _, set := range []bool{opts.Generate, opts.NewKeyFile != "", opts.Passphrase, opts.Vault != ""} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return opts, fmt.Errorf("--generate, --new-key-file, --passphrase and --vault are mutually exclusive")
+	}
+
+	return opts, nil
+}
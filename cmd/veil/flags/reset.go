@@ -0,0 +1,27 @@
+package flags
+
+import "fmt"
+
+// ResetOptions holds parsed flags for the reset command.
+type ResetOptions struct {
+	Snapshot bool
+	ShowHelp bool
+}
+
+// ParseResetFlags parses command-line flags for the reset command.
+func ParseResetFlags(args []string) (ResetOptions, error) {
+	opts := ResetOptions{}
+
+	for _, arg := range args {
+		switch arg {
+		case "--snapshot":
+			opts.Snapshot = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
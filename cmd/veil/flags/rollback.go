@@ -0,0 +1,48 @@
+package flags
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// RollbackOptions holds parsed flags for the rollback command.
+type RollbackOptions struct {
+	Version  int
+	ShowHelp bool
+}
+
+// ParseRollbackFlags parses command-line flags for the rollback command.
+func ParseRollbackFlags(args []string) (RollbackOptions, error) {
+	opts := RollbackOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--to":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--to requires a version number")
+			}
+			version, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return opts, fmt.Errorf("invalid --to value %q: must be a number", args[i+1])
+			}
+			if version < 1 {
+				return opts, fmt.Errorf("invalid --to value %d: versions start at 1", version)
+			}
+			opts.Version = version
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
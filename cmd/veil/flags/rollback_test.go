@@ -0,0 +1,23 @@
+package flags
+
+import (
+	"testing"
+)
+
+func TestParseRollbackFlags_To(t *testing.T) {
+	opts, err := ParseRollbackFlags([]string{"--to", "3"})
+	if err != nil {
+		t.Fatalf("ParseRollbackFlags error: %v", err)
+	}
+	if opts.Version != 3 {
+		t.Errorf("Version = %d, want 3", opts.Version)
+	}
+}
+
+func TestParseRollbackFlags_InvalidVersion(t *testing.T) {
+	for _, value := range []string{"abc", "0", "-1"} {
+		if _, err := ParseRollbackFlags([]string{"--to", value}); err == nil {
+			t.Errorf("Expected error for --to %s", value)
+		}
+	}
+}
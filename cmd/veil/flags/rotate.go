@@ -0,0 +1,57 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RotateOptions holds parsed arguments for the rotate command. Pattern is
+// the secret name or pattern given after the vault, if any.
+type RotateOptions struct {
+	Pattern   string
+	All       bool
+	UpdateEnv bool
+	Show      bool
+	ShowHelp  bool
+}
+
+// ParseRotateFlags parses the arguments following the vault of the rotate
+// command.
+func ParseRotateFlags(args []string) (RotateOptions, error) {
+	opts := RotateOptions{}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			if opts.Pattern != "" {
+				return opts, fmt.Errorf("unexpected argument: %q (only one name or pattern is allowed)", arg)
+			}
+			opts.Pattern = arg
+			continue
+		}
+
+		switch arg {
+		case "--all":
+			opts.All = true
+		case "--update-env":
+			opts.UpdateEnv = true
+		case "--show":
+			opts.Show = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	if opts.All && opts.Pattern != "" {
+		return opts, fmt.Errorf("--all cannot be combined with a name or pattern")
+	}
+	if !opts.All && opts.Pattern == "" {
+		return opts, fmt.Errorf("a secret name, a pattern or --all is required")
+	}
+
+	return opts, nil
+}
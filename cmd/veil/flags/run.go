@@ -6,9 +6,13 @@ import (
 )
 
 type RunOptions struct {
-	Include  []string
-	Exclude  []string
-	ShowHelp bool
+	Include     []string
+	Exclude     []string
+	KeepVeilEnv bool
+	CleanEnv    bool
+	AllowEnv    []string
+	FailExpired bool
+	ShowHelp    bool
 }
 
 func ParseRunFlags(args []string) (RunOptions, error) {
@@ -34,6 +38,18 @@ func ParseRunFlags(args []string) (RunOptions, error) {
 			}
 			opts.Exclude = append(opts.Exclude, args[i+1])
 			i++
+		case "--keep-veil-env":
+			opts.KeepVeilEnv = true
+		case "--clean-env":
+			opts.CleanEnv = true
+		case "--fail-expired":
+			opts.FailExpired = true
+		case "--allow-env":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--allow-env requires a variable name or pattern")
+			}
+			opts.AllowEnv = append(opts.AllowEnv, args[i+1])
+			i++
 		case "--help", "-h":
 			opts.ShowHelp = true
 		default:
@@ -41,5 +57,9 @@ func ParseRunFlags(args []string) (RunOptions, error) {
 		}
 	}
 
+	if len(opts.AllowEnv) > 0 && !opts.CleanEnv {
+		return opts, fmt.Errorf("--allow-env can only be used with --clean-env")
+	}
+
 	return opts, nil
 }
\ No newline at end of file
@@ -29,4 +29,21 @@ func TestParseRunFlags_MissingValue(t *testing.T) {
 	if err == nil {
 		t.Error("Expected error for --include without value")
 	}
+}
+
+func TestParseRunFlags_EnvOptions(t *testing.T) {
+	opts, err := ParseRunFlags([]string{"--clean-env", "--allow-env", "AWS_*", "--keep-veil-env"})
+	if err != nil {
+		t.Fatalf("ParseRunFlags error: %v", err)
+	}
+	if !opts.CleanEnv || !opts.KeepVeilEnv {
+		t.Errorf("CleanEnv = %v, KeepVeilEnv = %v; want both set", opts.CleanEnv, opts.KeepVeilEnv)
+	}
+	if len(opts.AllowEnv) != 1 || opts.AllowEnv[0] != "AWS_*" {
+		t.Errorf("AllowEnv = %v, want [AWS_*]", opts.AllowEnv)
+	}
+
+	if _, err := ParseRunFlags([]string{"--allow-env", "HOME"}); err == nil {
+		t.Error("Expected error for --allow-env without --clean-env")
+	}
 }
\ No newline at end of file
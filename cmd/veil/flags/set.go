@@ -0,0 +1,68 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SetOptions holds parsed flags for the set command.
+type SetOptions struct {
+	ExpiresAt   *time.Time
+	RotateEvery *time.Duration
+	Template    *bool
+	ShowHelp    bool
+}
+
+// ParseSetFlags parses command-line flags for the set command.
+func ParseSetFlags(args []string) (SetOptions, error) {
+	opts := SetOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("unexpected argument: %q (unknown flag or misplaced value)", arg)
+		}
+
+		switch arg {
+		case "--expires":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--expires requires a date or interval")
+			}
+			expiresAt, err := ParseExpiry(args[i+1], time.Now())
+			if err != nil {
+				return opts, err
+			}
+			opts.ExpiresAt = &expiresAt
+			i++
+		case "--rotate-every":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--rotate-every requires an interval")
+			}
+			rotateEvery, err := parseRotateEvery(args[i+1])
+			if err != nil {
+				return opts, err
+			}
+			opts.RotateEvery = &rotateEvery
+			i++
+		case "--template", "--no-template":
+			template := arg == "--template"
+			opts.Template = &template
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
+
+// parseRotateEvery parses a rotation interval; "none" removes the policy.
+func parseRotateEvery(s string) (time.Duration, error) {
+	if s == "none" {
+		return 0, nil
+	}
+	return ParseInterval(s)
+}
@@ -0,0 +1,77 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyncOptions holds parsed arguments for the sync command.
+type SyncOptions struct {
+	Vault    string
+	File     string
+	Prefer   string
+	Include  []string
+	Exclude  []string
+	DryRun   bool
+	Backup   bool
+	ShowHelp bool
+}
+
+// ParseSyncFlags parses '<vault> <file>' and the flags of the sync
+// command.
+func ParseSyncFlags(args []string) (SyncOptions, error) {
+	opts := SyncOptions{}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--prefer":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--prefer requires vault, file or skip")
+			}
+			switch args[i+1] {
+			case "vault", "file", "skip":
+				opts.Prefer = args[i+1]
+			default:
+				return opts, fmt.Errorf("invalid --prefer %q (use vault, file or skip)", args[i+1])
+			}
+			i++
+		case "--include":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--include requires a pattern")
+			}
+			opts.Include = append(opts.Include, args[i+1])
+			i++
+		case "--exclude":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--exclude requires a pattern")
+			}
+			opts.Exclude = append(opts.Exclude, args[i+1])
+			i++
+		case "--dry-run":
+			opts.DryRun = true
+		case "--backup":
+			opts.Backup = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	if len(positional) != 2 {
+		return opts, fmt.Errorf("expected <vault> <file>, got %d arguments", len(positional))
+	}
+
+	opts.Vault, opts.File = positional[0], positional[1]
+	return opts, nil
+}
@@ -0,0 +1,43 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TagOptions holds parsed arguments for the tag command.
+type TagOptions struct {
+	Add      []string
+	Remove   []string
+	ShowHelp bool
+}
+
+// ParseTagFlags parses the tags to add and the --remove flags of the tag
+// command.
+func ParseTagFlags(args []string) (TagOptions, error) {
+	opts := TagOptions{}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			opts.Add = append(opts.Add, arg)
+			continue
+		}
+
+		switch arg {
+		case "--remove":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--remove requires a tag argument")
+			}
+			opts.Remove = append(opts.Remove, args[i+1])
+			i++
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,68 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TrashOptions holds parsed arguments for the trash command.
+type TrashOptions struct {
+	Action    string
+	All       bool
+	Retention time.Duration
+	ShowHelp  bool
+}
+
+// ParseTrashFlags parses the action of the trash command (list, purge or
+// retention, defaulting to list) and its flags. The retention action takes
+// an optional interval to set.
+func ParseTrashFlags(args []string) (TrashOptions, error) {
+	opts := TrashOptions{Action: "list"}
+
+	var positional []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--all":
+			opts.All = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if len(positional) > 0 {
+		opts.Action = positional[0]
+		positional = positional[1:]
+	}
+
+	switch opts.Action {
+	case "list":
+	case "purge":
+	case "retention":
+		if len(positional) > 0 {
+			retention, err := ParseInterval(positional[0])
+			if err != nil {
+				return opts, err
+			}
+			opts.Retention = retention
+			positional = positional[1:]
+		}
+	default:
+		return opts, fmt.Errorf("unknown trash action: %q (expected list, purge or retention)", opts.Action)
+	}
+
+	if len(positional) > 0 {
+		return opts, fmt.Errorf("unexpected argument: %q", positional[0])
+	}
+	if opts.All && opts.Action != "purge" {
+		return opts, fmt.Errorf("--all can only be used with purge")
+	}
+	return opts, nil
+}
@@ -0,0 +1,99 @@
+package flags
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VaultOptions holds parsed arguments for the vault command: the action
+// (rename, copy, delete or inherit) and the vaults it applies to.
+type VaultOptions struct {
+	Action   string
+	Vaults   []string
+	Include  []string
+	Exclude  []string
+	Force    bool
+	Yes      bool
+	None     bool
+	ShowHelp bool
+}
+
+// vaultActionArgs is the number of vaults each vault action takes. Inherit
+// takes a vault followed by any number of parents.
+var vaultActionArgs = map[string]int{
+	"rename":  2,
+	"copy":    2,
+	"delete":  1,
+	"inherit": -1,
+}
+
+// ParseVaultFlags parses the action, vaults and flags of the vault command.
+func ParseVaultFlags(args []string) (VaultOptions, error) {
+	opts := VaultOptions{}
+
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		switch arg {
+		case "--include":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--include requires a pattern")
+			}
+			opts.Include = append(opts.Include, args[i+1])
+			i++
+		case "--exclude":
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("--exclude requires a pattern")
+			}
+			opts.Exclude = append(opts.Exclude, args[i+1])
+			i++
+		case "--force":
+			opts.Force = true
+		case "--yes", "-y":
+			opts.Yes = true
+		case "--none":
+			opts.None = true
+		case "--help", "-h":
+			opts.ShowHelp = true
+		default:
+			return opts, fmt.Errorf("unknown flag: %s", arg)
+		}
+	}
+
+	if opts.ShowHelp {
+		return opts, nil
+	}
+	if len(positional) == 0 {
+		return opts, fmt.Errorf("an action is required: rename, copy, delete or inherit")
+	}
+
+	opts.Action, opts.Vaults = positional[0], positional[1:]
+	want, ok := vaultActionArgs[opts.Action]
+	if !ok {
+		return opts, fmt.Errorf("unknown vault action: %q (expected rename, copy, delete or inherit)", opts.Action)
+	}
+	if want < 0 && len(opts.Vaults) == 0 {
+		return opts, fmt.Errorf("vault %s takes a vault name", opts.Action)
+	}
+	if want >= 0 && len(opts.Vaults) != want {
+		return opts, fmt.Errorf("vault %s takes %d vault names, got %d", opts.Action, want, len(opts.Vaults))
+	}
+
+	if opts.Action != "copy" && (len(opts.Include) > 0 || len(opts.Exclude) > 0 || opts.Force) {
+		return opts, fmt.Errorf("--include, --exclude and --force can only be used with copy")
+	}
+	if opts.Yes && opts.Action != "delete" {
+		return opts, fmt.Errorf("--yes can only be used with delete")
+	}
+	if opts.None && (opts.Action != "inherit" || len(opts.Vaults) > 1) {
+		return opts, fmt.Errorf("--none can only be used with inherit and no parents")
+	}
+
+	return opts, nil
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/ossydotpy/veil/cmd/veil/commands"
 	"github.com/ossydotpy/veil/internal/app"
 	"github.com/ossydotpy/veil/internal/config"
 	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/prompt"
+	"github.com/ossydotpy/veil/internal/store"
 	"github.com/ossydotpy/veil/internal/store/factory"
 )
 
@@ -24,7 +28,27 @@ func run() error {
 		return nil
 	}
 
-	cmdName := os.Args[1]
+	// Global flags come before the command name
+	args := os.Args[1:]
+	var profile string
+	for len(args) > 0 && (args[0] == "--profile" || strings.HasPrefix(args[0], "--profile=")) {
+		if value, ok := strings.CutPrefix(args[0], "--profile="); ok {
+			profile, args = value, args[1:]
+		} else if len(args) < 2 {
+			return fmt.Errorf("--profile requires a profile name")
+		} else {
+			profile, args = args[1], args[2:]
+		}
+		if profile == "" {
+			return fmt.Errorf("--profile requires a profile name")
+		}
+	}
+	if len(args) == 0 {
+		printUsage(os.Stderr)
+		return nil
+	}
+
+	cmdName := args[0]
 
 	// Handle help flags
 	if cmdName == "--help" || cmdName == "-h" || cmdName == "help" {
@@ -45,7 +69,7 @@ func run() error {
 	}
 
 	// Build dependencies based on what the command needs
-	deps, cleanup, err := buildDependencies(cmd)
+	deps, cleanup, err := buildDependencies(cmd, profile)
 	if err != nil {
 		return err
 	}
@@ -54,8 +78,7 @@ func run() error {
 	}
 
 	// Execute the command
-	args := os.Args[2:]
-	if err := cmd.Execute(args, deps); err != nil {
+	if err := cmd.Execute(args[1:], deps); err != nil {
 		// Handle UsageError specially - print without "Error:" prefix
 		if _, ok := err.(*commands.UsageError); ok {
 			return err
@@ -67,11 +90,13 @@ func run() error {
 }
 
 // buildDependencies creates the dependencies struct based on what the command needs.
-func buildDependencies(cmd commands.Command) (commands.Dependencies, func(), error) {
+// profile is the name given with --profile, if any.
+func buildDependencies(cmd commands.Command, profile string) (commands.Dependencies, func(), error) {
 	deps := commands.Dependencies{
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
-		Stdin:  os.Stdin,
+		Profile: profile,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+		Stdin:   os.Stdin,
 	}
 
 	// If the command doesn't need dependencies, return early
@@ -80,11 +105,19 @@ func buildDependencies(cmd commands.Command) (commands.Dependencies, func(), err
 	}
 
 	// Load and validate config
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig(profile)
+	if err != nil {
+		return commands.Dependencies{}, nil, fmt.Errorf("configuration error: %w", err)
+	}
 	if err := cfg.Validate(); err != nil {
 		return commands.Dependencies{}, nil, fmt.Errorf("configuration error: %w", err)
 	}
 	deps.Config = cfg
+	deps.Project = cfg.Project
+	deps.ProjectErr = cfg.ProjectErr
+	if cfg.ProjectErr != nil && !cmd.UsesProject() {
+		fmt.Fprintf(os.Stderr, "Warning: %v (ignored by veil %s)\n", cfg.ProjectErr, cmd.Name())
+	}
 
 	// Initialize store
 	s, err := factory.NewStore(cfg.StoreType, cfg.DbPath)
@@ -102,44 +135,165 @@ func buildDependencies(cmd commands.Command) (commands.Dependencies, func(), err
 		return deps, cleanup, nil
 	}
 
-	// Validate master key and initialize crypto
+	// Initialize crypto from MASTER_KEY or the database passphrase.
 	// Note: We explicitly close the store on error paths because the cleanup
 	// function is only returned on success paths. This ensures immediate
 	// resource cleanup without relying on deferred cleanup that may not execute.
-	if cfg.MasterKey == "" {
-		s.Close()
-		return commands.Dependencies{}, nil, fmt.Errorf("MASTER_KEY environment variable is not set")
-	}
-	if err := cfg.ValidateMasterKey(); err != nil {
+	engine, err := loadEngine(cfg, s)
+	if err != nil {
 		s.Close()
-		return commands.Dependencies{}, nil, fmt.Errorf("invalid MASTER_KEY: %w (run 'veil init' if you need a new key)", err)
+		return commands.Dependencies{}, nil, err
 	}
+	deps.Engine = engine
 
-	engine, err := crypto.NewEngine(cfg.MasterKey)
+	// With name encryption on, everything above the store sees plaintext
+	// names through a decorator.
+	named, err := app.UnlockNames(s, engine)
 	if err != nil {
 		s.Close()
-		return commands.Dependencies{}, nil, fmt.Errorf("failed to initialize crypto: %w", err)
+		return commands.Dependencies{}, nil, err
 	}
-	deps.Engine = engine
+	deps.Store = named
 
 	// Initialize app
-	deps.App = app.New(s, engine)
+	deps.App = app.New(named, engine)
 
 	return deps, cleanup, nil
 }
 
+// loadEngine creates the crypto engine. A key ring (MASTER_KEYS or
+// VEIL_KEYRING_FILE) or a master key (MASTER_KEY, VEIL_MASTER_KEY_FILE or
+// VEIL_MASTER_KEY_COMMAND) takes precedence; if none is set and the database
+// was initialised with 'veil init --passphrase', the key is derived from a
+// passphrase instead.
+func loadEngine(cfg *config.Config, s store.Store) (*crypto.Engine, error) {
+	if err := cfg.ValidateKeySources(); err != nil {
+		return nil, err
+	}
+	if err := cfg.LoadMasterKey(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cfg.MasterKeys != "":
+		keys, err := crypto.ParseKeyList(cfg.MasterKeys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MASTER_KEYS: %w", err)
+		}
+		return newKeyRing(keys)
+
+	case cfg.KeyRingFile != "":
+		data, err := config.ReadKeyFile(cfg.KeyRingFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key ring file: %w", err)
+		}
+		keys, err := crypto.ParseKeyFile(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key ring file %s: %w", cfg.KeyRingFile, err)
+		}
+		return newKeyRing(keys)
+
+	case cfg.MasterKey == "":
+		params, err := app.LoadKDFParams(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key settings: %w", err)
+		}
+		if params == nil {
+			return nil, fmt.Errorf("MASTER_KEY environment variable is not set")
+		}
+
+		passphrase, err := prompt.Passphrase(cfg.PassphraseFile, os.Stdin, os.Stderr, false)
+		if err != nil {
+			return nil, err
+		}
+		engine, err := crypto.NewEngineFromPassphrase(passphrase, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unlock database: %w", err)
+		}
+		return engine, nil
+	}
+
+	if err := cfg.ValidateMasterKey(); err != nil {
+		return nil, fmt.Errorf("invalid MASTER_KEY: %w (run 'veil init' if you need a new key)", err)
+	}
+
+	engine, err := crypto.NewEngine(cfg.MasterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize crypto: %w", err)
+	}
+	return engine, nil
+}
+
+func newKeyRing(keys []crypto.NamedKey) (*crypto.Engine, error) {
+	engine, err := crypto.NewKeyRing(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize key ring: %w", err)
+	}
+	return engine, nil
+}
+
 func printUsage(w *os.File) {
 	fmt.Fprintln(w, commands.Logo)
-	fmt.Fprintln(w, "Usage: veil <command> [arguments]")
+	fmt.Fprintln(w, "Usage: veil [--profile <name>] <command> [arguments]")
 	fmt.Fprintln(w, "\nCommands:")
 	fmt.Fprintln(w, "  init                        Generate a new master key")
+	fmt.Fprintln(w, "                              --passphrase    Derive the key from a passphrase instead")
+	fmt.Fprintln(w, "                              --passphrase-file <path> Read the passphrase from a file")
+	fmt.Fprintln(w, "  rekey                       Switch to a new master key or rotate a vault key")
+	fmt.Fprintln(w, "                              --generate      Generate a new random key")
+	fmt.Fprintln(w, "                              --new-key-file <path> Read the new key from a file")
+	fmt.Fprintln(w, "                              --passphrase    Switch to a new passphrase")
+	fmt.Fprintln(w, "                              --vault <vault> Rotate the key of one vault")
+	fmt.Fprintln(w, "  upgrade                     Upgrade stored secrets to the current encryption format")
+	fmt.Fprintln(w, "  keys                        List master keys and what each one encrypts")
+	fmt.Fprintln(w, "  names [encrypt|decrypt]     Show or change whether names are encrypted at rest")
+	fmt.Fprintln(w, "  config [list|get|set]       Show or change settings and profiles")
+	fmt.Fprintln(w, "                              --profile <name> Act on this profile")
 	fmt.Fprintln(w, "  version                     Show version information")
 	fmt.Fprintln(w, "  reset                       Delete all secrets and start fresh")
+	fmt.Fprintln(w, "                              --snapshot      Save a copy of the database first")
 	fmt.Fprintln(w, "  set <vault> <name> <value>  Store a secret")
+	fmt.Fprintln(w, "                              --expires <when> Expiry date or interval (90d)")
+	fmt.Fprintln(w, "                              --rotate-every <interval> Rotation interval (90d)")
 	fmt.Fprintln(w, "  get <vault> <name>          Retrieve a secret")
-	fmt.Fprintln(w, "  delete <vault> <name>       Remove a secret")
+	fmt.Fprintln(w, "                              --fail-expired  Refuse expired values")
+	fmt.Fprintln(w, "                              --raw           Do not expand ${NAME} references")
+	fmt.Fprintln(w, "  due                         List expired secrets and those due for rotation")
+	fmt.Fprintln(w, "                              --within <interval> Include upcoming (default: 14d)")
+	fmt.Fprintln(w, "  delete <vault> <name>       Move a secret to the trash")
+	fmt.Fprintln(w, "  restore <vault> <name>      Restore a deleted secret from the trash")
+	fmt.Fprintln(w, "  trash [list|purge|retention] List or purge deleted secrets")
+	fmt.Fprintln(w, "                              --all           Purge everything, not just expired")
+	fmt.Fprintln(w, "  history <vault> <name>      Show previous versions of a secret")
+	fmt.Fprintln(w, "                              --show          Print decrypted values")
+	fmt.Fprintln(w, "  rollback <vault> <name>     Restore a previous version of a secret")
+	fmt.Fprintln(w, "                              --to N          Version to restore (required)")
 	fmt.Fprintln(w, "  list <vault>                List all secret names in a vault")
+	fmt.Fprintln(w, "                              --long, -l      Show updated date, owner, tags, description")
+	fmt.Fprintln(w, "                              --tag <tag>     Only secrets with this tag")
+	fmt.Fprintln(w, "                              --resolved      Include inherited secrets and their vault")
+	fmt.Fprintln(w, "  describe <vault> <name>     Show a secret's metadata")
+	fmt.Fprintln(w, "                              --description <text> Set the description")
+	fmt.Fprintln(w, "                              --owner <name>  Set the owner")
+	fmt.Fprintln(w, "  tag <vault> <name> [tag...] Add tags to a secret")
+	fmt.Fprintln(w, "                              --remove <tag>  Remove a tag")
+	fmt.Fprintln(w, "  mv <vault> <name> <new-vault> [<new-name>] Move or rename a secret")
+	fmt.Fprintln(w, "                              --force         Trash an existing destination first")
+	fmt.Fprintln(w, "  cp <vault> <name> <new-vault> [<new-name>] Copy a secret")
+	fmt.Fprintln(w, "                              --force         Overwrite an existing destination")
 	fmt.Fprintln(w, "  vaults                      List all vaults")
+	fmt.Fprintln(w, "  vault rename <vault> <new>  Rename a vault")
+	fmt.Fprintln(w, "  vault copy <vault> <new>    Copy a vault's secrets")
+	fmt.Fprintln(w, "                              --include <pattern> Copy only matching keys (repeatable)")
+	fmt.Fprintln(w, "                              --exclude <pattern> Skip matching keys (repeatable)")
+	fmt.Fprintln(w, "                              --force         Overwrite existing secrets")
+	fmt.Fprintln(w, "  vault delete <vault>        Move all secrets of a vault to the trash")
+	fmt.Fprintln(w, "                              --yes, -y       Do not ask for confirmation")
+	fmt.Fprintln(w, "  vault inherit <vault> [parent...] Show or set the vaults a vault inherits from")
+	fmt.Fprintln(w, "                              --none          Stop inheriting")
+	fmt.Fprintln(w, "  diff <vault> <other-vault>  Compare two vaults (or a vault with --file <path>)")
+	fmt.Fprintln(w, "                              --show          Print the values that differ")
+	fmt.Fprintln(w, "                              --include/--exclude <pattern> Filter keys")
 	fmt.Fprintln(w, "  search <pattern>            Search secrets across all vaults")
 	fmt.Fprintln(w, "                              Supports * wildcard (e.g., DB_*)")
 	fmt.Fprintln(w, "  generate <vault> <name>     Generate and store a secret")
@@ -151,23 +305,41 @@ func printUsage(w *os.File) {
 	fmt.Fprintln(w, "                              --bits N        JWT secret bits: 256|512 (default: 256)")
 	fmt.Fprintln(w, "                              --to-env <path> Append to .env file")
 	fmt.Fprintln(w, "                              --force         Overwrite existing key in .env")
+	fmt.Fprintln(w, "                              --expires <when> Expiry date or interval (90d)")
+	fmt.Fprintln(w, "                              --rotate-every <interval> Rotation interval (90d)")
+	fmt.Fprintln(w, "  rotate <vault> <name|pattern> Regenerate secrets with their generate settings")
+	fmt.Fprintln(w, "                              --all           Every generated secret in the vault")
+	fmt.Fprintln(w, "                              --update-env    Also update the linked .env file")
+	fmt.Fprintln(w, "                              --show          Print the new values")
 	fmt.Fprintln(w, "  export <vault>              Export vault secrets to .env file")
-	fmt.Fprintln(w, "                              --to <path>     Output file path (default: .env)")
+	fmt.Fprintln(w, "                              --to <path>     Output file path (format from extension)")
 	fmt.Fprintln(w, "                              --force         Overwrite existing file")
 	fmt.Fprintln(w, "                              --append        Append to existing file")
 	fmt.Fprintln(w, "                              --dry-run       Preview without writing")
 	fmt.Fprintln(w, "                              --backup        Create backup before overwriting")
-	fmt.Fprintln(w, "                              --format <fmt>  Output format (env, json, yaml)")
+	fmt.Fprintln(w, "                              --raw           Do not expand ${NAME} references")
+	fmt.Fprintln(w, "                              --format <fmt>  Output format (env, json, yaml, k8s)")
 	fmt.Fprintln(w, "  run <vault> [flags] -- <cmd> Run command with vault secrets in environment")
 	fmt.Fprintln(w, "                              --include <pattern> Include only matching keys (repeatable)")
 	fmt.Fprintln(w, "                              --exclude <pattern> Exclude matching keys (repeatable)")
+	fmt.Fprintln(w, "                              --keep-veil-env Pass MASTER_KEY and VEIL_* to the command")
+	fmt.Fprintln(w, "                              --clean-env     Pass only a minimal host environment")
+	fmt.Fprintln(w, "                              --allow-env <pattern> Also pass matching host variables")
+	fmt.Fprintln(w, "                              --fail-expired  Refuse to run with expired secrets")
+	fmt.Fprintln(w, "  env <vault>                 Print export statements, e.g. eval \"$(veil env dev)\"")
+	fmt.Fprintln(w, "                              --shell <shell> bash|zsh|sh|fish|powershell|pwsh")
+	fmt.Fprintln(w, "  direnv <vault>              Print secrets for direnv ('use veil <vault>' in .envrc)")
+	fmt.Fprintln(w, "                              --stdlib        Print the use_veil function for direnvrc")
 	fmt.Fprintln(w, "  import <vault>              Import secrets from .env file")
 	fmt.Fprintln(w, "                              --from <path>   Source file path (required)")
 	fmt.Fprintln(w, "                              --force         Overwrite existing keys")
 	fmt.Fprintln(w, "                              --dry-run       Preview without importing")
 	fmt.Fprintln(w, "                              --include       Include matching keys (can repeat)")
 	fmt.Fprintln(w, "                              --exclude       Exclude matching keys (can repeat)")
-	fmt.Fprintln(w, "                              --format <fmt>  Input format (env, default: env)")
+	fmt.Fprintln(w, "                              --format <fmt>  Input format (env, json, yaml, k8s)")
+	fmt.Fprintln(w, "  sync <vault> <file>         Sync a vault and a .env file in both directions")
+	fmt.Fprintln(w, "                              --prefer <side> Resolve conflicts: vault|file|skip")
+	fmt.Fprintln(w, "                              --dry-run       Preview without changing anything")
 	fmt.Fprintln(w, "  quick [type]                Generate ephemeral secret (no storage)")
 	fmt.Fprintln(w, "                              Types: password|apikey|jwt|hex|base64|uuid|uuidv7")
 	fmt.Fprintln(w, "                              --length N      Password length (default: 32)")
@@ -181,4 +353,8 @@ func printUsage(w *os.File) {
 	fmt.Fprintln(w, "                              --force         Overwrite existing key in .env")
 	fmt.Fprintln(w, "                              --template <s>  Custom output format (use {value})")
 	fmt.Fprintln(w, "                              --batch <file>  Generate from JSON config file")
+	fmt.Fprintln(w, "\nGlobal flags:")
+	fmt.Fprintln(w, "  --profile <name>            Use a profile from ~/.config/veil/config.toml")
+	fmt.Fprintln(w, "\nIn a directory with a .veil.toml project file, get, run, export, env and")
+	fmt.Fprintln(w, "direnv can omit <vault>, and use its filters and export target by default.")
 }
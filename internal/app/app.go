@@ -3,9 +3,11 @@ package app
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"iter"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/ossydotpy/veil/internal/crypto"
@@ -20,41 +22,138 @@ import (
 type App struct {
 	store  store.Store
 	crypto *crypto.Engine
+
+	// dataKeys caches unwrapped vault keys by their wrapped value.
+	dataKeys map[string]*crypto.Engine
 }
 
 func New(s store.Store, c *crypto.Engine) *App {
 	return &App{
-		store:  s,
-		crypto: c,
+		store:    s,
+		crypto:   c,
+		dataKeys: make(map[string]*crypto.Engine),
 	}
 }
 
+// Set stores a secret. A vault's first secret also creates its data key,
+// so both are written in one transaction.
 func (a *App) Set(vault, name, value string) error {
-	encrypted, err := a.crypto.Encrypt(value)
+	return a.store.Atomic(func(s store.Store) error {
+		encrypted, err := a.withStore(s).encrypt(vault, name, value)
+		if err != nil {
+			return err
+		}
+		return s.Save(vault, name, encrypted)
+	})
+}
+
+// Get returns the value of a secret, with its references expanded if it is
+// a template, looking it up through the vaults vault inherits from if vault
+// does not hold it itself.
+func (a *App) Get(vault, name string) (string, error) {
+	source, encrypted, err := a.lookup(vault, name)
+	if err != nil {
+		return "", err
+	}
+	value, err := a.decrypt(source, name, encrypted)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return a.store.Save(vault, name, encrypted)
+	return a.expandTemplate(vault, source, name, value, []string{vault + "/" + name})
 }
 
-func (a *App) Get(vault, name string) (string, error) {
+// GetRaw is like Get but returns the value as stored, leaving references
+// unexpanded.
+func (a *App) GetRaw(vault, name string) (string, error) {
+	source, encrypted, err := a.lookup(vault, name)
+	if err != nil {
+		return "", err
+	}
+	return a.decrypt(source, name, encrypted)
+}
+
+// getOwn returns the value of a secret held by vault itself.
+func (a *App) getOwn(vault, name string) (string, error) {
 	encrypted, err := a.store.Get(vault, name)
 	if err != nil {
 		return "", err
 	}
-	return a.crypto.Decrypt(encrypted)
+	return a.decrypt(vault, name, encrypted)
 }
 
-func (a *App) Delete(vault, name string) error {
-	return a.store.Delete(vault, name)
+// secretAAD is the associated data binding a ciphertext to the vault and
+// name it is stored under, so values cannot be swapped between secrets by
+// editing the database.
+func secretAAD(vault, name string) []byte {
+	return fmt.Appendf(nil, "veil:secret:%d:%s:%s", len(vault), vault, name)
+}
+
+// encrypt seals a value for vault/name with the vault's data key, creating
+// the key on first use.
+func (a *App) encrypt(vault, name, value string) (string, error) {
+	engine, err := a.vaultEngine(vault, true)
+	if err != nil {
+		return "", err
+	}
+	return engine.EncryptWithAAD(value, secretAAD(vault, name))
+}
+
+// decrypt opens a stored value for vault/name with the vault's data key, or
+// with the master key for values written before vault keys existed. Legacy
+// values that are not bound to their name are accepted until 'veil upgrade'
+// has been run.
+func (a *App) decrypt(vault, name, encrypted string) (string, error) {
+	if crypto.IsLegacy(encrypted) {
+		if _, err := a.store.GetMeta(legacyRejectedMetaKey); err == nil {
+			return "", fmt.Errorf("%s/%s: %w", vault, name, ErrLegacyCiphertext)
+		}
+	}
+
+	engine := a.crypto
+	if !a.underMasterKey(encrypted) {
+		// Without a vault key the master key reports the unknown key ID.
+		vaultEngine, err := a.vaultEngine(vault, false)
+		switch {
+		case err == nil:
+			engine = vaultEngine
+		case !errors.Is(err, store.ErrVaultKeyNotFound):
+			return "", err
+		}
+	}
+	return engine.DecryptWithAAD(encrypted, secretAAD(vault, name))
 }
 
 func (a *App) List(vault string) iter.Seq2[string, error] {
 	return a.store.List(vault)
 }
 
+// ListVaults returns the vaults holding secrets and those that only
+// inherit from other vaults, sorted by name.
 func (a *App) ListVaults() iter.Seq2[string, error] {
-	return a.store.ListVaults()
+	return func(yield func(string, error) bool) {
+		var vaults []string
+		for vault, err := range a.store.ListVaults() {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			vaults = append(vaults, vault)
+		}
+		for vp, err := range a.store.ListVaultParents() {
+			if err != nil {
+				yield("", err)
+				return
+			}
+			vaults = append(vaults, vp.Vault)
+		}
+		slices.Sort(vaults)
+
+		for _, vault := range slices.Compact(vaults) {
+			if !yield(vault, nil) {
+				return
+			}
+		}
+	}
 }
 
 func (a *App) Reset() error {
@@ -72,13 +171,48 @@ func (a *App) Search(pattern string) ([]store.SecretRef, error) {
 	return results, nil
 }
 
+// GetAllSecrets returns the secrets of vault together with those it
+// inherits, the nearest vault in the chain winning for each name, with
+// references expanded.
 func (a *App) GetAllSecrets(vault string) (map[string]string, error) {
+	return a.resolvedSecrets(vault, true)
+}
+
+// resolvedSecrets returns the secrets visible from vault, expanding their
+// references if expand is set.
+func (a *App) resolvedSecrets(vault string, expand bool) (map[string]string, error) {
+	refs, err := a.ResolvedRefs(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[string]string)
+	for _, ref := range refs {
+		value, err := a.getOwn(ref.Vault, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		if expand {
+			value, err = a.expandTemplate(vault, ref.Vault, ref.Name, value, []string{vault + "/" + ref.Name})
+			if err != nil {
+				return nil, err
+			}
+		}
+		secrets[ref.Name] = value
+	}
+
+	return secrets, nil
+}
+
+// ownSecrets returns the secrets held by vault itself, without inherited
+// ones.
+func (a *App) ownSecrets(vault string) (map[string]string, error) {
 	secrets := make(map[string]string)
 	for name, err := range a.List(vault) {
 		if err != nil {
 			return nil, err
 		}
-		value, err := a.Get(vault, name)
+		value, err := a.getOwn(vault, name)
 		if err != nil {
 			return nil, err
 		}
@@ -89,7 +223,7 @@ func (a *App) GetAllSecrets(vault string) (map[string]string, error) {
 }
 
 func (a *App) Export(vault string, opts exporter.ExportOptions) (*exporter.Preview, error) {
-	secrets, err := a.GetAllSecrets(vault)
+	secrets, err := a.resolvedSecrets(vault, !opts.Raw)
 	if err != nil {
 		return nil, err
 	}
@@ -126,7 +260,7 @@ func (a *App) Import(vault string, opts importer.ImportOptions) (*importer.Previ
 		return nil, err
 	}
 
-	existing, err := a.GetAllSecrets(vault)
+	existing, err := a.ownSecrets(vault)
 	if err != nil {
 		return nil, err
 	}
@@ -170,7 +304,16 @@ func (a *App) Import(vault string, opts importer.ImportOptions) (*importer.Previ
 	return preview, nil
 }
 
-func (a *App) Generate(vault, name string, opts generator.Options) (string, error) {
+// Generate creates a secret, stores it with the metadata changes in upd and
+// optionally appends it to an env file. The generator settings are stored
+// as the secret's recipe for 'veil rotate'.
+func (a *App) Generate(vault, name string, opts generator.Options, upd MetadataUpdate) (string, error) {
+	recipe, err := NewRecipe(opts)
+	if err != nil {
+		return "", err
+	}
+	upd.Recipe = &recipe
+
 	// Generate the secret
 	secret, err := generator.Generate(opts)
 	if err != nil {
@@ -178,7 +321,7 @@ func (a *App) Generate(vault, name string, opts generator.Options) (string, erro
 	}
 
 	// Store in vault
-	if err := a.Set(vault, name, secret); err != nil {
+	if err := a.SetWithMetadata(vault, name, secret, upd); err != nil {
 		return "", err
 	}
 
@@ -208,6 +351,8 @@ func (a *App) RunEnv(vault string, include, exclude []string) (map[string]string
 	return filter.FilterSecrets(secrets, include, exclude), nil
 }
 
+// vaultExists reports whether vault holds secrets or inherits from other
+// vaults.
 func (a *App) vaultExists(vault string) (bool, error) {
 	for v, err := range a.store.ListVaults() {
 		if err != nil {
@@ -217,7 +362,11 @@ func (a *App) vaultExists(vault string) (bool, error) {
 			return true, nil
 		}
 	}
-	return false, nil
+	parents, err := a.store.GetVaultParents(vault)
+	if err != nil {
+		return false, err
+	}
+	return len(parents) > 0, nil
 }
 
 // appendToEnvFile appends a key-value pair to an .env file
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestDiffVaults(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("staging", "DEBUG", "true")
+	a.Set("staging", "API_URL", "https://staging")
+	a.Set("staging", "SAME", "x")
+	a.Set("production", "API_URL", "https://prod")
+	a.Set("production", "SAME", "x")
+	a.Set("production", "SENTRY_DSN", "dsn")
+
+	entries, err := a.DiffVaults("staging", "production", nil, nil)
+	if err != nil {
+		t.Fatalf("DiffVaults error: %v", err)
+	}
+	want := []DiffEntry{
+		{Name: "API_URL", Kind: DiffChanged, Left: "https://staging", Right: "https://prod"},
+		{Name: "DEBUG", Kind: DiffOnlyLeft, Left: "true"},
+		{Name: "SENTRY_DSN", Kind: DiffOnlyRight, Right: "dsn"},
+	}
+	if !slices.Equal(entries, want) {
+		t.Errorf("DiffVaults = %v, want %v", entries, want)
+	}
+
+	entries, _ = a.DiffVaults("staging", "production", nil, []string{"DEBUG", "SENTRY_*"})
+	if len(entries) != 1 || entries[0].Name != "API_URL" {
+		t.Errorf("DiffVaults with exclude = %v", entries)
+	}
+
+	if _, err := a.DiffVaults("staging", "missing", nil, nil); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("DiffVaults missing vault error = %v, want ErrVaultNotFound", err)
+	}
+}
+
+func TestDiffFile(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("production", "API_URL", "https://prod")
+	a.Set("production", "TOKEN", "abc")
+
+	path := createTempEnvFile(t, map[string]string{"API_URL": "https://prod", "TOKEN": "old", "EXTRA": "1"})
+	defer os.Remove(path)
+
+	entries, err := a.DiffFile("production", path, nil, nil)
+	if err != nil {
+		t.Fatalf("DiffFile error: %v", err)
+	}
+	want := []DiffEntry{
+		{Name: "EXTRA", Kind: DiffOnlyRight, Right: "1"},
+		{Name: "TOKEN", Kind: DiffChanged, Left: "abc", Right: "old"},
+	}
+	if !slices.Equal(entries, want) {
+		t.Errorf("DiffFile = %v, want %v", entries, want)
+	}
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDue(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	now := time.Now()
+	day := 24 * time.Hour
+
+	past, soon, later := now.Add(-day), now.Add(3*day), now.Add(60*day)
+	every := 30 * day
+	a.SetWithMetadata("prod", "EXPIRED", "v", MetadataUpdate{ExpiresAt: &past})
+	a.SetWithMetadata("prod", "SOON", "v", MetadataUpdate{ExpiresAt: &soon})
+	a.SetWithMetadata("dev", "LATER", "v", MetadataUpdate{ExpiresAt: &later})
+	a.SetWithMetadata("dev", "ROTATED", "v", MetadataUpdate{RotateEvery: &every})
+	a.Set("dev", "PLAIN", "v")
+
+	due, err := a.Due(now, 7*day)
+	if err != nil {
+		t.Fatalf("Due error: %v", err)
+	}
+	if len(due) != 2 {
+		t.Fatalf("Due = %+v, want EXPIRED and SOON", due)
+	}
+	if due[0].Name != "EXPIRED" || !due[0].Overdue || due[0].Reason != DueExpired {
+		t.Errorf("due[0] = %+v, want overdue EXPIRED", due[0])
+	}
+	if due[1].Name != "SOON" || due[1].Overdue {
+		t.Errorf("due[1] = %+v, want upcoming SOON", due[1])
+	}
+
+	due, _ = a.Due(now.Add(31*day), 0)
+	if len(due) != 3 || due[2].Name != "ROTATED" || due[2].Reason != DueRotation {
+		t.Errorf("Due a month later = %+v, want EXPIRED, SOON and ROTATED overdue", due)
+	}
+}
+
+func TestSet_ClearsExpiryOfReplacedValue(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	past := time.Now().Add(-time.Hour)
+	every := 90 * 24 * time.Hour
+	a.SetWithMetadata("prod", "KEY", "old", MetadataUpdate{ExpiresAt: &past, RotateEvery: &every})
+
+	expired, err := a.Expired("prod", []string{"KEY"}, time.Now())
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("Expired = %+v, %v; want KEY", expired, err)
+	}
+
+	a.Set("prod", "KEY", "new")
+
+	if expired, _ := a.Expired("prod", []string{"KEY"}, time.Now()); len(expired) != 0 {
+		t.Errorf("Expired after new value = %+v, want none", expired)
+	}
+	if m, _ := a.Describe("prod", "KEY"); m.RotateEvery != every {
+		t.Errorf("RotateEvery = %v, want rotation policy kept", m.RotateEvery)
+	}
+}
+
+func TestRollback_KeepsExpiry(t *testing.T) {
+	for _, encryptNames := range []bool{false, true} {
+		a, _, _ := setupTestApp(t)
+		a.Set("prod", "KEY", "old")
+		past := time.Now().Add(-time.Hour)
+		a.SetWithMetadata("prod", "KEY", "new", MetadataUpdate{ExpiresAt: &past})
+		if encryptNames {
+			if _, err := a.EncryptNames(); err != nil {
+				t.Fatalf("EncryptNames error: %v", err)
+			}
+		}
+
+		if err := a.Rollback("prod", "KEY", 1); err != nil {
+			t.Fatalf("Rollback error: %v", err)
+		}
+		if got, _ := a.Get("prod", "KEY"); got != "old" {
+			t.Errorf("value after rollback = %q, want old", got)
+		}
+		if expired, _ := a.Expired("prod", []string{"KEY"}, time.Now()); len(expired) != 1 {
+			t.Errorf("Expired after rollback (names encrypted: %v) = %+v, want KEY still expired", encryptNames, expired)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func TestHistory_ArchivesOverwrittenValues(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+
+	a.Set("prod", "DB_PASSWORD", "first")
+	a.Set("prod", "DB_PASSWORD", "second")
+	a.Set("prod", "DB_PASSWORD", "third")
+
+	versions, err := a.History("prod", "DB_PASSWORD")
+	if err != nil {
+		t.Fatalf("History error: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("History returned %d versions, want 2", len(versions))
+	}
+	if versions[0].Number != 2 || versions[1].Number != 1 {
+		t.Errorf("versions not newest first: got %d, %d", versions[0].Number, versions[1].Number)
+	}
+
+	old, err := a.GetVersion("prod", "DB_PASSWORD", 1)
+	if err != nil {
+		t.Fatalf("GetVersion error: %v", err)
+	}
+	if old != "first" {
+		t.Errorf("version 1 = %q, want %q", old, "first")
+	}
+}
+
+func TestHistory_NotFound(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+
+	_, err := a.History("prod", "MISSING")
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("History error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRollback_RestoresAndArchivesCurrent(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+
+	a.Set("prod", "DB_PASSWORD", "good")
+	a.Set("prod", "DB_PASSWORD", "oops")
+
+	if err := a.Rollback("prod", "DB_PASSWORD", 1); err != nil {
+		t.Fatalf("Rollback error: %v", err)
+	}
+
+	current, err := a.Get("prod", "DB_PASSWORD")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if current != "good" {
+		t.Errorf("current = %q, want %q", current, "good")
+	}
+
+	replaced, err := a.GetVersion("prod", "DB_PASSWORD", 2)
+	if err != nil {
+		t.Fatalf("GetVersion error: %v", err)
+	}
+	if replaced != "oops" {
+		t.Errorf("version 2 = %q, want %q", replaced, "oops")
+	}
+}
+
+func TestRollback_UnknownVersion(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+
+	a.Set("prod", "DB_PASSWORD", "only")
+
+	err := a.Rollback("prod", "DB_PASSWORD", 5)
+	if !errors.Is(err, store.ErrVersionNotFound) {
+		t.Errorf("Rollback error = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/importer"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func TestInheritance_ChildOverridesParents(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("base", "LOG_LEVEL", "info")
+	a.Set("base", "API_URL", "https://api")
+	a.Set("shared", "LOG_LEVEL", "warn")
+	a.Set("shared", "SENTRY_DSN", "dsn")
+	a.Set("production", "LOG_LEVEL", "error")
+
+	if err := a.SetParents("shared", []string{"base"}); err != nil {
+		t.Fatalf("SetParents(shared) error: %v", err)
+	}
+	if err := a.SetParents("production", []string{"shared"}); err != nil {
+		t.Fatalf("SetParents(production) error: %v", err)
+	}
+
+	chain, _ := a.Chain("production")
+	if !slices.Equal(chain, []string{"production", "shared", "base"}) {
+		t.Errorf("Chain = %v", chain)
+	}
+
+	secrets, err := a.GetAllSecrets("production")
+	if err != nil {
+		t.Fatalf("GetAllSecrets error: %v", err)
+	}
+	if secrets["LOG_LEVEL"] != "error" || secrets["SENTRY_DSN"] != "dsn" || secrets["API_URL"] != "https://api" {
+		t.Errorf("GetAllSecrets = %v", secrets)
+	}
+
+	if value, err := a.Get("production", "API_URL"); err != nil || value != "https://api" {
+		t.Errorf("Get inherited = %q, %v", value, err)
+	}
+	if _, err := a.Get("production", "MISSING"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get missing error = %v, want ErrNotFound", err)
+	}
+
+	refs, _ := a.ResolvedRefs("production")
+	want := []store.SecretRef{
+		{Vault: "base", Name: "API_URL"},
+		{Vault: "production", Name: "LOG_LEVEL"},
+		{Vault: "shared", Name: "SENTRY_DSN"},
+	}
+	if !slices.Equal(refs, want) {
+		t.Errorf("ResolvedRefs = %v, want %v", refs, want)
+	}
+}
+
+func TestSetParents_Validation(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("base", "A", "a")
+	a.Set("dev", "B", "b")
+
+	if err := a.SetParents("dev", []string{"dev"}); !errors.Is(err, ErrInheritanceCycle) {
+		t.Errorf("self inheritance error = %v, want ErrInheritanceCycle", err)
+	}
+	if err := a.SetParents("dev", []string{"missing"}); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("missing parent error = %v, want ErrVaultNotFound", err)
+	}
+	if err := a.SetParents("dev", []string{"base"}); err != nil {
+		t.Fatalf("SetParents error: %v", err)
+	}
+	if err := a.SetParents("base", []string{"dev"}); !errors.Is(err, ErrInheritanceCycle) {
+		t.Errorf("cycle error = %v, want ErrInheritanceCycle", err)
+	}
+
+	// A vault holding no secrets of its own exists through its parents.
+	if err := a.SetParents("qa", []string{"dev"}); err != nil {
+		t.Fatalf("SetParents(qa) error: %v", err)
+	}
+	env, err := a.RunEnv("qa", nil, nil)
+	if err != nil || env["A"] != "a" || env["B"] != "b" {
+		t.Errorf("RunEnv(qa) = %v, %v", env, err)
+	}
+
+	if _, err := a.DeleteVault("base"); !errors.Is(err, ErrVaultInherited) {
+		t.Errorf("DeleteVault of a parent error = %v, want ErrVaultInherited", err)
+	}
+	if _, err := a.RenameVault("base", "common"); err != nil {
+		t.Fatalf("RenameVault error: %v", err)
+	}
+	if parents, _ := a.Parents("dev"); !slices.Equal(parents, []string{"common"}) {
+		t.Errorf("parents after RenameVault = %v, want [common]", parents)
+	}
+
+	if err := a.SetParents("dev", nil); err != nil {
+		t.Fatalf("SetParents(nil) error: %v", err)
+	}
+	if _, err := a.Get("dev", "A"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get after removing inheritance error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestImport_ComparesOwnSecretsOnly(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("base", "KEY", "same")
+	a.Set("dev", "OTHER", "x")
+	a.SetParents("dev", []string{"base"})
+
+	path := createTempEnvFile(t, map[string]string{"KEY": "same"})
+	preview, err := a.Import("dev", importer.ImportOptions{SourcePath: path, Format: "env"})
+	if err != nil {
+		t.Fatalf("Import error: %v", err)
+	}
+	if !slices.Equal(preview.NewKeys, []string{"KEY"}) {
+		t.Errorf("NewKeys = %v, want the inherited key imported into dev", preview.NewKeys)
+	}
+}
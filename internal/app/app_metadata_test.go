@@ -0,0 +1,73 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func TestUpdateMetadata(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("prod", "STRIPE_KEY", "sk_live")
+
+	desc, owner := "  Payments API key ", "payments"
+	m, err := a.UpdateMetadata("prod", "STRIPE_KEY", MetadataUpdate{
+		Description: &desc,
+		Owner:       &owner,
+		AddTags:     []string{"pci", "payments", "pci"},
+	})
+	if err != nil {
+		t.Fatalf("UpdateMetadata error: %v", err)
+	}
+	if m.Description != "Payments API key" || m.Owner != "payments" {
+		t.Errorf("metadata = %+v, want trimmed description and owner", m)
+	}
+	if !slices.Equal(m.Tags, []string{"payments", "pci"}) {
+		t.Errorf("Tags = %v, want [payments pci]", m.Tags)
+	}
+
+	m, err = a.UpdateMetadata("prod", "STRIPE_KEY", MetadataUpdate{RemoveTags: []string{"pci"}})
+	if err != nil {
+		t.Fatalf("UpdateMetadata error: %v", err)
+	}
+	if !slices.Equal(m.Tags, []string{"payments"}) || m.Owner != "payments" {
+		t.Errorf("metadata after removing tag = %+v", m)
+	}
+
+	if _, err := a.UpdateMetadata("prod", "STRIPE_KEY", MetadataUpdate{AddTags: []string{"a,b"}}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("UpdateMetadata with comma tag error = %v, want ErrInvalidTag", err)
+	}
+	if _, err := a.UpdateMetadata("prod", "MISSING", MetadataUpdate{Owner: &owner}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("UpdateMetadata of missing secret error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListMetadata_FiltersByTag(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("prod", "B_KEY", "b")
+	a.Set("prod", "A_KEY", "a")
+	a.Set("prod", "C_KEY", "c")
+	a.UpdateMetadata("prod", "C_KEY", MetadataUpdate{AddTags: []string{"db"}})
+	a.UpdateMetadata("prod", "A_KEY", MetadataUpdate{AddTags: []string{"db"}})
+
+	all, err := a.ListMetadata("prod", "")
+	if err != nil {
+		t.Fatalf("ListMetadata error: %v", err)
+	}
+	if len(all) != 3 || all[0].Name != "A_KEY" || all[2].Name != "C_KEY" {
+		t.Errorf("ListMetadata = %+v, want 3 secrets sorted by name", all)
+	}
+	if all[0].UpdatedAt.IsZero() {
+		t.Error("ListMetadata returned no update time")
+	}
+
+	tagged, err := a.ListMetadata("prod", "db")
+	if err != nil {
+		t.Fatalf("ListMetadata error: %v", err)
+	}
+	if len(tagged) != 2 || tagged[0].Name != "A_KEY" || tagged[1].Name != "C_KEY" {
+		t.Errorf("ListMetadata(db) = %+v, want A_KEY and C_KEY", tagged)
+	}
+}
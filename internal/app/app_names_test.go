@@ -0,0 +1,128 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/store/blind"
+)
+
+func TestEncryptNames_RoundTrip(t *testing.T) {
+	a, memStore, engine := setupTestApp(t)
+
+	a.Set("stripe-api", "STRIPE_KEY", "old")
+	a.Set("stripe-api", "STRIPE_KEY", "sk_live")
+	a.Set("dev", "DB_URL", "postgres://")
+
+	stats, err := a.EncryptNames()
+	if err != nil {
+		t.Fatalf("EncryptNames error: %v", err)
+	}
+	if stats.Secrets != 2 || stats.Vaults != 2 {
+		t.Errorf("stats = %+v, want 2 secrets in 2 vaults", stats)
+	}
+	if !a.NamesEncrypted() {
+		t.Error("NamesEncrypted() = false after EncryptNames")
+	}
+
+	for vault, err := range memStore.ListVaults() {
+		if err != nil {
+			t.Fatalf("ListVaults error: %v", err)
+		}
+		if strings.Contains(vault, "stripe") || vault == "dev" {
+			t.Errorf("raw store still holds plaintext vault %q", vault)
+		}
+	}
+
+	// A fresh process unlocks the names with the master key.
+	named, err := UnlockNames(memStore, engine)
+	if err != nil {
+		t.Fatalf("UnlockNames error: %v", err)
+	}
+	if _, ok := named.(*blind.Store); !ok {
+		t.Fatalf("UnlockNames returned %T, want *blind.Store", named)
+	}
+	reopened := New(named, engine)
+	if got, err := reopened.Get("stripe-api", "STRIPE_KEY"); err != nil || got != "sk_live" {
+		t.Errorf("Get after EncryptNames = %q, %v; want %q", got, err, "sk_live")
+	}
+	if got, err := reopened.GetVersion("stripe-api", "STRIPE_KEY", 1); err != nil || got != "old" {
+		t.Errorf("GetVersion after EncryptNames = %q, %v; want %q", got, err, "old")
+	}
+
+	if _, err := reopened.EncryptNames(); !errors.Is(err, ErrNamesEncrypted) {
+		t.Errorf("second EncryptNames error = %v, want ErrNamesEncrypted", err)
+	}
+
+	if _, err := reopened.DecryptNames(); err != nil {
+		t.Fatalf("DecryptNames error: %v", err)
+	}
+	if got, err := New(memStore, engine).Get("dev", "DB_URL"); err != nil || got != "postgres://" {
+		t.Errorf("Get from raw store after DecryptNames = %q, %v; want %q", got, err, "postgres://")
+	}
+	if _, err := memStore.GetMeta(nameKeyMetaKey); err == nil {
+		t.Error("name key kept after DecryptNames")
+	}
+}
+
+func TestRekey_RewrapsNameKey(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	a.Set("prod", "KEY", "value")
+	if _, err := a.EncryptNames(); err != nil {
+		t.Fatalf("EncryptNames error: %v", err)
+	}
+
+	next := newTestEngine(t)
+	if _, err := a.Rekey(next, nil); err != nil {
+		t.Fatalf("Rekey error: %v", err)
+	}
+
+	named, err := UnlockNames(memStore, next)
+	if err != nil {
+		t.Fatalf("UnlockNames with new key error: %v", err)
+	}
+	if got, err := New(named, next).Get("prod", "KEY"); err != nil || got != "value" {
+		t.Errorf("Get after rekey = %q, %v; want %q", got, err, "value")
+	}
+}
+
+func TestEncryptNames_SealsMetadata(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	owner := "payments"
+	a.SetWithMetadata("prod", "STRIPE_KEY", "sk_live", MetadataUpdate{Owner: &owner, AddTags: []string{"pci"}})
+	a.SetWithMetadata("prod", "OLD_KEY", "sk_old", MetadataUpdate{Owner: &owner})
+	a.Delete("prod", "OLD_KEY")
+
+	if _, err := a.EncryptNames(); err != nil {
+		t.Fatalf("EncryptNames error: %v", err)
+	}
+	for ref, err := range memStore.Search("*") {
+		if err != nil {
+			t.Fatalf("Search error: %v", err)
+		}
+		if m, _ := memStore.GetMetadata(ref.Vault, ref.Name); m.Owner != "" || m.Tags != nil {
+			t.Errorf("raw store holds plaintext metadata %+v", m)
+		}
+	}
+	trashed, _ := a.ListTrash()
+	if m, _ := memStore.GetTrashMetadata(trashed[0].ID); m.Owner != "" {
+		t.Errorf("raw trash holds plaintext metadata %+v", m)
+	}
+	if m, err := a.Describe("prod", "STRIPE_KEY"); err != nil || m.Owner != "payments" || len(m.Tags) != 1 {
+		t.Errorf("GetMetadata with encrypted names = %+v, %v; want owner and tag", m, err)
+	}
+
+	if _, err := a.DecryptNames(); err != nil {
+		t.Fatalf("DecryptNames error: %v", err)
+	}
+	if m, err := memStore.GetMetadata("prod", "STRIPE_KEY"); err != nil || m.Owner != "payments" {
+		t.Errorf("raw metadata after DecryptNames = %+v, %v; want plaintext owner", m, err)
+	}
+	if err := a.Restore("prod", "OLD_KEY"); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if m, err := a.Describe("prod", "OLD_KEY"); err != nil || m.Owner != "payments" {
+		t.Errorf("restored metadata = %+v, %v; want plaintext owner", m, err)
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/exporter"
+	"github.com/ossydotpy/veil/internal/generator"
+)
+
+// setTemplate stores a secret marked as a template.
+func setTemplate(t *testing.T, a *App, vault, name, value string) {
+	t.Helper()
+	yes := true
+	if err := a.SetWithMetadata(vault, name, value, MetadataUpdate{Template: &yes}); err != nil {
+		t.Fatalf("SetWithMetadata(%s/%s) error: %v", vault, name, err)
+	}
+}
+
+func TestReferences_Expand(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("production", "DB_USER", "app")
+	a.Set("production", "DB_PASSWORD", "s3cret")
+	a.Set("production", "DB_HOST", "db:5432")
+	setTemplate(t, a, "production", "DATABASE_URL", "postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/app")
+	a.Set("shared", "SENTRY_DSN", "https://sentry/1")
+	setTemplate(t, a, "production", "SENTRY", "${shared/SENTRY_DSN}")
+	setTemplate(t, a, "production", "LITERAL", "$${DB_USER} ${HOME:-/root} ${not closed")
+	a.Set("production", "PLAIN", "$${DB_USER} ${DB_HOST}")
+
+	tests := map[string]string{
+		"DATABASE_URL": "postgres://app:s3cret@db:5432/app",
+		"SENTRY":       "https://sentry/1",
+		"LITERAL":      "${DB_USER} ${HOME:-/root} ${not closed",
+		"PLAIN":        "$${DB_USER} ${DB_HOST}",
+	}
+	for name, want := range tests {
+		if got, err := a.Get("production", name); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	raw, err := a.GetRaw("production", "DATABASE_URL")
+	if err != nil || raw != "postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}/app" {
+		t.Errorf("GetRaw = %q, %v", raw, err)
+	}
+
+	// A rotated part shows up in the composed value.
+	a.Set("production", "DB_PASSWORD", "n3w")
+	secrets, err := a.GetAllSecrets("production")
+	if err != nil {
+		t.Fatalf("GetAllSecrets error: %v", err)
+	}
+	if secrets["DATABASE_URL"] != "postgres://app:n3w@db:5432/app" {
+		t.Errorf("GetAllSecrets DATABASE_URL = %q", secrets["DATABASE_URL"])
+	}
+}
+
+func TestReferences_ResolveThroughInheritingVault(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("base", "DB_HOST", "localhost")
+	setTemplate(t, a, "base", "DATABASE_URL", "postgres://${DB_HOST}/app")
+	a.Set("production", "DB_HOST", "prod-db")
+	if err := a.SetParents("production", []string{"base"}); err != nil {
+		t.Fatalf("SetParents error: %v", err)
+	}
+
+	if got, _ := a.Get("base", "DATABASE_URL"); got != "postgres://localhost/app" {
+		t.Errorf("Get(base) = %q", got)
+	}
+	if got, _ := a.Get("production", "DATABASE_URL"); got != "postgres://prod-db/app" {
+		t.Errorf("Get(production) = %q", got)
+	}
+}
+
+func TestReferences_Errors(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	setTemplate(t, a, "v", "A", "${B}")
+	setTemplate(t, a, "v", "B", "x${other/C}")
+	setTemplate(t, a, "other", "C", "${v/A}")
+	setTemplate(t, a, "v", "MISSING", "${NOPE}")
+
+	_, err := a.Get("v", "A")
+	if !errors.Is(err, ErrReferenceCycle) {
+		t.Fatalf("Get cycle error = %v, want ErrReferenceCycle", err)
+	}
+	if !strings.Contains(err.Error(), "v/A -> v/B -> other/C -> v/A") {
+		t.Errorf("cycle error = %q", err)
+	}
+
+	if _, err := a.Get("v", "MISSING"); !errors.Is(err, ErrUnresolvedReference) {
+		t.Errorf("Get missing error = %v, want ErrUnresolvedReference", err)
+	}
+	if _, err := a.GetAllSecrets("v"); err == nil {
+		t.Error("GetAllSecrets succeeded with broken references")
+	}
+
+	dir := t.TempDir()
+	if _, err := a.Export("v", exporter.ExportOptions{TargetPath: dir + "/.env", Format: "env", Raw: true}); err != nil {
+		t.Errorf("Export --raw error: %v", err)
+	}
+}
+
+func TestReferences_GeneratedValuesAreLiteral(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("v", "DB_USER", "app")
+	yes := true
+	if _, err := a.Generate("v", "PASSWORD", generator.Options{Length: 16}, MetadataUpdate{Template: &yes}); err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+	// Generated passwords may contain $, { and }; pin one that looks like
+	// a template.
+	a.Set("v", "PASSWORD", "a$${DB_USER}b${DB_USER}c")
+
+	if got, err := a.Get("v", "PASSWORD"); err != nil || got != "a$${DB_USER}b${DB_USER}c" {
+		t.Errorf("Get = %q, %v, want the value unchanged", got, err)
+	}
+	secrets, err := a.GetAllSecrets("v")
+	if err != nil || secrets["PASSWORD"] != "a$${DB_USER}b${DB_USER}c" {
+		t.Errorf("GetAllSecrets PASSWORD = %q, %v, want the value unchanged", secrets["PASSWORD"], err)
+	}
+}
+
+func TestReferences_PlainValueWithUnresolvedReference(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("v", "GREETING", "hello ${HOME}")
+	a.Set("v", "OTHER", "x")
+
+	if got, err := a.Get("v", "GREETING"); err != nil || got != "hello ${HOME}" {
+		t.Errorf("Get = %q, %v, want the value unchanged", got, err)
+	}
+	secrets, err := a.GetAllSecrets("v")
+	if err != nil {
+		t.Fatalf("GetAllSecrets error: %v", err)
+	}
+	if secrets["GREETING"] != "hello ${HOME}" || secrets["OTHER"] != "x" {
+		t.Errorf("GetAllSecrets = %v", secrets)
+	}
+
+	dir := t.TempDir()
+	if _, err := a.Export("v", exporter.ExportOptions{TargetPath: dir + "/.env", Format: "env"}); err != nil {
+		t.Errorf("Export error: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func newTestEngine(t *testing.T) *crypto.Engine {
+	t.Helper()
+	key, err := crypto.GenerateRandomKey()
+	if err != nil {
+		t.Fatalf("GenerateRandomKey error: %v", err)
+	}
+	engine, err := crypto.NewEngine(key)
+	if err != nil {
+		t.Fatalf("NewEngine error: %v", err)
+	}
+	return engine
+}
+
+func TestRekey_ReencryptsSecretsAndVersions(t *testing.T) {
+	a, memStore, oldEngine := setupTestApp(t)
+
+	a.Set("prod", "DB_PASSWORD", "old")
+	a.Set("prod", "DB_PASSWORD", "current")
+	a.Set("dev", "API_KEY", "dev-key")
+
+	oldKey, _ := memStore.GetVaultKey("prod")
+	oldDataKey, err := oldEngine.DecryptWithAAD(oldKey, vaultKeyAAD("prod"))
+	if err != nil {
+		t.Fatalf("unwrap vault key error: %v", err)
+	}
+
+	next := newTestEngine(t)
+	stats, err := a.Rekey(next, nil)
+	if err != nil {
+		t.Fatalf("Rekey error: %v", err)
+	}
+	// Both vaults get new data keys, so every value is re-encrypted.
+	if stats.VaultKeys != 2 || stats.Secrets != 2 || stats.Versions != 1 {
+		t.Errorf("stats = %+v, want 2 vault keys, 2 secrets and 1 version", stats)
+	}
+
+	// A data key unwrapped with the old master key opens nothing anymore.
+	newKey, _ := memStore.GetVaultKey("prod")
+	if dataKey, err := next.DecryptWithAAD(newKey, vaultKeyAAD("prod")); err != nil || dataKey == oldDataKey {
+		t.Errorf("data key of prod after rekey = %v; want a new key", err)
+	}
+
+	rekeyed := New(memStore, next)
+	if got, err := rekeyed.Get("prod", "DB_PASSWORD"); err != nil || got != "current" {
+		t.Errorf("Get after rekey = %q, %v; want %q", got, err, "current")
+	}
+	if got, err := rekeyed.GetVersion("prod", "DB_PASSWORD", 1); err != nil || got != "old" {
+		t.Errorf("GetVersion after rekey = %q, %v; want %q", got, err, "old")
+	}
+
+	if _, err := New(memStore, oldEngine).Get("dev", "API_KEY"); err == nil {
+		t.Error("old key should no longer decrypt secrets")
+	}
+}
+
+func TestRekey_AllOrNothing(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+
+	a.Set("a", "GOOD", "value")
+	// A value the current key cannot decrypt aborts the whole rekey.
+	memStore.Save("b", "CORRUPT", "deadbeef")
+
+	if _, err := a.Rekey(newTestEngine(t), nil); err == nil {
+		t.Fatal("Rekey should fail when a secret cannot be decrypted")
+	}
+
+	if got, err := a.Get("a", "GOOD"); err != nil || got != "value" {
+		t.Errorf("Get after failed rekey = %q, %v; want original value", got, err)
+	}
+}
+
+func TestRekey_StoresKDFParams(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	a.Set("a", "KEY", "value")
+
+	params, err := crypto.NewKDFParams()
+	if err != nil {
+		t.Fatalf("NewKDFParams error: %v", err)
+	}
+	next, err := crypto.NewEngineFromPassphrase("passphrase", params)
+	if err != nil {
+		t.Fatalf("NewEngineFromPassphrase error: %v", err)
+	}
+
+	if _, err := a.Rekey(next, params); err != nil {
+		t.Fatalf("Rekey error: %v", err)
+	}
+
+	stored, err := LoadKDFParams(memStore)
+	if err != nil || stored == nil {
+		t.Fatalf("LoadKDFParams = %v, %v; want parameters", stored, err)
+	}
+	if stored.Salt != params.Salt {
+		t.Errorf("stored salt = %q, want %q", stored.Salt, params.Salt)
+	}
+}
+
+func TestRekey_MovesMasterKeyValuesUnderVaultKeys(t *testing.T) {
+	a, memStore, engine := setupTestApp(t)
+
+	// Values written before vault keys existed are encrypted directly with
+	// the master key.
+	direct, _ := engine.EncryptWithAAD("direct", secretAAD("prod", "OLD"))
+	memStore.Save("prod", "OLD", direct)
+
+	next := newTestEngine(t)
+	stats, err := a.Rekey(next, nil)
+	if err != nil {
+		t.Fatalf("Rekey error: %v", err)
+	}
+	if stats.VaultKeys != 1 || stats.Secrets != 1 {
+		t.Errorf("stats = %+v, want 1 vault key and 1 secret", stats)
+	}
+
+	raw, _ := memStore.Get("prod", "OLD")
+	if id, _ := crypto.KeyIDOf(raw); id == next.KeyID() {
+		t.Error("value was encrypted with the master key instead of the vault key")
+	}
+	if got, err := New(memStore, next).Get("prod", "OLD"); err != nil || got != "direct" {
+		t.Errorf("Get after rekey = %q, %v; want %q", got, err, "direct")
+	}
+}
+
+func TestRotateVaultKey(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+
+	a.Set("prod", "DB_PASSWORD", "old")
+	a.Set("prod", "DB_PASSWORD", "current")
+	a.Set("dev", "API_KEY", "dev-key")
+	before, _ := memStore.Get("dev", "API_KEY")
+	oldKey, _ := memStore.GetVaultKey("prod")
+
+	stats, err := a.RotateVaultKey("prod")
+	if err != nil {
+		t.Fatalf("RotateVaultKey error: %v", err)
+	}
+	if stats.VaultKeys != 1 || stats.Secrets != 1 || stats.Versions != 1 {
+		t.Errorf("stats = %+v, want 1 vault key, 1 secret and 1 version", stats)
+	}
+
+	if newKey, _ := memStore.GetVaultKey("prod"); newKey == oldKey {
+		t.Error("vault key was not replaced")
+	}
+	if after, _ := memStore.Get("dev", "API_KEY"); after != before {
+		t.Error("rotating prod re-encrypted another vault")
+	}
+	if got, err := a.GetVersion("prod", "DB_PASSWORD", 1); err != nil || got != "old" {
+		t.Errorf("GetVersion after rotation = %q, %v; want %q", got, err, "old")
+	}
+
+	if _, err := a.RotateVaultKey("missing"); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("RotateVaultKey of missing vault error = %v, want ErrVaultNotFound", err)
+	}
+}
+
+func TestSet_FailedSaveLeavesNoVaultKey(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	memStore.SaveErr = errors.New("disk full")
+
+	if err := a.Set("prod", "DB_PASSWORD", "secret"); err == nil {
+		t.Fatal("Set should fail when the value cannot be saved")
+	}
+	if _, err := memStore.GetVaultKey("prod"); !errors.Is(err, store.ErrVaultKeyNotFound) {
+		t.Errorf("GetVaultKey after failed Set error = %v, want ErrVaultKeyNotFound", err)
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/generator"
+)
+
+func TestRotate_UsesStoredRecipe(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+
+	old, err := a.Generate("prod", "API_KEY", generator.Options{Type: "apikey", Format: "hex", Prefix: "sk_"}, MetadataUpdate{})
+	if err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+
+	result, err := a.Rotate("prod", "API_KEY", false)
+	if err != nil {
+		t.Fatalf("Rotate error: %v", err)
+	}
+	if len(result.Rotated) != 1 {
+		t.Fatalf("Rotated = %+v, want API_KEY", result.Rotated)
+	}
+	r := result.Rotated[0]
+	if r.Version != 1 || r.Recipe.Type != "apikey" || r.Recipe.Format != "hex" {
+		t.Errorf("rotation = %+v, want apikey/hex recipe with old value as v1", r)
+	}
+
+	current, _ := a.Get("prod", "API_KEY")
+	if current == old || current != r.Value || !strings.HasPrefix(current, "sk_") {
+		t.Errorf("value after rotate = %q, want a new sk_ key", current)
+	}
+	if prev, _ := a.GetVersion("prod", "API_KEY", 1); prev != old {
+		t.Errorf("archived value = %q, want %q", prev, old)
+	}
+}
+
+func TestRotate_PatternSkipsSecretsWithoutRecipe(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Generate("prod", "DB_PASSWORD", generator.Options{Length: 16}, MetadataUpdate{})
+	a.Set("prod", "DB_HOST", "localhost")
+	a.Generate("prod", "JWT_SECRET", generator.Options{Type: "jwt"}, MetadataUpdate{})
+
+	result, err := a.Rotate("prod", "DB_*", false)
+	if err != nil {
+		t.Fatalf("Rotate error: %v", err)
+	}
+	if len(result.Rotated) != 1 || result.Rotated[0].Name != "DB_PASSWORD" {
+		t.Errorf("Rotated = %+v, want DB_PASSWORD", result.Rotated)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "DB_HOST" {
+		t.Errorf("Skipped = %v, want [DB_HOST]", result.Skipped)
+	}
+	if got, _ := a.Get("prod", "DB_HOST"); got != "localhost" {
+		t.Errorf("DB_HOST = %q, want it untouched", got)
+	}
+
+	if _, err := a.Rotate("prod", "DB_HOST", false); !errors.Is(err, ErrNoRecipe) {
+		t.Errorf("Rotate of secret without recipe error = %v, want ErrNoRecipe", err)
+	}
+
+	all, err := a.Rotate("prod", "", false)
+	if err != nil || len(all.Rotated) != 2 {
+		t.Errorf("Rotate all = %+v, %v; want 2 secrets", all, err)
+	}
+}
+
+func TestRotate_UpdatesLinkedEnvFile(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	envPath := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(envPath, []byte("OTHER=1\n"), 0600)
+
+	if _, err := a.Generate("prod", "TOKEN", generator.Options{ToEnv: envPath}, MetadataUpdate{}); err != nil {
+		t.Fatalf("Generate error: %v", err)
+	}
+
+	result, err := a.Rotate("prod", "TOKEN", true)
+	if err != nil {
+		t.Fatalf("Rotate error: %v", err)
+	}
+	if result.Rotated[0].EnvErr != nil {
+		t.Fatalf("EnvErr = %v", result.Rotated[0].EnvErr)
+	}
+
+	data, _ := os.ReadFile(envPath)
+	if want := "OTHER=1\nTOKEN=" + result.Rotated[0].Value + "\n"; string(data) != want {
+		t.Errorf(".env = %q, want %q", data, want)
+	}
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/envfile"
+)
+
+func setupSync(t *testing.T) (*App, string) {
+	t.Helper()
+	a, _, _ := setupTestApp(t)
+	a.Set("dev", "VAULT_ONLY", "v")
+	a.Set("dev", "SAME", "s")
+	a.Set("dev", "CONFLICT", "from-vault")
+
+	path := filepath.Join(t.TempDir(), ".env")
+	content := "# local settings\nSAME=s\nCONFLICT=from-file\nFILE_ONLY=f\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return a, path
+}
+
+func TestSync_RefusesConflictsByDefault(t *testing.T) {
+	a, path := setupSync(t)
+
+	result, err := a.Sync("dev", path, SyncOptions{})
+	if !errors.Is(err, ErrSyncConflict) {
+		t.Fatalf("Sync error = %v, want ErrSyncConflict", err)
+	}
+	if !slices.Equal(result.Conflicts, []string{"CONFLICT"}) {
+		t.Errorf("Conflicts = %v", result.Conflicts)
+	}
+	if _, err := a.Get("dev", "FILE_ONLY"); err == nil {
+		t.Error("Sync with conflicts changed the vault")
+	}
+}
+
+func TestSync_PreferFile(t *testing.T) {
+	a, path := setupSync(t)
+
+	result, err := a.Sync("dev", path, SyncOptions{Prefer: SyncPreferFile})
+	if err != nil {
+		t.Fatalf("Sync error: %v", err)
+	}
+	if !slices.Equal(result.ToVault.NewKeys, []string{"FILE_ONLY"}) || !slices.Equal(result.ToVault.UpdatedKeys, []string{"CONFLICT"}) {
+		t.Errorf("ToVault = %+v", result.ToVault)
+	}
+	if !slices.Equal(result.ToFile.NewKeys, []string{"VAULT_ONLY"}) || len(result.ToFile.UpdatedKeys) != 0 {
+		t.Errorf("ToFile = %+v", result.ToFile)
+	}
+
+	if v, _ := a.Get("dev", "CONFLICT"); v != "from-file" {
+		t.Errorf("vault CONFLICT = %q, want from-file", v)
+	}
+	if v, _ := a.Get("dev", "FILE_ONLY"); v != "f" {
+		t.Errorf("vault FILE_ONLY = %q", v)
+	}
+	vars, _ := envfile.ParseEnvFile(path)
+	if vars["VAULT_ONLY"] != "v" || vars["CONFLICT"] != "from-file" {
+		t.Errorf("file = %v", vars)
+	}
+
+	// A second sync has nothing left to do.
+	result, err = a.Sync("dev", path, SyncOptions{})
+	if err != nil || len(result.ToVault.NewKeys)+len(result.ToFile.NewKeys)+len(result.Conflicts) != 0 {
+		t.Errorf("second Sync = %+v, %v", result, err)
+	}
+}
+
+func TestSync_PreferVaultDryRun(t *testing.T) {
+	a, path := setupSync(t)
+	before, _ := os.ReadFile(path)
+
+	result, err := a.Sync("dev", path, SyncOptions{Prefer: SyncPreferVault, DryRun: true})
+	if err != nil {
+		t.Fatalf("Sync error: %v", err)
+	}
+	if !slices.Equal(result.ToFile.UpdatedKeys, []string{"CONFLICT"}) || !slices.Equal(result.ToVault.SkippedKeys, []string{"CONFLICT"}) {
+		t.Errorf("result = %+v %+v", result.ToFile, result.ToVault)
+	}
+
+	after, _ := os.ReadFile(path)
+	if string(after) != string(before) {
+		t.Error("dry run modified the file")
+	}
+	if _, err := a.Get("dev", "FILE_ONLY"); err == nil {
+		t.Error("dry run modified the vault")
+	}
+
+	if _, err := a.Sync("dev", path, SyncOptions{Prefer: SyncPreferVault}); err != nil {
+		t.Fatalf("Sync error: %v", err)
+	}
+	vars, _ := envfile.ParseEnvFile(path)
+	if vars["CONFLICT"] != "from-vault" || vars["VAULT_ONLY"] != "v" || vars["FILE_ONLY"] != "f" {
+		t.Errorf("file = %v", vars)
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func TestDelete_MovesToTrashAndRestore(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("prod", "KEY", "v1")
+	a.Set("prod", "KEY", "v2")
+
+	if err := a.Delete("prod", "KEY"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, err := a.Get("prod", "KEY"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := a.Delete("prod", "KEY"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("second Delete error = %v, want ErrNotFound", err)
+	}
+
+	trashed, err := a.ListTrash()
+	if err != nil || len(trashed) != 1 {
+		t.Fatalf("ListTrash = %+v, %v; want one entry", trashed, err)
+	}
+
+	if err := a.Restore("prod", "OTHER"); !errors.Is(err, store.ErrTrashNotFound) {
+		t.Errorf("Restore of unknown secret error = %v, want ErrTrashNotFound", err)
+	}
+	if err := a.Restore("prod", "KEY"); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if value, err := a.Get("prod", "KEY"); err != nil || value != "v2" {
+		t.Errorf("Get after Restore = %q, %v; want v2", value, err)
+	}
+	if versions, _ := a.History("prod", "KEY"); len(versions) != 1 {
+		t.Errorf("History after Restore has %d versions, want 1", len(versions))
+	}
+}
+
+func TestTrash_SurvivesReencryption(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("prod", "KEY", "v1")
+	a.Set("prod", "KEY", "v2")
+	a.Set("prod", "OTHER", "other")
+	a.Delete("prod", "KEY")
+
+	stats, err := a.RotateVaultKey("prod")
+	if err != nil {
+		t.Fatalf("RotateVaultKey error: %v", err)
+	}
+	if stats.Secrets != 2 || stats.Versions != 1 {
+		t.Errorf("stats = %+v, want 2 secrets and 1 version including the trash", stats)
+	}
+
+	if _, err := a.EncryptNames(); err != nil {
+		t.Fatalf("EncryptNames error: %v", err)
+	}
+	if trashed, _ := a.ListTrash(); len(trashed) != 1 || trashed[0].Name != "KEY" {
+		t.Fatalf("ListTrash with encrypted names = %+v", trashed)
+	}
+
+	if err := a.Restore("prod", "KEY"); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if value, err := a.Get("prod", "KEY"); err != nil || value != "v2" {
+		t.Errorf("Get after Restore = %q, %v; want v2", value, err)
+	}
+	if value, err := a.GetVersion("prod", "KEY", 1); err != nil || value != "v1" {
+		t.Errorf("GetVersion after Restore = %q, %v; want v1", value, err)
+	}
+}
+
+func TestTrash_SurvivesRekey(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	// The vault's only secret is in the trash.
+	a.Set("staging", "KEY", "v1")
+	a.Delete("staging", "KEY")
+
+	next := newTestEngine(t)
+	stats, err := a.Rekey(next, nil)
+	if err != nil {
+		t.Fatalf("Rekey error: %v", err)
+	}
+	if stats.VaultKeys != 1 || stats.Secrets != 1 {
+		t.Errorf("stats = %+v, want the trashed vault's key and value", stats)
+	}
+
+	rekeyed := New(memStore, next)
+	if err := rekeyed.Restore("staging", "KEY"); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if value, err := rekeyed.Get("staging", "KEY"); err != nil || value != "v1" {
+		t.Errorf("Get after Restore = %q, %v; want v1", value, err)
+	}
+}
+
+func TestPurgeTrash_UsesRetention(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+
+	if retention, err := a.TrashRetention(); err != nil || retention != DefaultTrashRetention {
+		t.Errorf("TrashRetention = %v, %v; want default", retention, err)
+	}
+	if err := a.SetTrashRetention(0); err == nil {
+		t.Error("SetTrashRetention(0) should fail")
+	}
+	if err := a.SetTrashRetention(time.Hour); err != nil {
+		t.Fatalf("SetTrashRetention error: %v", err)
+	}
+
+	a.Set("prod", "KEY", "v1")
+	a.Delete("prod", "KEY")
+
+	if n, err := a.PurgeTrash(false); err != nil || n != 0 {
+		t.Errorf("PurgeTrash(false) = %d, %v; want nothing past retention", n, err)
+	}
+	if n, err := a.PurgeTrash(true); err != nil || n != 1 {
+		t.Errorf("PurgeTrash(true) = %d, %v; want 1", n, err)
+	}
+}
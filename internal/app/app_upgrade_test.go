@@ -0,0 +1,119 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/crypto"
+)
+
+// sealHex produces hex of nonce||ciphertext under the setupTestApp key,
+// the body of both pre-envelope formats.
+func sealHex(t *testing.T, value string, aad []byte) string {
+	t.Helper()
+	key, _ := hex.DecodeString("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatalf("NewCipher error: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("NewGCM error: %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	rand.Read(nonce)
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), aad))
+}
+
+// legacyEncrypt produces a value in the pre-v1 format: hex of
+// nonce||ciphertext without associated data.
+func legacyEncrypt(t *testing.T, value string) string {
+	return sealHex(t, value, nil)
+}
+
+func TestGet_RejectsSwappedCiphertext(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+
+	a.Set("production", "DB_PASSWORD", "prod-secret")
+	a.Set("production", "API_KEY", "api-key")
+	a.Set("dev", "DB_PASSWORD", "dev-secret")
+
+	// Simulate someone with write access to the database copying the
+	// production ciphertext over another secret.
+	prod, _ := memStore.Get("production", "DB_PASSWORD")
+	memStore.RewriteValue("production", "API_KEY", 0, prod)
+	memStore.RewriteValue("dev", "DB_PASSWORD", 0, prod)
+
+	if _, err := a.Get("production", "API_KEY"); !errors.Is(err, crypto.ErrDecryptionFailed) {
+		t.Errorf("Get of value swapped within a vault error = %v, want ErrDecryptionFailed", err)
+	}
+	// Across vaults the value names a key the other vault doesn't have.
+	if _, err := a.Get("dev", "DB_PASSWORD"); !errors.Is(err, crypto.ErrUnknownKeyID) {
+		t.Errorf("Get of value swapped between vaults error = %v, want ErrUnknownKeyID", err)
+	}
+}
+
+func TestUpgrade_BindsLegacyValues(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+
+	memStore.Save("prod", "OLD", legacyEncrypt(t, "first"))
+	memStore.Save("prod", "OLD", legacyEncrypt(t, "second"))
+	a.Set("prod", "NEW", "already bound")
+
+	if got, err := a.Get("prod", "OLD"); err != nil || got != "second" {
+		t.Fatalf("Get of legacy value before upgrade = %q, %v; want %q", got, err, "second")
+	}
+
+	stats, err := a.Upgrade()
+	if err != nil {
+		t.Fatalf("Upgrade error: %v", err)
+	}
+	if stats.Secrets != 1 || stats.Versions != 1 {
+		t.Errorf("stats = %+v, want 1 secret and 1 version", stats)
+	}
+
+	raw, _ := memStore.Get("prod", "OLD")
+	if crypto.IsLegacy(raw) {
+		t.Error("value still in legacy format after upgrade")
+	}
+	if got, err := a.GetVersion("prod", "OLD", 1); err != nil || got != "first" {
+		t.Errorf("GetVersion after upgrade = %q, %v; want %q", got, err, "first")
+	}
+
+	// Legacy values planted after the upgrade are refused.
+	memStore.RewriteValue("prod", "NEW", 0, legacyEncrypt(t, "planted"))
+	if _, err := a.Get("prod", "NEW"); !errors.Is(err, ErrLegacyCiphertext) {
+		t.Errorf("Get of planted legacy value error = %v, want ErrLegacyCiphertext", err)
+	}
+}
+
+func TestUpgrade_MovesV1ValuesIntoEnvelopes(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+
+	memStore.Save("prod", "V1", "v1:"+sealHex(t, "bound", secretAAD("prod", "V1")))
+	a.Set("prod", "NEW", "already enveloped")
+	current, _ := memStore.Get("prod", "NEW")
+
+	stats, err := a.Upgrade()
+	if err != nil {
+		t.Fatalf("Upgrade error: %v", err)
+	}
+	if stats.Secrets != 1 {
+		t.Errorf("stats = %+v, want 1 secret", stats)
+	}
+
+	raw, _ := memStore.Get("prod", "V1")
+	if crypto.FormatOf(raw) != crypto.FormatEnvelope {
+		t.Errorf("value not in envelope format after upgrade: %q", raw)
+	}
+	if got, err := a.Get("prod", "V1"); err != nil || got != "bound" {
+		t.Errorf("Get after upgrade = %q, %v; want %q", got, err, "bound")
+	}
+	if after, _ := memStore.Get("prod", "NEW"); after != current {
+		t.Error("Upgrade rewrote a value already in the envelope format")
+	}
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func TestMove_ReencryptsValueAndHistory(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	a.Set("staging", "KEY", "v1")
+	a.Set("staging", "KEY", "v2")
+	owner := "ops"
+	a.UpdateMetadata("staging", "KEY", MetadataUpdate{Owner: &owner})
+
+	if err := a.Move("staging", "KEY", "prod", "API_KEY", false); err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	if memStore.HasKey("staging", "KEY") {
+		t.Error("source still exists after Move")
+	}
+	if value, err := a.Get("prod", "API_KEY"); err != nil || value != "v2" {
+		t.Errorf("Get after Move = %q, %v; want v2", value, err)
+	}
+	if value, err := a.GetVersion("prod", "API_KEY", 1); err != nil || value != "v1" {
+		t.Errorf("GetVersion after Move = %q, %v; want v1", value, err)
+	}
+	if m, _ := a.Describe("prod", "API_KEY"); m.Owner != "ops" {
+		t.Errorf("metadata after Move = %+v, want owner kept", m)
+	}
+
+	a.Set("staging", "KEY", "new")
+	if err := a.Move("staging", "KEY", "prod", "API_KEY", false); !errors.Is(err, store.ErrExists) {
+		t.Errorf("Move onto existing secret error = %v, want ErrExists", err)
+	}
+	if err := a.Move("staging", "KEY", "prod", "API_KEY", true); err != nil {
+		t.Fatalf("Move --force error: %v", err)
+	}
+	if trashed, _ := a.ListTrash(); len(trashed) != 1 || trashed[0].Vault != "prod" {
+		t.Errorf("trash after Move --force = %+v, want the replaced secret", trashed)
+	}
+	if err := a.Move("prod", "API_KEY", "prod", "API_KEY", true); !errors.Is(err, ErrSameTarget) {
+		t.Errorf("Move onto itself error = %v, want ErrSameTarget", err)
+	}
+}
+
+func TestCopy_KeepsSourceAndMetadata(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("prod", "DSN", "old")
+	a.Set("prod", "DSN", "current")
+	a.UpdateMetadata("prod", "DSN", MetadataUpdate{AddTags: []string{"shared"}})
+
+	if err := a.Copy("prod", "DSN", "staging", "DSN", false); err != nil {
+		t.Fatalf("Copy error: %v", err)
+	}
+	for _, vault := range []string{"prod", "staging"} {
+		if value, err := a.Get(vault, "DSN"); err != nil || value != "current" {
+			t.Errorf("Get(%s) after Copy = %q, %v; want current", vault, value, err)
+		}
+	}
+	if versions, _ := a.History("staging", "DSN"); len(versions) != 0 {
+		t.Errorf("copy has %d versions, want a fresh history", len(versions))
+	}
+	if m, _ := a.Describe("staging", "DSN"); !slices.Equal(m.Tags, []string{"shared"}) {
+		t.Errorf("copy metadata = %+v, want tags kept", m)
+	}
+
+	if err := a.Copy("prod", "DSN", "staging", "DSN", false); !errors.Is(err, store.ErrExists) {
+		t.Errorf("Copy onto existing secret error = %v, want ErrExists", err)
+	}
+}
+
+func TestRenameVault(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	a.Set("prod", "A", "a")
+	a.Set("prod", "B", "b")
+	a.Set("dev", "A", "dev")
+
+	if _, err := a.RenameVault("prod", "dev"); !errors.Is(err, ErrVaultExists) {
+		t.Errorf("RenameVault onto existing vault error = %v, want ErrVaultExists", err)
+	}
+	if _, err := a.RenameVault("missing", "other"); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("RenameVault of missing vault error = %v, want ErrVaultNotFound", err)
+	}
+
+	n, err := a.RenameVault("prod", "production")
+	if err != nil || n != 2 {
+		t.Fatalf("RenameVault = %d, %v; want 2", n, err)
+	}
+	if value, err := a.Get("production", "B"); err != nil || value != "b" {
+		t.Errorf("Get after RenameVault = %q, %v; want b", value, err)
+	}
+	if _, err := memStore.GetVaultKey("prod"); !errors.Is(err, store.ErrVaultKeyNotFound) {
+		t.Errorf("old vault key error = %v, want it deleted", err)
+	}
+}
+
+func TestCopyVault_FiltersAndConflicts(t *testing.T) {
+	a, memStore, _ := setupTestApp(t)
+	a.Set("staging", "DB_URL", "db")
+	a.Set("staging", "DB_PASSWORD", "pw")
+	a.Set("staging", "STRIPE_KEY", "sk")
+	a.Set("qa", "DB_URL", "qa-db")
+
+	if _, err := a.CopyVault("staging", "qa", nil, []string{"STRIPE_*"}, false); !errors.Is(err, store.ErrExists) {
+		t.Fatalf("CopyVault with conflict error = %v, want ErrExists", err)
+	}
+	if memStore.HasKey("qa", "DB_PASSWORD") {
+		t.Error("CopyVault copied secrets before failing")
+	}
+
+	n, err := a.CopyVault("staging", "qa", nil, []string{"STRIPE_*"}, true)
+	if err != nil || n != 2 {
+		t.Fatalf("CopyVault --force = %d, %v; want 2", n, err)
+	}
+	if value, _ := a.Get("qa", "DB_URL"); value != "db" {
+		t.Errorf("qa/DB_URL = %q, want overwritten", value)
+	}
+	if memStore.HasKey("qa", "STRIPE_KEY") {
+		t.Error("excluded secret was copied")
+	}
+}
+
+func TestDeleteVault_TrashesSecrets(t *testing.T) {
+	a, _, _ := setupTestApp(t)
+	a.Set("qa", "A", "a")
+	a.Set("qa", "B", "b")
+
+	n, err := a.DeleteVault("qa")
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteVault = %d, %v; want 2", n, err)
+	}
+	if exists, _ := a.vaultExists("qa"); exists {
+		t.Error("vault still exists after DeleteVault")
+	}
+
+	if err := a.Restore("qa", "A"); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if value, err := a.Get("qa", "A"); err != nil || value != "a" {
+		t.Errorf("Get after Restore = %q, %v; want a", value, err)
+	}
+}
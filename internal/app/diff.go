@@ -0,0 +1,100 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ossydotpy/veil/internal/envfile"
+	"github.com/ossydotpy/veil/internal/filter"
+)
+
+// DiffKind says how a key differs between the two sides of a diff.
+type DiffKind int
+
+const (
+	// DiffOnlyLeft marks a key present only on the left side.
+	DiffOnlyLeft DiffKind = iota
+	// DiffOnlyRight marks a key present only on the right side.
+	DiffOnlyRight
+	// DiffChanged marks a key present on both sides with different values.
+	DiffChanged
+)
+
+// DiffEntry is one key that differs between two sets of secrets. Left or
+// Right is empty when the key is missing on that side.
+type DiffEntry struct {
+	Name  string
+	Kind  DiffKind
+	Left  string
+	Right string
+}
+
+// DiffVaults compares the secrets visible from two vaults, including
+// inherited ones, with references expanded. Keys are selected by include
+// and exclude as in 'veil export'.
+func (a *App) DiffVaults(left, right string, include, exclude []string) ([]DiffEntry, error) {
+	if left == right {
+		return nil, fmt.Errorf("%w: %s", ErrSameTarget, left)
+	}
+
+	leftSecrets, err := a.diffSecrets(left)
+	if err != nil {
+		return nil, err
+	}
+	rightSecrets, err := a.diffSecrets(right)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(filter.FilterSecrets(leftSecrets, include, exclude), filter.FilterSecrets(rightSecrets, include, exclude)), nil
+}
+
+// DiffFile compares the secrets visible from vault with the variables in
+// the env file at path, which is the right side.
+func (a *App) DiffFile(vault, path string, include, exclude []string) ([]DiffEntry, error) {
+	secrets, err := a.diffSecrets(vault)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := envfile.ParseEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(filter.FilterSecrets(secrets, include, exclude), filter.FilterSecrets(vars, include, exclude)), nil
+}
+
+func (a *App) diffSecrets(vault string) (map[string]string, error) {
+	exists, err := a.vaultExists(vault)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrVaultNotFound, vault)
+	}
+	return a.GetAllSecrets(vault)
+}
+
+// Diff returns the keys that differ between left and right, sorted by
+// name. Keys with equal values on both sides are omitted.
+func Diff(left, right map[string]string) []DiffEntry {
+	var entries []DiffEntry
+	for name, l := range left {
+		r, ok := right[name]
+		switch {
+		case !ok:
+			entries = append(entries, DiffEntry{Name: name, Kind: DiffOnlyLeft, Left: l})
+		case l != r:
+			entries = append(entries, DiffEntry{Name: name, Kind: DiffChanged, Left: l, Right: r})
+		}
+	}
+	for name, r := range right {
+		if _, ok := left[name]; !ok {
+			entries = append(entries, DiffEntry{Name: name, Kind: DiffOnlyRight, Right: r})
+		}
+	}
+
+	slices.SortFunc(entries, func(x, y DiffEntry) int {
+		return strings.Compare(x.Name, y.Name)
+	})
+	return entries
+}
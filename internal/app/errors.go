@@ -7,4 +7,26 @@ var (
 	ErrKeyExistsInEnv  = errors.New("key already exists in env file")
 	ErrEnvFileNotExist = errors.New("env file does not exist")
 	ErrVaultNotFound   = errors.New("vault not found")
+
+	ErrLegacyCiphertext = errors.New("legacy ciphertext rejected (not bound to its vault and name)")
+
+	ErrNamesEncrypted    = errors.New("names are already encrypted")
+	ErrNamesNotEncrypted = errors.New("names are not encrypted")
+
+	ErrInvalidTag = errors.New("invalid tag")
+
+	ErrSecretExpired = errors.New("secret has expired")
+
+	ErrNoRecipe = errors.New("secret was not generated by veil")
+
+	ErrVaultExists = errors.New("vault already exists")
+	ErrSameTarget  = errors.New("source and destination are the same")
+
+	ErrInheritanceCycle = errors.New("vault inheritance would form a cycle")
+	ErrVaultInherited   = errors.New("vault is inherited by other vaults")
+
+	ErrReferenceCycle      = errors.New("secret references form a cycle")
+	ErrUnresolvedReference = errors.New("referenced secret not found")
+
+	ErrSyncConflict = errors.New("vault and file disagree")
 )
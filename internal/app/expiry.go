@@ -0,0 +1,113 @@
+package app
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// DueReason says why a secret needs attention.
+type DueReason int
+
+const (
+	// DueExpired means the secret's value has an expiry date.
+	DueExpired DueReason = iota
+	// DueRotation means the secret has a rotation interval.
+	DueRotation
+)
+
+// DueSecret is a secret that has expired or is due for rotation, or will be
+// soon. Date is the expiry or rotation date; it is zero when a rotation is
+// due but the secret's last update was not recorded.
+type DueSecret struct {
+	store.SecretRef
+	Reason      DueReason
+	Date        time.Time
+	RotateEvery time.Duration
+	Overdue     bool
+}
+
+// Due returns the secrets in all vaults that have expired or are due for
+// rotation at now, and those that will be within the given window. Overdue
+// secrets come first, each group ordered by date.
+func (a *App) Due(now time.Time, within time.Duration) ([]DueSecret, error) {
+	refs, err := a.allRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var due []DueSecret
+	for _, ref := range refs {
+		m, err := a.store.GetMetadata(ref.Vault, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range dueDates(ref, m) {
+			d.Overdue = !d.Date.After(now)
+			if d.Overdue || d.Date.Before(now.Add(within)) {
+				due = append(due, d)
+			}
+		}
+	}
+
+	slices.SortStableFunc(due, func(x, y DueSecret) int {
+		if x.Overdue != y.Overdue {
+			if x.Overdue {
+				return -1
+			}
+			return 1
+		}
+		return x.Date.Compare(y.Date)
+	})
+	return due, nil
+}
+
+// Expired returns the secrets of vault among names whose value has expired
+// at now, ordered by name. Inherited secrets are reported under the vault
+// that holds them.
+func (a *App) Expired(vault string, names []string, now time.Time) ([]DueSecret, error) {
+	var expired []DueSecret
+	for _, name := range names {
+		source, _, err := a.lookup(vault, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := a.store.GetMetadata(source, name)
+		if err != nil {
+			return nil, err
+		}
+		if !m.ExpiresAt.IsZero() && !m.ExpiresAt.After(now) {
+			expired = append(expired, DueSecret{
+				SecretRef: store.SecretRef{Vault: source, Name: name},
+				Reason:    DueExpired,
+				Date:      m.ExpiresAt,
+				Overdue:   true,
+			})
+		}
+	}
+	slices.SortFunc(expired, func(x, y DueSecret) int {
+		return cmp.Compare(x.Name, y.Name)
+	})
+	return expired, nil
+}
+
+// dueDates returns the expiry and next rotation of a secret, if it has
+// them.
+func dueDates(ref store.SecretRef, m store.Metadata) []DueSecret {
+	var dates []DueSecret
+	if !m.ExpiresAt.IsZero() {
+		dates = append(dates, DueSecret{SecretRef: ref, Reason: DueExpired, Date: m.ExpiresAt})
+	}
+	if m.RotateEvery > 0 {
+		// Without a recorded update the rotation is due immediately; the
+		// zero date sorts first.
+		var next time.Time
+		if !m.UpdatedAt.IsZero() {
+			next = m.UpdatedAt.Add(m.RotateEvery)
+		}
+		dates = append(dates, DueSecret{SecretRef: ref, Reason: DueRotation, Date: next, RotateEvery: m.RotateEvery})
+	}
+	return dates
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// History returns the archived versions of a secret, newest first.
+// Values in the returned versions are still encrypted; use GetVersion to
+// read one.
+func (a *App) History(vault, name string) ([]store.Version, error) {
+	var versions []store.Version
+	for v, err := range a.store.ListVersions(vault, name) {
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+
+	if len(versions) == 0 {
+		if _, err := a.store.Get(vault, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// GetVersion decrypts an archived version of a secret.
+func (a *App) GetVersion(vault, name string, version int) (string, error) {
+	encrypted, err := a.store.GetVersion(vault, name, version)
+	if err != nil {
+		return "", err
+	}
+	return a.decrypt(vault, name, encrypted)
+}
+
+// Rollback restores an archived version as the current value of a secret.
+// The value being replaced is itself archived, so a rollback can be undone.
+// Unlike a new value, a restored one keeps the secret's expiry: rolling
+// back must not silently turn an expiring secret into one that never
+// expires.
+func (a *App) Rollback(vault, name string, version int) error {
+	value, err := a.GetVersion(vault, name, version)
+	if err != nil {
+		if errors.Is(err, store.ErrVersionNotFound) {
+			return fmt.Errorf("%w: %s/%s has no version %d", err, vault, name, version)
+		}
+		return err
+	}
+
+	return a.store.Atomic(func(s store.Store) error {
+		before, err := s.GetMetadata(vault, name)
+		if err != nil {
+			return err
+		}
+		if err := a.withStore(s).Set(vault, name, value); err != nil {
+			return err
+		}
+		if before.ExpiresAt.IsZero() {
+			return nil
+		}
+		m, err := s.GetMetadata(vault, name)
+		if err != nil {
+			return err
+		}
+		m.ExpiresAt = before.ExpiresAt
+		return s.SetMetadata(vault, name, m)
+	})
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// Parents returns the vaults vault inherits from, nearest first.
+func (a *App) Parents(vault string) ([]string, error) {
+	return a.store.GetVaultParents(vault)
+}
+
+// SetParents makes vault inherit the secrets it does not hold itself from
+// parents, in order. Each parent must exist and must not already inherit
+// from vault. Passing no parents removes the inheritance.
+func (a *App) SetParents(vault string, parents []string) error {
+	return a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		for i, parent := range parents {
+			if parent == vault {
+				return fmt.Errorf("%w: %s cannot inherit from itself", ErrInheritanceCycle, vault)
+			}
+			if slices.Contains(parents[:i], parent) {
+				return fmt.Errorf("parent %q is listed twice", parent)
+			}
+			exists, err := tx.vaultExists(parent)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("%w: %q", ErrVaultNotFound, parent)
+			}
+
+			chain, err := tx.Chain(parent)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(chain, vault) {
+				return fmt.Errorf("%w: %s already inherits from %s", ErrInheritanceCycle, parent, vault)
+			}
+		}
+		return s.SetVaultParents(vault, parents)
+	})
+}
+
+// Chain returns the vaults a secret of vault is looked up in, in order:
+// vault itself, then each parent followed by its own chain. A vault reached
+// more than once is only looked up the first time.
+func (a *App) Chain(vault string) ([]string, error) {
+	var chain []string
+	var walk func(vault string) error
+	walk = func(vault string) error {
+		if slices.Contains(chain, vault) {
+			return nil
+		}
+		chain = append(chain, vault)
+
+		parents, err := a.store.GetVaultParents(vault)
+		if err != nil {
+			return err
+		}
+		for _, parent := range parents {
+			if err := walk(parent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(vault); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// ResolvedRefs returns every secret visible from vault, sorted by name,
+// each with the vault its value comes from: vault itself or the nearest
+// vault in its chain that holds the name.
+func (a *App) ResolvedRefs(vault string) ([]store.SecretRef, error) {
+	chain, err := a.Chain(vault)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string)
+	for _, v := range chain {
+		for name, err := range a.store.List(v) {
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := sources[name]; !ok {
+				sources[name] = v
+			}
+		}
+	}
+
+	refs := make([]store.SecretRef, 0, len(sources))
+	for name, source := range sources {
+		refs = append(refs, store.SecretRef{Vault: source, Name: name})
+	}
+	slices.SortFunc(refs, func(x, y store.SecretRef) int {
+		return strings.Compare(x.Name, y.Name)
+	})
+	return refs, nil
+}
+
+// lookup finds name in the chain of vault and returns the vault holding it
+// with its stored value.
+func (a *App) lookup(vault, name string) (string, string, error) {
+	chain, err := a.Chain(vault)
+	if err != nil {
+		return "", "", err
+	}
+	for _, v := range chain {
+		encrypted, err := a.store.Get(v, name)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return v, encrypted, nil
+	}
+	return "", "", store.ErrNotFound
+}
+
+// children returns the vaults that name vault as a parent.
+func (a *App) children(vault string) ([]string, error) {
+	var children []string
+	for vp, err := range a.store.ListVaultParents() {
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(vp.Parents, vault) {
+			children = append(children, vp.Vault)
+		}
+	}
+	return children, nil
+}
+
+// renameInheritance moves the parents of vault to newVault and points the
+// vaults inheriting from vault to newVault. It must run inside an Atomic
+// callback.
+func (a *App) renameInheritance(vault, newVault string) error {
+	var all []store.VaultParents
+	for vp, err := range a.store.ListVaultParents() {
+		if err != nil {
+			return err
+		}
+		all = append(all, vp)
+	}
+
+	for _, vp := range all {
+		parents := slices.Clone(vp.Parents)
+		for i, parent := range parents {
+			if parent == vault {
+				parents[i] = newVault
+			}
+		}
+		target := vp.Vault
+		if target == vault {
+			target = newVault
+			if err := a.store.SetVaultParents(vault, nil); err != nil {
+				return err
+			}
+		}
+		if err := a.store.SetVaultParents(target, parents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
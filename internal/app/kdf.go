@@ -0,0 +1,43 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// kdfMetaKey is the meta entry holding the passphrase KDF parameters.
+const kdfMetaKey = "kdf"
+
+// LoadKDFParams returns the passphrase KDF parameters stored in the
+// database, or nil if the database uses a raw MASTER_KEY.
+func LoadKDFParams(s store.Store) (*crypto.KDFParams, error) {
+	raw, err := s.GetMeta(kdfMetaKey)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var params crypto.KDFParams
+	if err := json.Unmarshal([]byte(raw), &params); err != nil {
+		return nil, fmt.Errorf("invalid KDF parameters in database: %w", err)
+	}
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid KDF parameters in database: %w", err)
+	}
+	return &params, nil
+}
+
+// SaveKDFParams stores passphrase KDF parameters in the database.
+func SaveKDFParams(s store.Store, params *crypto.KDFParams) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return s.SetMeta(kdfMetaKey, string(raw))
+}
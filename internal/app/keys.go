@@ -0,0 +1,96 @@
+package app
+
+import "github.com/ossydotpy/veil/internal/crypto"
+
+// KeyUse counts what a master key protects: wrapped vault keys, the name
+// key, and values still encrypted directly with it.
+type KeyUse struct {
+	VaultKeys int
+	NameKey   bool
+	Values    int
+}
+
+// KeyUsage reports, by the key ID recorded in their envelope, the vault
+// keys and the values (current, archived and trashed) that are encrypted
+// with a master key rather than a vault key. Values in older formats, which don't
+// record a key, are counted under the empty ID.
+func (a *App) KeyUsage() (map[string]KeyUse, error) {
+	usage := make(map[string]KeyUse)
+	masterKeyID := func(encrypted string) string {
+		id, _ := crypto.KeyIDOf(encrypted)
+		if ringID, ok := a.crypto.ResolveKeyID(id); ok {
+			return ringID
+		}
+		return id
+	}
+
+	// The IDs of the vault keys that can be unwrapped tell their values
+	// apart from values under a master key missing from the ring.
+	vaultKeyIDs := make(map[string]string)
+	for vk, err := range a.store.ListVaultKeys() {
+		if err != nil {
+			return nil, err
+		}
+		id := masterKeyID(vk.Key)
+		use := usage[id]
+		use.VaultKeys++
+		usage[id] = use
+
+		if engine, err := a.unwrapVaultKey(vk.Vault, vk.Key); err == nil {
+			vaultKeyIDs[vk.Vault] = engine.KeyID()
+		}
+	}
+
+	if wrapped, err := a.store.GetMeta(nameKeyMetaKey); err == nil {
+		id := masterKeyID(wrapped)
+		use := usage[id]
+		use.NameKey = true
+		usage[id] = use
+	}
+
+	refs, err := a.allRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	count := func(vault, encrypted string) {
+		if id, ok := crypto.KeyIDOf(encrypted); ok && id == vaultKeyIDs[vault] {
+			return
+		}
+		id := masterKeyID(encrypted)
+		use := usage[id]
+		use.Values++
+		usage[id] = use
+	}
+
+	for _, ref := range refs {
+		encrypted, err := a.store.Get(ref.Vault, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		count(ref.Vault, encrypted)
+
+		versions, err := a.History(ref.Vault, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			count(ref.Vault, v.Value)
+		}
+	}
+
+	trashed, err := a.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trashed {
+		for v, err := range a.store.TrashValues(t.ID) {
+			if err != nil {
+				return nil, err
+			}
+			count(t.Vault, v.Value)
+		}
+	}
+
+	return usage, nil
+}
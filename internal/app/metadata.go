@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// SecretInfo is the metadata of one secret, as shown by 'veil list --long'.
+type SecretInfo struct {
+	Name string
+	store.Metadata
+}
+
+// MetadataUpdate describes a change to the metadata of a secret. Nil
+// fields are left unchanged; a zero ExpiresAt or RotateEvery clears it.
+type MetadataUpdate struct {
+	Description *string
+	Owner       *string
+	AddTags     []string
+	RemoveTags  []string
+	ExpiresAt   *time.Time
+	RotateEvery *time.Duration
+	Recipe      *Recipe
+	Template    *bool
+}
+
+// IsZero reports whether upd changes nothing.
+func (upd MetadataUpdate) IsZero() bool {
+	return upd.Description == nil && upd.Owner == nil &&
+		len(upd.AddTags) == 0 && len(upd.RemoveTags) == 0 &&
+		upd.ExpiresAt == nil && upd.RotateEvery == nil && upd.Recipe == nil && upd.Template == nil
+}
+
+// Describe returns the metadata of a secret.
+func (a *App) Describe(vault, name string) (store.Metadata, error) {
+	return a.store.GetMetadata(vault, name)
+}
+
+// UpdateMetadata applies upd to the metadata of a secret and returns the
+// result. Tags are kept sorted and free of duplicates.
+func (a *App) UpdateMetadata(vault, name string, upd MetadataUpdate) (store.Metadata, error) {
+	for _, tag := range upd.AddTags {
+		if err := validateTag(tag); err != nil {
+			return store.Metadata{}, err
+		}
+	}
+
+	var m store.Metadata
+	err := a.store.Atomic(func(s store.Store) error {
+		var err error
+		m, err = s.GetMetadata(vault, name)
+		if err != nil {
+			return err
+		}
+
+		if upd.Description != nil {
+			m.Description = strings.TrimSpace(*upd.Description)
+		}
+		if upd.Owner != nil {
+			m.Owner = strings.TrimSpace(*upd.Owner)
+		}
+		if upd.ExpiresAt != nil {
+			m.ExpiresAt = *upd.ExpiresAt
+		}
+		if upd.RotateEvery != nil {
+			m.RotateEvery = *upd.RotateEvery
+		}
+		if upd.Template != nil {
+			m.Template = *upd.Template
+		}
+		if upd.Recipe != nil {
+			if m.Recipe, err = encodeRecipe(*upd.Recipe); err != nil {
+				return err
+			}
+		}
+		m.Tags = slices.DeleteFunc(append(m.Tags, upd.AddTags...), func(tag string) bool {
+			return slices.Contains(upd.RemoveTags, tag)
+		})
+		slices.Sort(m.Tags)
+		m.Tags = slices.Compact(m.Tags)
+
+		return s.SetMetadata(vault, name, m)
+	})
+	if err != nil {
+		return store.Metadata{}, err
+	}
+	return m, nil
+}
+
+// SetWithMetadata stores a secret and applies upd to its metadata in one
+// transaction.
+func (a *App) SetWithMetadata(vault, name, value string, upd MetadataUpdate) error {
+	return a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		if err := tx.Set(vault, name, value); err != nil {
+			return err
+		}
+		if upd.IsZero() {
+			return nil
+		}
+		_, err := tx.UpdateMetadata(vault, name, upd)
+		return err
+	})
+}
+
+// ListMetadata returns the metadata of every secret in a vault, sorted by
+// name. If tag is not empty, only secrets carrying it are returned.
+func (a *App) ListMetadata(vault, tag string) ([]SecretInfo, error) {
+	var names []string
+	for name, err := range a.store.List(vault) {
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var infos []SecretInfo
+	for _, name := range names {
+		m, err := a.store.GetMetadata(vault, name)
+		if err != nil {
+			return nil, err
+		}
+		if tag != "" && !slices.Contains(m.Tags, tag) {
+			continue
+		}
+		infos = append(infos, SecretInfo{Name: name, Metadata: m})
+	}
+	return infos, nil
+}
+
+// validateTag rejects tags that cannot be stored or displayed unambiguously.
+func validateTag(tag string) error {
+	if tag == "" {
+		return fmt.Errorf("%w: tag is empty", ErrInvalidTag)
+	}
+	if strings.ContainsAny(tag, ", \t\r\n") {
+		return fmt.Errorf("%w: %q contains a comma or whitespace", ErrInvalidTag, tag)
+	}
+	return nil
+}
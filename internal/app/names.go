@@ -0,0 +1,260 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/store"
+	"github.com/ossydotpy/veil/internal/store/blind"
+)
+
+// nameKeyMetaKey holds the key that encrypts vault and secret names,
+// wrapped by the master key. Its presence turns name encryption on.
+const nameKeyMetaKey = "name_key"
+
+var nameKeyAAD = []byte("veil:name-key")
+
+// UnlockNames returns s wrapped so that vault and secret names are
+// encrypted, if name encryption is enabled for the database, and s itself
+// otherwise.
+func UnlockNames(s store.Store, engine *crypto.Engine) (store.Store, error) {
+	wrapped, err := s.GetMeta(nameKeyMetaKey)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyHex, err := engine.DecryptWithAAD(wrapped, nameKeyAAD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock names: %w", err)
+	}
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unlock names: %w", err)
+	}
+	return blind.New(s, key)
+}
+
+// NamesEncrypted reports whether vault and secret names are stored
+// encrypted.
+func (a *App) NamesEncrypted() bool {
+	_, ok := a.store.(*blind.Store)
+	return ok
+}
+
+// NameStats reports how many secrets and vaults had their names converted.
+type NameStats struct {
+	Secrets int
+	Vaults  int
+}
+
+// EncryptNames turns on name encryption: a new name key is generated and
+// every secret, archived version and vault key is moved to its encrypted
+// name in a single transaction.
+func (a *App) EncryptNames() (NameStats, error) {
+	if a.NamesEncrypted() {
+		return NameStats{}, ErrNamesEncrypted
+	}
+
+	key := make([]byte, blind.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return NameStats{}, fmt.Errorf("failed to generate name key: %w", err)
+	}
+	wrapped, err := a.crypto.EncryptWithAAD(hex.EncodeToString(key), nameKeyAAD)
+	if err != nil {
+		return NameStats{}, err
+	}
+	encrypted, err := blind.New(a.store, key)
+	if err != nil {
+		return NameStats{}, err
+	}
+
+	var stats NameStats
+	err = a.store.Atomic(func(s store.Store) error {
+		var err error
+		stats, err = a.renameAll(s, encrypted.WithInner(s), true)
+		if err != nil {
+			return err
+		}
+		return s.SetMeta(nameKeyMetaKey, wrapped)
+	})
+	if err != nil {
+		return NameStats{}, err
+	}
+
+	compact(a.store)
+	a.store = encrypted
+	return stats, nil
+}
+
+// DecryptNames turns off name encryption, moving everything back to its
+// plaintext name and discarding the name key.
+func (a *App) DecryptNames() (NameStats, error) {
+	encrypted, ok := a.store.(*blind.Store)
+	if !ok {
+		return NameStats{}, ErrNamesNotEncrypted
+	}
+	plain := encrypted.Inner()
+
+	var stats NameStats
+	err := plain.Atomic(func(s store.Store) error {
+		var err error
+		stats, err = a.renameAll(s, encrypted.WithInner(s), false)
+		if err != nil {
+			return err
+		}
+		return s.DeleteMeta(nameKeyMetaKey)
+	})
+	if err != nil {
+		return NameStats{}, err
+	}
+
+	compact(plain)
+	a.store = plain
+	return stats, nil
+}
+
+// renameAll moves everything stored under a name in raw between its
+// plaintext name and its encrypted name in tokens, which must wrap raw:
+// every secret with its archived versions, every trashed secret, every
+// vault key and the vault inheritance. Metadata is sealed or opened on the
+// way, as tokens keeps it encrypted.
+func (a *App) renameAll(raw store.Store, tokens *blind.Store, encrypt bool) (NameStats, error) {
+	var stats NameStats
+
+	source, target := store.Store(tokens), raw
+	if encrypt {
+		source, target = raw, tokens
+	}
+
+	refs, err := a.withStore(source).allRefs()
+	if err != nil {
+		return stats, err
+	}
+	trashed, err := a.withStore(source).ListTrash()
+	if err != nil {
+		return stats, err
+	}
+	var inheritance []store.VaultParents
+	for vp, err := range source.ListVaultParents() {
+		if err != nil {
+			return stats, err
+		}
+		inheritance = append(inheritance, vp)
+	}
+	var keys []store.VaultKey
+	for vk, err := range source.ListVaultKeys() {
+		if err != nil {
+			return stats, err
+		}
+		keys = append(keys, vk)
+	}
+
+	vaults := make(map[string]bool)
+	for _, ref := range refs {
+		m, err := source.GetMetadata(ref.Vault, ref.Name)
+		if err != nil {
+			return stats, fmt.Errorf("%s/%s: %w", ref.Vault, ref.Name, err)
+		}
+		vaultToken, nameToken := tokens.VaultToken(ref.Vault), tokens.NameToken(ref.Vault, ref.Name)
+		if encrypt {
+			err = raw.Rename(ref.Vault, ref.Name, vaultToken, nameToken)
+		} else {
+			err = raw.Rename(vaultToken, nameToken, ref.Vault, ref.Name)
+		}
+		if err == nil {
+			err = target.SetMetadata(ref.Vault, ref.Name, m)
+		}
+		if err != nil {
+			return stats, fmt.Errorf("%s/%s: %w", ref.Vault, ref.Name, err)
+		}
+		stats.Secrets++
+		vaults[ref.Vault] = true
+	}
+	stats.Vaults = len(vaults)
+
+	for _, vp := range inheritance {
+		from, to := vp.Vault, tokens.VaultToken(vp.Vault)
+		parents := make([]string, len(vp.Parents))
+		for i, parent := range vp.Parents {
+			parents[i] = tokens.VaultToken(parent)
+		}
+		if !encrypt {
+			from, to, parents = to, from, vp.Parents
+		}
+		if err := raw.SetVaultParents(from, nil); err != nil {
+			return stats, err
+		}
+		if err := raw.SetVaultParents(to, parents); err != nil {
+			return stats, err
+		}
+	}
+
+	for _, t := range trashed {
+		vault, name := tokens.VaultToken(t.Vault), tokens.NameToken(t.Vault, t.Name)
+		if !encrypt {
+			vault, name = t.Vault, t.Name
+		}
+		m, err := source.GetTrashMetadata(t.ID)
+		if err == nil {
+			err = raw.RenameTrash(t.ID, vault, name)
+		}
+		if err == nil {
+			err = target.SetTrashMetadata(t.ID, m)
+		}
+		if err != nil {
+			return stats, fmt.Errorf("trashed %s/%s: %w", t.Vault, t.Name, err)
+		}
+	}
+
+	for _, vk := range keys {
+		from, to := vk.Vault, tokens.VaultToken(vk.Vault)
+		if !encrypt {
+			from, to = to, from
+		}
+		if err := raw.DeleteVaultKey(from); err != nil {
+			return stats, err
+		}
+		if err := raw.SetVaultKey(to, vk.Key); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+// rewrapNameKey re-wraps the name key with next if name encryption is on
+// and needed selects the current wrapping. It reports whether it did.
+func (a *App) rewrapNameKey(next *crypto.Engine, needed func(wrapped string) bool) (bool, error) {
+	wrapped, err := a.store.GetMeta(nameKeyMetaKey)
+	if errors.Is(err, store.ErrMetaNotFound) || (err == nil && !needed(wrapped)) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	key, err := a.crypto.DecryptWithAAD(wrapped, nameKeyAAD)
+	if err != nil {
+		return false, fmt.Errorf("name key: %w", err)
+	}
+	rewrapped, err := next.EncryptWithAAD(key, nameKeyAAD)
+	if err != nil {
+		return false, err
+	}
+	return true, a.store.SetMeta(nameKeyMetaKey, rewrapped)
+}
+
+// compact reclaims the space of renamed rows so that old names do not
+// linger in the database file. Failure is not fatal: the names are already
+// converted.
+func compact(s store.Store) {
+	if c, ok := s.(store.Compacter); ok {
+		_ = c.Compact()
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// referencePattern matches the inside of a reference: a secret name,
+// optionally prefixed by the vault holding it. Anything else between ${
+// and } is left alone, so shell expressions such as ${HOME:-/root} stored
+// in a value are not mistaken for references.
+var referencePattern = regexp.MustCompile(`^(?:[A-Za-z0-9_.-]+/)?[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// scanReferences splits value into literal text and references, calling
+// fn for each piece with either literal or ref set. $${ is unescaped to a
+// literal ${.
+func scanReferences(value string, fn func(literal, ref string)) {
+	for {
+		i := strings.Index(value, "${")
+		if i < 0 {
+			fn(value, "")
+			return
+		}
+		if i > 0 && value[i-1] == '$' {
+			fn(value[:i-1]+"${", "")
+			value = value[i+2:]
+			continue
+		}
+
+		end := strings.IndexByte(value[i+2:], '}')
+		if end < 0 {
+			fn(value, "")
+			return
+		}
+		ref := value[i+2 : i+2+end]
+		if !referencePattern.MatchString(ref) {
+			fn(value[:i+2], "")
+			value = value[i+2:]
+			continue
+		}
+
+		fn(value[:i], "")
+		fn("", ref)
+		value = value[i+3+end:]
+	}
+}
+
+// expandTemplate expands value, the stored value of name in source read
+// through vault, if the secret is marked as a template. Other values,
+// including every generated one, are returned as they are.
+func (a *App) expandTemplate(vault, source, name, value string, stack []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	m, err := a.store.GetMetadata(source, name)
+	if err != nil {
+		return "", err
+	}
+	if !m.Template || m.Recipe != "" {
+		return value, nil
+	}
+	return a.expand(vault, value, stack)
+}
+
+// expand replaces the references in value, read from vault, with the
+// values they name. stack holds the secrets being expanded, outermost
+// first, and is used to report cycles.
+func (a *App) expand(vault, value string, stack []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var b strings.Builder
+	var err error
+	scanReferences(value, func(literal, ref string) {
+		if err != nil {
+			return
+		}
+		if ref == "" {
+			b.WriteString(literal)
+			return
+		}
+		var resolved string
+		resolved, err = a.resolveReference(vault, ref, stack)
+		b.WriteString(resolved)
+	})
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// resolveReference returns the expanded value of ref as seen from vault.
+// A bare name is looked up in vault and the vaults it inherits from, so a
+// template in a parent vault picks up the values of the vault it is read
+// through; vault/NAME names the vault explicitly.
+func (a *App) resolveReference(vault, ref string, stack []string) (string, error) {
+	target, name := vault, ref
+	if v, n, ok := strings.Cut(ref, "/"); ok {
+		target, name = v, n
+	}
+
+	key := target + "/" + name
+	if slices.Contains(stack, key) {
+		return "", fmt.Errorf("%w: %s", ErrReferenceCycle, strings.Join(append(stack, key), " -> "))
+	}
+
+	source, encrypted, err := a.lookup(target, name)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return "", fmt.Errorf("%w: ${%s} in %s", ErrUnresolvedReference, ref, stack[len(stack)-1])
+		}
+		return "", err
+	}
+	value, err := a.decrypt(source, name, encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	// Clip the stack so sibling references do not share a backing array.
+	return a.expandTemplate(target, source, name, value, append(stack[:len(stack):len(stack)], key))
+}
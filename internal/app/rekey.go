@@ -0,0 +1,300 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// legacyRejectedMetaKey is set by Upgrade once every value is bound to its
+// vault and name. From then on legacy values are refused, so an attacker
+// cannot plant an unbound ciphertext copied from another secret.
+const legacyRejectedMetaKey = "legacy_ciphertext_rejected"
+
+// ReencryptStats reports how many vault keys were re-wrapped and how many
+// values were re-encrypted.
+type ReencryptStats struct {
+	VaultKeys int
+	Secrets   int
+	Versions  int
+}
+
+// add accumulates the counts of other into s.
+func (s *ReencryptStats) add(other ReencryptStats) {
+	s.VaultKeys += other.VaultKeys
+	s.Secrets += other.Secrets
+	s.Versions += other.Versions
+}
+
+// Rekey switches the database to the master key next. params holds the KDF
+// parameters for a passphrase-derived key, or nil when next uses a raw
+// MASTER_KEY. Every vault gets a new data key wrapped with next and its
+// values, including archived versions and the trash, are re-encrypted with
+// it, so neither the old master key nor a data key unwrapped with it opens
+// anything afterwards. Everything is written in a single transaction, so a
+// failure part-way leaves the database encrypted with the old key.
+func (a *App) Rekey(next *crypto.Engine, params *crypto.KDFParams) (ReencryptStats, error) {
+	var stats ReencryptStats
+
+	err := a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+
+		if _, err := tx.rewrapNameKey(next, func(string) bool { return true }); err != nil {
+			return err
+		}
+		refs, err := tx.allRefs()
+		if err != nil {
+			return err
+		}
+		vaults, err := tx.keyedVaults(refs)
+		if err != nil {
+			return err
+		}
+		for _, vault := range vaults {
+			rotated, err := tx.rotateVaultKey(vault, refs, next)
+			if err != nil {
+				return err
+			}
+			stats.add(rotated)
+		}
+
+		if params == nil {
+			return s.DeleteMeta(kdfMetaKey)
+		}
+		return SaveKDFParams(s, params)
+	})
+	if err != nil {
+		return ReencryptStats{}, err
+	}
+
+	a.crypto = next
+	return stats, nil
+}
+
+// Upgrade rewrites every value not yet in the current format: values in an
+// older format or encrypted directly with the master key are moved under
+// their vault key, binding legacy values to their vault and name, and vault
+// keys wrapped by a non-primary key are re-wrapped with the primary key.
+// Afterwards legacy values are no longer accepted.
+func (a *App) Upgrade() (ReencryptStats, error) {
+	var stats ReencryptStats
+
+	err := a.store.Atomic(func(s store.Store) error {
+		var err error
+		stats, err = a.withStore(s).rewrapAndReencrypt(a.crypto, a.crypto.NeedsUpgrade)
+		if err != nil {
+			return err
+		}
+		return s.SetMeta(legacyRejectedMetaKey, "true")
+	})
+	if err != nil {
+		return ReencryptStats{}, err
+	}
+	return stats, nil
+}
+
+// RotateVaultKey replaces the data key of one vault and re-encrypts the
+// vault's secrets and archived versions with it, including those in the
+// trash, leaving other vaults untouched.
+func (a *App) RotateVaultKey(vault string) (ReencryptStats, error) {
+	var stats ReencryptStats
+
+	err := a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+
+		var refs []store.SecretRef
+		for name, err := range s.List(vault) {
+			if err != nil {
+				return err
+			}
+			refs = append(refs, store.SecretRef{Vault: vault, Name: name})
+		}
+		if len(refs) == 0 {
+			if _, err := s.GetVaultKey(vault); err != nil {
+				if errors.Is(err, store.ErrVaultKeyNotFound) {
+					return fmt.Errorf("%w: %s", ErrVaultNotFound, vault)
+				}
+				return err
+			}
+		}
+
+		var err error
+		stats, err = tx.rotateVaultKey(vault, refs, a.crypto)
+		return err
+	})
+	if err != nil {
+		return ReencryptStats{}, err
+	}
+	return stats, nil
+}
+
+// rotateVaultKey gives vault a new data key wrapped with master and
+// re-encrypts the values of the refs in vault and of its trash with it.
+// It must run inside an Atomic callback.
+func (a *App) rotateVaultKey(vault string, refs []store.SecretRef, master *crypto.Engine) (ReencryptStats, error) {
+	refs = slices.DeleteFunc(slices.Clone(refs), func(ref store.SecretRef) bool { return ref.Vault != vault })
+
+	engine, wrapped, err := a.newVaultKey(vault, master)
+	if err != nil {
+		return ReencryptStats{}, err
+	}
+	seal := func(vault, name, value string) (string, error) {
+		return engine.EncryptWithAAD(value, secretAAD(vault, name))
+	}
+
+	// Values are decrypted with the old key, so it is only replaced once
+	// everything has been re-encrypted.
+	stats, err := a.reencrypt(refs, func(string) bool { return true }, seal)
+	if err != nil {
+		return stats, err
+	}
+	trashed, err := a.reencryptTrash(vault, func(string) bool { return true }, seal)
+	stats.add(trashed)
+	if err != nil {
+		return stats, err
+	}
+	stats.VaultKeys = 1
+	return stats, a.store.SetVaultKey(vault, wrapped)
+}
+
+// keyedVaults returns, sorted, every vault that has a data key or holds
+// values, live or in the trash, among refs.
+func (a *App) keyedVaults(refs []store.SecretRef) ([]string, error) {
+	var vaults []string
+	for vk, err := range a.store.ListVaultKeys() {
+		if err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, vk.Vault)
+	}
+	for _, ref := range refs {
+		vaults = append(vaults, ref.Vault)
+	}
+	trashed, err := a.ListTrash()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trashed {
+		vaults = append(vaults, t.Vault)
+	}
+	slices.Sort(vaults)
+	return slices.Compact(vaults), nil
+}
+
+// rewrapAndReencrypt re-wraps the vault keys and name key selected by
+// needed with next and moves values still encrypted directly with the
+// master key under their vault key, in the trash as well. It must run
+// inside an Atomic callback.
+func (a *App) rewrapAndReencrypt(next *crypto.Engine, needed func(wrapped string) bool) (ReencryptStats, error) {
+	rewrapped, err := a.rewrapVaultKeys(next, needed)
+	if err != nil {
+		return ReencryptStats{}, err
+	}
+	if _, err := a.rewrapNameKey(next, needed); err != nil {
+		return ReencryptStats{}, err
+	}
+
+	refs, err := a.allRefs()
+	if err != nil {
+		return ReencryptStats{}, err
+	}
+
+	// Vault keys are now wrapped with next, so new ones must be too.
+	target := a.withStore(a.store)
+	target.crypto = next
+
+	stats, err := a.reencrypt(refs, a.underMasterKey, target.encrypt)
+	if err != nil {
+		return stats, err
+	}
+	trashed, err := a.reencryptTrash("", a.underMasterKey, target.encrypt)
+	stats.add(trashed)
+	stats.VaultKeys = rewrapped
+	return stats, err
+}
+
+// reencrypt decrypts every stored value of refs selected by needed and
+// rewrites it in place sealed by seal. It must run inside an Atomic
+// callback.
+func (a *App) reencrypt(refs []store.SecretRef, needed func(encrypted string) bool, seal func(vault, name, value string) (string, error)) (ReencryptStats, error) {
+	var stats ReencryptStats
+
+	for _, ref := range refs {
+		encrypted, err := a.store.Get(ref.Vault, ref.Name)
+		if err != nil {
+			return stats, err
+		}
+		if needed(encrypted) {
+			if err := a.rewrite(seal, ref, 0, encrypted); err != nil {
+				return stats, err
+			}
+			stats.Secrets++
+		}
+
+		versions, err := a.History(ref.Vault, ref.Name)
+		if err != nil {
+			return stats, err
+		}
+		for _, v := range versions {
+			if !needed(v.Value) {
+				continue
+			}
+			if err := a.rewrite(seal, ref, v.Number, v.Value); err != nil {
+				return stats, err
+			}
+			stats.Versions++
+		}
+	}
+
+	return stats, nil
+}
+
+func (a *App) rewrite(seal func(vault, name, value string) (string, error), ref store.SecretRef, version int, encrypted string) error {
+	value, err := a.decrypt(ref.Vault, ref.Name, encrypted)
+	if err != nil {
+		if version > 0 {
+			return fmt.Errorf("%s/%s version %d: %w", ref.Vault, ref.Name, version, err)
+		}
+		return fmt.Errorf("%s/%s: %w", ref.Vault, ref.Name, err)
+	}
+
+	reencrypted, err := seal(ref.Vault, ref.Name, value)
+	if err != nil {
+		return err
+	}
+	return a.store.RewriteValue(ref.Vault, ref.Name, version, reencrypted)
+}
+
+// withStore returns a copy of the app that operates on s, typically the
+// transactional store passed to an Atomic callback.
+func (a *App) withStore(s store.Store) *App {
+	clone := *a
+	clone.store = s
+	return &clone
+}
+
+// allRefs returns every secret in the store. The refs are collected up
+// front so callers can modify the store while walking them.
+func (a *App) allRefs() ([]store.SecretRef, error) {
+	var vaults []string
+	for vault, err := range a.store.ListVaults() {
+		if err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, vault)
+	}
+
+	var refs []store.SecretRef
+	for _, vault := range vaults {
+		for name, err := range a.store.List(vault) {
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, store.SecretRef{Vault: vault, Name: name})
+		}
+	}
+	return refs, nil
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ossydotpy/veil/internal/filter"
+	"github.com/ossydotpy/veil/internal/generator"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// Recipe records how 'veil generate' made a secret, so that 'veil rotate'
+// can make its next value the same way. EnvFile is the absolute path of the
+// .env file the secret was written to, if any.
+type Recipe struct {
+	Type      string `json:"type"`
+	Length    int    `json:"length,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	Bits      int    `json:"bits,omitempty"`
+	NoSymbols bool   `json:"no_symbols,omitempty"`
+	EnvFile   string `json:"env_file,omitempty"`
+}
+
+// NewRecipe captures the settings of opts. A relative ToEnv path is made
+// absolute so that rotation works from any directory.
+func NewRecipe(opts generator.Options) (Recipe, error) {
+	r := Recipe{
+		Type:      opts.Type,
+		Length:    opts.Length,
+		Format:    opts.Format,
+		Prefix:    opts.Prefix,
+		Bits:      opts.Bits,
+		NoSymbols: opts.NoSymbols,
+	}
+	if r.Type == "" {
+		r.Type = "password"
+	}
+	if opts.ToEnv != "" {
+		path, err := filepath.Abs(opts.ToEnv)
+		if err != nil {
+			return Recipe{}, err
+		}
+		r.EnvFile = path
+	}
+	return r, nil
+}
+
+// Options returns the generator options of the recipe.
+func (r Recipe) Options() generator.Options {
+	return generator.Options{
+		Type:      r.Type,
+		Length:    r.Length,
+		Format:    r.Format,
+		Prefix:    r.Prefix,
+		Bits:      r.Bits,
+		NoSymbols: r.NoSymbols,
+	}
+}
+
+// String describes the recipe as flags of 'veil generate'.
+func (r Recipe) String() string {
+	parts := []string{"--type " + r.Type}
+	if r.Length > 0 {
+		parts = append(parts, fmt.Sprintf("--length %d", r.Length))
+	}
+	if r.Format != "" {
+		parts = append(parts, "--format "+r.Format)
+	}
+	if r.Prefix != "" {
+		parts = append(parts, "--prefix "+r.Prefix)
+	}
+	if r.Bits > 0 {
+		parts = append(parts, fmt.Sprintf("--bits %d", r.Bits))
+	}
+	if r.NoSymbols {
+		parts = append(parts, "--no-symbols")
+	}
+	return strings.Join(parts, " ")
+}
+
+func encodeRecipe(r Recipe) (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RecipeOf decodes the recipe stored in m. ok is false for secrets that
+// were not generated.
+func RecipeOf(m store.Metadata) (r Recipe, ok bool, err error) {
+	if m.Recipe == "" {
+		return Recipe{}, false, nil
+	}
+	if err := json.Unmarshal([]byte(m.Recipe), &r); err != nil {
+		return Recipe{}, false, fmt.Errorf("invalid generator recipe: %w", err)
+	}
+	return r, true, nil
+}
+
+// Rotation is one secret replaced by Rotate. Version is the number under
+// which the previous value was archived.
+type Rotation struct {
+	Name    string
+	Recipe  Recipe
+	Value   string
+	Version int
+
+	// EnvErr is set if the linked .env file could not be updated.
+	EnvErr error
+}
+
+// RotateResult lists the rotated secrets and, for patterns, the matching
+// secrets that were skipped because they were not generated.
+type RotateResult struct {
+	Rotated []Rotation
+	Skipped []string
+}
+
+// Rotate gives secrets of vault a new value made with their stored recipe.
+// pattern is a secret name, a pattern with a leading or trailing *, or
+// empty for every generated secret in the vault. A name given exactly must
+// have a recipe. All secrets are replaced in one transaction; if updateEnv
+// is set, each one's linked .env file is then updated as well.
+func (a *App) Rotate(vault, pattern string, updateEnv bool) (RotateResult, error) {
+	var result RotateResult
+
+	err := a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+
+		names, err := tx.rotationTargets(vault, pattern)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			m, err := s.GetMetadata(vault, name)
+			if err != nil {
+				return err
+			}
+			recipe, ok, err := RecipeOf(m)
+			if err != nil {
+				return fmt.Errorf("%s/%s: %w", vault, name, err)
+			}
+			if !ok {
+				if !strings.Contains(pattern, "*") && pattern != "" {
+					return fmt.Errorf("%w: %s/%s (generate it once with 'veil generate' to record how)", ErrNoRecipe, vault, name)
+				}
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+
+			value, err := generator.Generate(recipe.Options())
+			if err != nil {
+				return fmt.Errorf("%s/%s: %w", vault, name, err)
+			}
+			if err := tx.Set(vault, name, value); err != nil {
+				return err
+			}
+
+			history, err := tx.History(vault, name)
+			if err != nil {
+				return err
+			}
+			result.Rotated = append(result.Rotated, Rotation{
+				Name:    name,
+				Recipe:  recipe,
+				Value:   value,
+				Version: history[0].Number,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return RotateResult{}, err
+	}
+
+	if updateEnv {
+		for i, r := range result.Rotated {
+			if r.Recipe.EnvFile != "" {
+				result.Rotated[i].EnvErr = a.appendToEnvFile(r.Name, r.Value, r.Recipe.EnvFile, true)
+			}
+		}
+	}
+	return result, nil
+}
+
+// rotationTargets returns the names in vault matching pattern, sorted.
+func (a *App) rotationTargets(vault, pattern string) ([]string, error) {
+	exists, err := a.vaultExists(vault)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %q", ErrVaultNotFound, vault)
+	}
+
+	if pattern != "" && !strings.Contains(pattern, "*") {
+		if _, err := a.store.Get(vault, pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+
+	var names []string
+	for name, err := range a.store.List(vault) {
+		if err != nil {
+			return nil, err
+		}
+		if pattern == "" || filter.MatchPattern(name, pattern) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
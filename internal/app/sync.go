@@ -0,0 +1,151 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ossydotpy/veil/internal/envfile"
+	"github.com/ossydotpy/veil/internal/exporter"
+	"github.com/ossydotpy/veil/internal/filter"
+	"github.com/ossydotpy/veil/internal/fsutil"
+	"github.com/ossydotpy/veil/internal/importer"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// SyncPolicy decides which side wins when a key has different values in
+// the vault and the file.
+type SyncPolicy string
+
+const (
+	// SyncFail refuses to sync while there are conflicts.
+	SyncFail SyncPolicy = ""
+	// SyncPreferVault writes the vault's value to the file.
+	SyncPreferVault SyncPolicy = "vault"
+	// SyncPreferFile writes the file's value to the vault.
+	SyncPreferFile SyncPolicy = "file"
+	// SyncSkip leaves conflicting keys unchanged on both sides.
+	SyncSkip SyncPolicy = "skip"
+)
+
+// SyncOptions controls Sync.
+type SyncOptions struct {
+	Prefer  SyncPolicy
+	Include []string
+	Exclude []string
+	DryRun  bool
+	Backup  bool
+}
+
+// SyncResult describes the changes made, or planned for a dry run, on
+// each side. ToFile.NewKeys are the keys only the vault had and
+// ToVault.NewKeys those only the file had; conflicts appear as updated on
+// the side that lost them and as skipped on the other.
+type SyncResult struct {
+	ToFile    *exporter.Preview
+	ToVault   *importer.Preview
+	Conflicts []string
+}
+
+// Sync makes vault and the env file at path hold the same keys: keys only
+// in the vault are added to the file, keys only in the file are stored in
+// the vault, and conflicting keys are resolved by opts.Prefer. The vault
+// is compared as 'veil export' would write it, with inherited secrets and
+// expanded references; values from the file are stored in vault itself.
+// With SyncFail and conflicts, nothing is changed and the result is
+// returned with ErrSyncConflict.
+func (a *App) Sync(vault, path string, opts SyncOptions) (*SyncResult, error) {
+	switch opts.Prefer {
+	case SyncFail, SyncPreferVault, SyncPreferFile, SyncSkip:
+	default:
+		return nil, fmt.Errorf("unknown conflict policy %q (use vault, file or skip)", opts.Prefer)
+	}
+
+	secrets, err := a.GetAllSecrets(vault)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	if fsutil.FileExists(path) {
+		if vars, err = envfile.ParseEnvFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	toFile := make(map[string]string)
+	result := &SyncResult{
+		ToVault: &importer.Preview{
+			NewKeys:     make([]string, 0),
+			UpdatedKeys: make([]string, 0),
+			SkippedKeys: make([]string, 0),
+		},
+	}
+	var skipped []string
+	for _, e := range Diff(filter.FilterSecrets(secrets, opts.Include, opts.Exclude), filter.FilterSecrets(vars, opts.Include, opts.Exclude)) {
+		switch e.Kind {
+		case DiffOnlyLeft:
+			toFile[e.Name] = e.Left
+		case DiffOnlyRight:
+			result.ToVault.NewKeys = append(result.ToVault.NewKeys, e.Name)
+		case DiffChanged:
+			result.Conflicts = append(result.Conflicts, e.Name)
+			switch opts.Prefer {
+			case SyncPreferVault:
+				toFile[e.Name] = e.Left
+				result.ToVault.SkippedKeys = append(result.ToVault.SkippedKeys, e.Name)
+			case SyncPreferFile:
+				result.ToVault.UpdatedKeys = append(result.ToVault.UpdatedKeys, e.Name)
+				skipped = append(skipped, e.Name)
+			default:
+				result.ToVault.SkippedKeys = append(result.ToVault.SkippedKeys, e.Name)
+				skipped = append(skipped, e.Name)
+			}
+		}
+	}
+
+	exp, err := exporter.Get("env")
+	if err != nil {
+		return nil, err
+	}
+	exportOpts := exporter.ExportOptions{
+		TargetPath: path,
+		Append:     true,
+		Force:      true,
+		Backup:     opts.Backup,
+		Format:     "env",
+	}
+	result.ToFile, err = exp.Preview(toFile, exportOpts)
+	if err != nil {
+		return nil, err
+	}
+	result.ToFile.SkippedKeys = append(result.ToFile.SkippedKeys, skipped...)
+	slices.Sort(result.ToFile.SkippedKeys)
+
+	if len(result.Conflicts) > 0 && opts.Prefer == SyncFail {
+		return result, fmt.Errorf("%w: %d keys differ (choose a side with --prefer)", ErrSyncConflict, len(result.Conflicts))
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	err = a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		for _, keys := range [][]string{result.ToVault.NewKeys, result.ToVault.UpdatedKeys} {
+			for _, key := range keys {
+				if err := tx.Set(vault, key, vars[key]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(toFile) > 0 {
+		if err := exp.Export(toFile, exportOpts); err != nil {
+			return result, fmt.Errorf("vault updated, but writing %s failed: %w", path, err)
+		}
+	}
+	return result, nil
+}
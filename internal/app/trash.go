@@ -0,0 +1,156 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// trashRetentionMetaKey holds how long deleted secrets are kept in the
+// trash, as a Go duration.
+const trashRetentionMetaKey = "trash_retention"
+
+// DefaultTrashRetention is how long deleted secrets are kept in the trash
+// unless another retention has been set.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// Delete moves a secret, with its history and metadata, to the trash, from
+// which it can be restored until the trash retention has passed. Trashed
+// secrets past their retention are purged at the same time.
+func (a *App) Delete(vault, name string) error {
+	return a.store.Atomic(func(s store.Store) error {
+		if err := s.Trash(vault, name); err != nil {
+			return err
+		}
+		_, err := a.withStore(s).PurgeTrash(false)
+		return err
+	})
+}
+
+// ListTrash returns the trashed secrets, most recently deleted first.
+func (a *App) ListTrash() ([]store.TrashedSecret, error) {
+	var trashed []store.TrashedSecret
+	for t, err := range a.store.ListTrash() {
+		if err != nil {
+			return nil, err
+		}
+		trashed = append(trashed, t)
+	}
+	return trashed, nil
+}
+
+// Restore moves the most recently deleted secret called vault/name back
+// out of the trash.
+func (a *App) Restore(vault, name string) error {
+	trashed, err := a.ListTrash()
+	if err != nil {
+		return err
+	}
+	for _, t := range trashed {
+		if t.Vault != vault || t.Name != name {
+			continue
+		}
+		if err := a.store.RestoreTrash(t.ID); err != nil {
+			return fmt.Errorf("%s/%s: %w", vault, name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s/%s: %w", vault, name, store.ErrTrashNotFound)
+}
+
+// PurgeTrash permanently deletes the trashed secrets past the trash
+// retention, or all of them, and returns how many there were.
+func (a *App) PurgeTrash(all bool) (int, error) {
+	var before time.Time
+	if !all {
+		retention, err := a.TrashRetention()
+		if err != nil {
+			return 0, err
+		}
+		before = time.Now().Add(-retention)
+	}
+
+	purged, err := a.store.PurgeTrash(before)
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		compact(a.store)
+	}
+	return purged, nil
+}
+
+// TrashRetention returns how long deleted secrets are kept in the trash.
+func (a *App) TrashRetention() (time.Duration, error) {
+	value, err := a.store.GetMeta(trashRetentionMetaKey)
+	if errors.Is(err, store.ErrMetaNotFound) {
+		return DefaultTrashRetention, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		return 0, fmt.Errorf("invalid trash retention %q", value)
+	}
+	return retention, nil
+}
+
+// SetTrashRetention changes how long deleted secrets are kept in the trash.
+func (a *App) SetTrashRetention(retention time.Duration) error {
+	if retention <= 0 {
+		return fmt.Errorf("invalid trash retention %s: must be positive", retention)
+	}
+	return a.store.SetMeta(trashRetentionMetaKey, retention.String())
+}
+
+// reencryptTrash does for the trashed secrets of vault, or of every vault
+// if vault is empty, what reencrypt does for live secrets, so that they
+// can still be decrypted once restored. It must run inside an Atomic
+// callback.
+func (a *App) reencryptTrash(vault string, needed func(encrypted string) bool, seal func(vault, name, value string) (string, error)) (ReencryptStats, error) {
+	var stats ReencryptStats
+
+	trashed, err := a.ListTrash()
+	if err != nil {
+		return stats, err
+	}
+	for _, t := range trashed {
+		if vault != "" && t.Vault != vault {
+			continue
+		}
+
+		var values []store.Version
+		for v, err := range a.store.TrashValues(t.ID) {
+			if err != nil {
+				return stats, err
+			}
+			values = append(values, v)
+		}
+
+		for _, v := range values {
+			if !needed(v.Value) {
+				continue
+			}
+			value, err := a.decrypt(t.Vault, t.Name, v.Value)
+			if err != nil {
+				return stats, fmt.Errorf("trashed %s/%s: %w", t.Vault, t.Name, err)
+			}
+			reencrypted, err := seal(t.Vault, t.Name, value)
+			if err != nil {
+				return stats, err
+			}
+			if err := a.store.RewriteTrashValue(t.ID, v.Number, reencrypted); err != nil {
+				return stats, err
+			}
+			if v.Number == 0 {
+				stats.Secrets++
+			} else {
+				stats.Versions++
+			}
+		}
+	}
+	return stats, nil
+}
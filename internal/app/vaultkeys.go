@@ -0,0 +1,115 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ossydotpy/veil/internal/crypto"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// vaultKeyAAD is the associated data binding a wrapped data key to its
+// vault, so keys cannot be swapped between vaults by editing the database.
+func vaultKeyAAD(vault string) []byte {
+	return fmt.Appendf(nil, "veil:vault-key:%s", vault)
+}
+
+// vaultEngine returns an engine holding the data encryption key of vault.
+// If the vault has no key yet and create is set, one is generated.
+func (a *App) vaultEngine(vault string, create bool) (*crypto.Engine, error) {
+	wrapped, err := a.store.GetVaultKey(vault)
+	if errors.Is(err, store.ErrVaultKeyNotFound) && create {
+		engine, wrapped, err := a.newVaultKey(vault, a.crypto)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.store.SetVaultKey(vault, wrapped); err != nil {
+			return nil, err
+		}
+		return engine, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.unwrapVaultKey(vault, wrapped)
+}
+
+// newVaultKey generates a data key for vault and wraps it with master. The
+// caller stores the wrapped key.
+func (a *App) newVaultKey(vault string, master *crypto.Engine) (*crypto.Engine, string, error) {
+	dek, err := crypto.GenerateRandomKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate vault key: %w", err)
+	}
+	engine, err := crypto.NewEngine(dek)
+	if err != nil {
+		return nil, "", err
+	}
+	wrapped, err := master.EncryptWithAAD(dek, vaultKeyAAD(vault))
+	if err != nil {
+		return nil, "", err
+	}
+	return engine, wrapped, nil
+}
+
+// unwrapVaultKey decrypts a wrapped data key with the master key. Results
+// are cached by wrapped value, which is unique per key and master key, so
+// a key created in a transaction that was rolled back is never reused.
+func (a *App) unwrapVaultKey(vault, wrapped string) (*crypto.Engine, error) {
+	if engine, ok := a.dataKeys[wrapped]; ok {
+		return engine, nil
+	}
+
+	dek, err := a.crypto.DecryptWithAAD(wrapped, vaultKeyAAD(vault))
+	if err != nil {
+		return nil, fmt.Errorf("vault key for %s: %w", vault, err)
+	}
+	engine, err := crypto.NewEngine(dek)
+	if err != nil {
+		return nil, fmt.Errorf("vault key for %s: %w", vault, err)
+	}
+
+	a.dataKeys[wrapped] = engine
+	return engine, nil
+}
+
+// underMasterKey reports whether a stored value was encrypted directly with
+// the master key rather than a vault key, as all values were before vault
+// keys existed.
+func (a *App) underMasterKey(encrypted string) bool {
+	id, ok := crypto.KeyIDOf(encrypted)
+	return !ok || a.crypto.HasKey(id)
+}
+
+// rewrapVaultKeys re-wraps the data key of every vault selected by needed
+// with next. The data keys themselves, and so the values they encrypt, are
+// unchanged.
+func (a *App) rewrapVaultKeys(next *crypto.Engine, needed func(wrapped string) bool) (int, error) {
+	var keys []store.VaultKey
+	for vk, err := range a.store.ListVaultKeys() {
+		if err != nil {
+			return 0, err
+		}
+		keys = append(keys, vk)
+	}
+
+	rewrapped := 0
+	for _, vk := range keys {
+		if !needed(vk.Key) {
+			continue
+		}
+		dek, err := a.crypto.DecryptWithAAD(vk.Key, vaultKeyAAD(vk.Vault))
+		if err != nil {
+			return rewrapped, fmt.Errorf("vault key for %s: %w", vk.Vault, err)
+		}
+		wrapped, err := next.EncryptWithAAD(dek, vaultKeyAAD(vk.Vault))
+		if err != nil {
+			return rewrapped, err
+		}
+		if err := a.store.SetVaultKey(vk.Vault, wrapped); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+	return rewrapped, nil
+}
@@ -0,0 +1,266 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ossydotpy/veil/internal/filter"
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// Move moves vault/name with its archived versions and metadata to
+// newVault/newName. Values are bound to their vault and name, so each one
+// is re-encrypted for its new place. With force an existing destination is
+// moved to the trash first; otherwise it fails with store.ErrExists.
+func (a *App) Move(vault, name, newVault, newName string, force bool) error {
+	from, to := store.SecretRef{Vault: vault, Name: name}, store.SecretRef{Vault: newVault, Name: newName}
+	if from == to {
+		return ErrSameTarget
+	}
+
+	return a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		if force {
+			if err := tx.trashIfExists(to); err != nil {
+				return err
+			}
+		}
+		return tx.move(from, to)
+	})
+}
+
+// Copy copies the current value and metadata of vault/name to
+// newVault/newName; the archived versions stay with the original. With
+// force an existing destination is overwritten, keeping its old value in
+// its history; otherwise it fails with store.ErrExists.
+func (a *App) Copy(vault, name, newVault, newName string, force bool) error {
+	from, to := store.SecretRef{Vault: vault, Name: name}, store.SecretRef{Vault: newVault, Name: newName}
+	if from == to {
+		return ErrSameTarget
+	}
+
+	return a.store.Atomic(func(s store.Store) error {
+		return a.withStore(s).copy(from, to, force)
+	})
+}
+
+// RenameVault moves every secret of vault to newVault, which must not
+// exist yet, and returns how many there were. The inheritance of vault and
+// of the vaults inheriting from it follows the new name. Secrets of vault in
+// the trash keep their vault, so they are restored under the old name.
+func (a *App) RenameVault(vault, newVault string) (int, error) {
+	if vault == newVault {
+		return 0, ErrSameTarget
+	}
+
+	var moved int
+	err := a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		names, err := tx.vaultNames(vault)
+		if err != nil {
+			return err
+		}
+		if exists, err := tx.vaultExists(newVault); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("%w: %q", ErrVaultExists, newVault)
+		}
+
+		for _, name := range names {
+			if err := tx.move(store.SecretRef{Vault: vault, Name: name}, store.SecretRef{Vault: newVault, Name: name}); err != nil {
+				return err
+			}
+			moved++
+		}
+		if err := tx.renameInheritance(vault, newVault); err != nil {
+			return err
+		}
+		return tx.dropUnusedVaultKey(vault)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// CopyVault copies the current value and metadata of the secrets of vault
+// that match the include and exclude patterns to newVault, and returns how
+// many there were. Without force it fails with store.ErrExists before
+// copying anything if one of them already exists in newVault.
+func (a *App) CopyVault(vault, newVault string, include, exclude []string, force bool) (int, error) {
+	if vault == newVault {
+		return 0, ErrSameTarget
+	}
+
+	var copied int
+	err := a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		names, err := tx.vaultNames(vault)
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if !filter.MatchesFilters(name, include, exclude) {
+				continue
+			}
+			if err := tx.copy(store.SecretRef{Vault: vault, Name: name}, store.SecretRef{Vault: newVault, Name: name}, force); err != nil {
+				return err
+			}
+			copied++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return copied, nil
+}
+
+// DeleteVault moves every secret of vault to the trash, removes its
+// parents and returns how many secrets there were. They can be restored one
+// by one with Restore. A vault other vaults inherit from cannot be deleted.
+func (a *App) DeleteVault(vault string) (int, error) {
+	var trashed int
+	err := a.store.Atomic(func(s store.Store) error {
+		tx := a.withStore(s)
+		names, err := tx.vaultNames(vault)
+		if err != nil {
+			return err
+		}
+		children, err := tx.children(vault)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return fmt.Errorf("%w: %s", ErrVaultInherited, strings.Join(children, ", "))
+		}
+		if err := s.SetVaultParents(vault, nil); err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			if err := s.Trash(vault, name); err != nil {
+				return fmt.Errorf("%s/%s: %w", vault, name, err)
+			}
+			trashed++
+		}
+		_, err = tx.PurgeTrash(false)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return trashed, nil
+}
+
+// vaultNames returns the names of the secrets in vault, failing with
+// ErrVaultNotFound if there are none.
+func (a *App) vaultNames(vault string) ([]string, error) {
+	var names []string
+	for name, err := range a.store.List(vault) {
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrVaultNotFound, vault)
+	}
+	return names, nil
+}
+
+// move renames a secret and re-encrypts its value and archived versions
+// for the new vault and name. It must run inside an Atomic callback.
+func (a *App) move(from, to store.SecretRef) error {
+	if err := a.store.Rename(from.Vault, from.Name, to.Vault, to.Name); err != nil {
+		return fmt.Errorf("%s/%s: %w", to.Vault, to.Name, err)
+	}
+
+	encrypted, err := a.store.Get(to.Vault, to.Name)
+	if err != nil {
+		return err
+	}
+	if err := a.reseal(from, to, 0, encrypted); err != nil {
+		return err
+	}
+
+	versions, err := a.History(to.Vault, to.Name)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := a.reseal(from, to, v.Number, v.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reseal rewrites a value that was moved from one secret to another, which
+// is still encrypted for the old one, for its new vault and name.
+func (a *App) reseal(from, to store.SecretRef, version int, encrypted string) error {
+	value, err := a.decrypt(from.Vault, from.Name, encrypted)
+	if err != nil {
+		return fmt.Errorf("%s/%s: %w", from.Vault, from.Name, err)
+	}
+	resealed, err := a.encrypt(to.Vault, to.Name, value)
+	if err != nil {
+		return err
+	}
+	return a.store.RewriteValue(to.Vault, to.Name, version, resealed)
+}
+
+// copy saves the current value and metadata of one secret under another.
+// It must run inside an Atomic callback.
+func (a *App) copy(from, to store.SecretRef, force bool) error {
+	if !force {
+		if _, err := a.store.Get(to.Vault, to.Name); err == nil {
+			return fmt.Errorf("%s/%s: %w", to.Vault, to.Name, store.ErrExists)
+		}
+	}
+
+	value, err := a.getOwn(from.Vault, from.Name)
+	if err != nil {
+		return fmt.Errorf("%s/%s: %w", from.Vault, from.Name, err)
+	}
+	m, err := a.store.GetMetadata(from.Vault, from.Name)
+	if err != nil {
+		return err
+	}
+	if err := a.Set(to.Vault, to.Name, value); err != nil {
+		return err
+	}
+	return a.store.SetMetadata(to.Vault, to.Name, m)
+}
+
+// trashIfExists moves a secret to the trash if it exists.
+func (a *App) trashIfExists(ref store.SecretRef) error {
+	err := a.store.Trash(ref.Vault, ref.Name)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// dropUnusedVaultKey deletes the data key of vault once no secret, live or
+// in the trash, is encrypted with it.
+func (a *App) dropUnusedVaultKey(vault string) error {
+	for _, err := range a.store.List(vault) {
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+	trashed, err := a.ListTrash()
+	if err != nil {
+		return err
+	}
+	for _, t := range trashed {
+		if t.Vault == vault {
+			return nil
+		}
+	}
+	return a.store.DeleteVaultKey(vault)
+}
@@ -5,12 +5,55 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// EnvPatterns match the environment variables veil reads its configuration
+// from, including the master key. They are stripped from the environment of
+// commands started by 'veil run'.
+var EnvPatterns = []string{"MASTER_KEY", "MASTER_KEYS", "VEIL_*"}
+
 type Config struct {
-	MasterKey string
-	DbPath    string
-	StoreType string
+	MasterKey        string
+	MasterKeyFile    string
+	MasterKeyCommand string
+	MasterKeys       string
+	KeyRingFile      string
+	DbPath           string
+	StoreType        string
+	PassphraseFile   string
+
+	// Profile is the name of the profile in use and ProfileSource says
+	// how it was selected.
+	Profile       string
+	ProfileSource string
+
+	// File is the global config file and Project the project file found
+	// above the working directory, or nil if there is none.
+	File    *File
+	Project *Project
+
+	// ProjectErr is why the project file could not be loaded, in which
+	// case Project is nil. Only commands that use the project file fail
+	// because of it.
+	ProjectErr error
+
+	// Sources records where each of Settings got its value: an
+	// environment variable, "profile <name>" or "default". Settings
+	// without a value have no entry.
+	Sources map[string]string
+
+	settings Profile
+}
+
+// Get returns the value in use for the setting called name, or "" if it
+// is not set.
+func (c *Config) Get(name string) (string, error) {
+	s, err := LookupSetting(name)
+	if err != nil {
+		return "", err
+	}
+	return s.Get(&c.settings), nil
 }
 
 func (c *Config) Validate() error {
@@ -33,20 +76,108 @@ func (c *Config) ValidateMasterKey() error {
 	return nil
 }
 
-func LoadConfig() *Config {
-	masterkey := getenv("MASTER_KEY", "")
+// ValidateKeySources rejects configurations that name more than one source
+// for the master key, since it would be ambiguous which one encrypts.
+func (c *Config) ValidateKeySources() error {
+	sources := []struct {
+		name string
+		set  bool
+	}{
+		{"MASTER_KEY", c.MasterKey != ""},
+		{"VEIL_MASTER_KEY_FILE", c.MasterKeyFile != ""},
+		{"VEIL_MASTER_KEY_COMMAND", c.MasterKeyCommand != ""},
+		{"MASTER_KEYS", c.MasterKeys != ""},
+		{"VEIL_KEYRING_FILE", c.KeyRingFile != ""},
+	}
+
+	var set []string
+	for _, src := range sources {
+		if src.set {
+			set = append(set, src.name)
+		}
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("only one master key source may be set, got %s", strings.Join(set, " and "))
+	}
+	return nil
+}
+
+// LoadConfig reads the configuration. Environment variables override the
+// selected profile of the global config file, which overrides the
+// defaults; profile is the name given with --profile, if any. The project
+// file is looked up from the working directory, since it may select the
+// profile.
+func LoadConfig(profile string) (*Config, error) {
+	file, err := LoadFile(FilePath())
+	if err != nil {
+		return nil, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	// A broken project file must not stop commands that do not use it.
+	project, projectErr := FindProject(wd)
+
+	name, source := file.SelectProfile(profile, project)
+	selected, err := file.lookupProfile(name)
+	if err != nil && project != nil && source == project.Path {
+		// An unknown profile in the project file is a broken project file:
+		// it fails the commands using the project, and the others run with
+		// the profile they would use without it.
+		projectErr = fmt.Errorf("%w (selected by %s)", err, source)
+		project = nil
+		name, source = file.SelectProfile(profile, nil)
+		selected, err = file.lookupProfile(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w (selected by %s)", err, source)
+	}
 
 	home, _ := os.UserHomeDir()
-	defaultDbPath := filepath.Join(home, ".veil.db")
-	dbPath := getenv("VEIL_DB_PATH", defaultDbPath)
-	storeType := getenv("VEIL_STORE_TYPE", "sqlite")
+	defaults := Profile{
+		DbPath:    filepath.Join(home, ".veil.db"),
+		StoreType: "sqlite",
+	}
+	ignoreKeySource := keySourceEnv()
+
+	var resolved Profile
+	sources := make(map[string]string)
+	for _, s := range Settings {
+		value, from := s.Get(&defaults), "default"
+		if v := s.Get(&selected); v != "" && !(s.IsKeySource && ignoreKeySource) {
+			value, from = v, "profile "+name
+			if s.IsPath {
+				value = file.resolvePath(v)
+			}
+		}
+		if v := getenv(s.Env, ""); v != "" {
+			value, from = v, s.Env
+		}
+		if value != "" {
+			sources[s.Name] = from
+		}
+		s.Set(&resolved, value)
+	}
 
 	cfg := &Config{
-		MasterKey: masterkey,
-		DbPath:    dbPath,
-		StoreType: storeType,
+		MasterKey:        getenv("MASTER_KEY", ""),
+		MasterKeyFile:    resolved.MasterKeyFile,
+		MasterKeyCommand: resolved.MasterKeyCommand,
+		MasterKeys:       getenv("MASTER_KEYS", ""),
+		KeyRingFile:      resolved.KeyRingFile,
+		DbPath:           resolved.DbPath,
+		StoreType:        resolved.StoreType,
+		PassphraseFile:   resolved.PassphraseFile,
+		Profile:          name,
+		ProfileSource:    source,
+		File:             file,
+		Project:          project,
+		ProjectErr:       projectErr,
+		Sources:          sources,
+		settings:         resolved,
 	}
-	return cfg
+	return cfg, nil
 }
 
 func getenv(key string, def string) string {
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var (
+	ErrInsecureKeyFile = errors.New("key file is accessible by other users")
+
+	ErrKeyCommandFailed = errors.New("master key command failed")
+)
+
+// ReadKeyFile reads a file holding key material. Files that group or other
+// users can access are refused, as ssh does for private keys. Windows has
+// no such permission bits and is not checked.
+func ReadKeyFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if perm := info.Mode().Perm(); runtime.GOOS != "windows" && perm&0o077 != 0 {
+		return nil, fmt.Errorf("%w: %s has mode %04o, expected 0600 or stricter (run: chmod 600 %s)", ErrInsecureKeyFile, path, perm, path)
+	}
+	return os.ReadFile(path)
+}
+
+// RunKeyCommand runs command through the shell and returns what it prints
+// on stdout with surrounding whitespace removed. The command's stderr is
+// passed through so helpers such as password managers can report errors.
+func RunKeyCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrKeyCommandFailed, err)
+	}
+	return string(bytes.TrimSpace(out)), nil
+}
+
+// LoadMasterKey fills MasterKey from VEIL_MASTER_KEY_FILE or
+// VEIL_MASTER_KEY_COMMAND when one of them is set, so that the key never
+// has to be placed in the environment.
+func (c *Config) LoadMasterKey() error {
+	switch {
+	case c.MasterKeyFile != "":
+		data, err := ReadKeyFile(c.MasterKeyFile)
+		if err != nil {
+			return fmt.Errorf("VEIL_MASTER_KEY_FILE: %w", err)
+		}
+		c.MasterKey = strings.TrimSpace(string(data))
+		if c.MasterKey == "" {
+			return fmt.Errorf("VEIL_MASTER_KEY_FILE: %s is empty", c.MasterKeyFile)
+		}
+
+	case c.MasterKeyCommand != "":
+		key, err := RunKeyCommand(c.MasterKeyCommand)
+		if err != nil {
+			return fmt.Errorf("VEIL_MASTER_KEY_COMMAND: %w", err)
+		}
+		if key == "" {
+			return fmt.Errorf("VEIL_MASTER_KEY_COMMAND: command printed no key")
+		}
+		c.MasterKey = key
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+const testKey = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func writeKeyFile(t *testing.T, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "master.key")
+	if err := os.WriteFile(path, []byte(testKey+"\n"), mode); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatalf("Chmod error: %v", err)
+	}
+	return path
+}
+
+func TestLoadMasterKey_File(t *testing.T) {
+	cfg := &Config{MasterKeyFile: writeKeyFile(t, 0o600)}
+	if err := cfg.LoadMasterKey(); err != nil {
+		t.Fatalf("LoadMasterKey error: %v", err)
+	}
+	if cfg.MasterKey != testKey {
+		t.Errorf("MasterKey = %q, want %q", cfg.MasterKey, testKey)
+	}
+}
+
+func TestLoadMasterKey_RefusesReadableFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+	for _, mode := range []os.FileMode{0o640, 0o604, 0o644} {
+		cfg := &Config{MasterKeyFile: writeKeyFile(t, mode)}
+		if err := cfg.LoadMasterKey(); !errors.Is(err, ErrInsecureKeyFile) {
+			t.Errorf("mode %04o: LoadMasterKey error = %v, want ErrInsecureKeyFile", mode, err)
+		}
+	}
+}
+
+func TestLoadMasterKey_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("test command uses a POSIX shell")
+	}
+	cfg := &Config{MasterKeyCommand: "printf '  %s\\n' " + testKey}
+	if err := cfg.LoadMasterKey(); err != nil {
+		t.Fatalf("LoadMasterKey error: %v", err)
+	}
+	if cfg.MasterKey != testKey {
+		t.Errorf("MasterKey = %q, want %q", cfg.MasterKey, testKey)
+	}
+
+	cfg = &Config{MasterKeyCommand: "exit 3"}
+	if err := cfg.LoadMasterKey(); !errors.Is(err, ErrKeyCommandFailed) {
+		t.Errorf("LoadMasterKey error = %v, want ErrKeyCommandFailed", err)
+	}
+}
+
+func TestValidateKeySources(t *testing.T) {
+	if err := (&Config{MasterKeyFile: "a"}).ValidateKeySources(); err != nil {
+		t.Errorf("single source error: %v", err)
+	}
+	if err := (&Config{MasterKey: testKey, MasterKeyCommand: "pass"}).ValidateKeySources(); err == nil {
+		t.Error("MASTER_KEY with VEIL_MASTER_KEY_COMMAND should be rejected")
+	}
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/ossydotpy/veil/internal/fsutil"
+)
+
+var (
+	ErrUnknownProfile = errors.New("unknown profile")
+
+	ErrUnknownSetting = errors.New("unknown setting")
+)
+
+// DefaultProfile is the profile used when none is selected, neither on
+// the command line, in the environment, in the project file nor in the
+// config file.
+const DefaultProfile = "default"
+
+// Profile holds the settings of one named profile in the config file.
+// Master keys themselves are never stored in it, only where to find them.
+type Profile struct {
+	DbPath           string `toml:"db_path,omitempty"`
+	StoreType        string `toml:"store_type,omitempty"`
+	MasterKeyFile    string `toml:"master_key_file,omitempty"`
+	MasterKeyCommand string `toml:"master_key_command,omitempty"`
+	KeyRingFile      string `toml:"keyring_file,omitempty"`
+	PassphraseFile   string `toml:"passphrase_file,omitempty"`
+}
+
+// File is the global config file, which selects a profile and defines
+// the profiles.
+type File struct {
+	// Path is where the file was read from, or would be written to if it
+	// does not exist yet.
+	Path string `toml:"-"`
+
+	Profile  string             `toml:"profile,omitempty"`
+	Profiles map[string]Profile `toml:"profiles,omitempty"`
+}
+
+// Setting describes one profile setting and the environment variable that
+// overrides it.
+type Setting struct {
+	Name string
+	Env  string
+	// IsPath marks settings holding a path, which may start with ~ and are
+	// relative to the config file's directory.
+	IsPath bool
+	// IsKeySource marks the settings that locate the master key.
+	IsKeySource bool
+
+	field func(*Profile) *string
+}
+
+// Settings lists the profile settings in the order they are shown.
+var Settings = []Setting{
+	{Name: "db_path", Env: "VEIL_DB_PATH", IsPath: true, field: func(p *Profile) *string { return &p.DbPath }},
+	{Name: "store_type", Env: "VEIL_STORE_TYPE", field: func(p *Profile) *string { return &p.StoreType }},
+	{Name: "master_key_file", Env: "VEIL_MASTER_KEY_FILE", IsPath: true, IsKeySource: true, field: func(p *Profile) *string { return &p.MasterKeyFile }},
+	{Name: "master_key_command", Env: "VEIL_MASTER_KEY_COMMAND", IsKeySource: true, field: func(p *Profile) *string { return &p.MasterKeyCommand }},
+	{Name: "keyring_file", Env: "VEIL_KEYRING_FILE", IsPath: true, IsKeySource: true, field: func(p *Profile) *string { return &p.KeyRingFile }},
+	{Name: "passphrase_file", Env: "VEIL_PASSPHRASE_FILE", IsPath: true, field: func(p *Profile) *string { return &p.PassphraseFile }},
+}
+
+// LookupSetting returns the setting called name.
+func LookupSetting(name string) (Setting, error) {
+	for _, s := range Settings {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	names := make([]string, len(Settings))
+	for i, s := range Settings {
+		names[i] = s.Name
+	}
+	return Setting{}, fmt.Errorf("%w %q (expected profile, %s)", ErrUnknownSetting, name, strings.Join(names, ", "))
+}
+
+// Get returns the value of setting s in p.
+func (s Setting) Get(p *Profile) string {
+	return *s.field(p)
+}
+
+// Set changes setting s in p; an empty value removes it.
+func (s Setting) Set(p *Profile, value string) {
+	*s.field(p) = value
+}
+
+// FilePath returns the location of the global config file: $VEIL_CONFIG if
+// set, otherwise veil/config.toml in $XDG_CONFIG_HOME, defaulting to
+// ~/.config. On Windows the default is under %AppData%.
+func FilePath() string {
+	if path := getenv("VEIL_CONFIG", ""); path != "" {
+		return path
+	}
+	dir := getenv("XDG_CONFIG_HOME", "")
+	if dir == "" && runtime.GOOS == "windows" {
+		dir, _ = os.UserConfigDir()
+	}
+	if dir == "" {
+		home, _ := os.UserHomeDir()
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "veil", "config.toml")
+}
+
+// LoadFile reads the config file at path. A missing file is an empty
+// configuration; unknown settings are rejected so that typos do not go
+// unnoticed.
+func LoadFile(path string) (*File, error) {
+	f := &File{Path: path}
+	md, err := toml.DecodeFile(path, f)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("invalid config file %s: unknown settings %s", path, strings.Join(keys, ", "))
+	}
+	return f, nil
+}
+
+// Save writes the file back to its path, creating the directory if
+// needed. Comments in an existing file are not preserved.
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(f); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	return fsutil.SafeWriteFile(f.Path, buf.Bytes(), 0600, false, "")
+}
+
+// ProfileNames returns the names of the profiles defined in the file,
+// sorted.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SelectProfile decides which profile to use: the one given on the
+// command line, then $VEIL_PROFILE, then the project file's, then the
+// config file's, and finally DefaultProfile. It returns the name and a
+// description of where it came from.
+func (f *File) SelectProfile(flag string, project *Project) (name, source string) {
+	switch {
+	case flag != "":
+		return flag, "--profile"
+	case getenv("VEIL_PROFILE", "") != "":
+		return getenv("VEIL_PROFILE", ""), "VEIL_PROFILE"
+	case project != nil && project.Profile != "":
+		return project.Profile, project.Path
+	case f.Profile != "":
+		return f.Profile, f.Path
+	}
+	return DefaultProfile, "default"
+}
+
+// lookupProfile returns the profile called name. DefaultProfile need not
+// be defined; any other profile must be.
+func (f *File) lookupProfile(name string) (Profile, error) {
+	p, ok := f.Profiles[name]
+	if !ok && name != DefaultProfile {
+		if len(f.Profiles) == 0 {
+			return p, fmt.Errorf("%w %q: %s defines no profiles", ErrUnknownProfile, name, f.Path)
+		}
+		return p, fmt.Errorf("%w %q (defined in %s: %s)", ErrUnknownProfile, name, f.Path, strings.Join(f.ProfileNames(), ", "))
+	}
+	return p, nil
+}
+
+// resolvePath expands a leading ~ and makes a relative path from the
+// config file relative to the file's directory.
+func (f *File) resolvePath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(f.Path), path)
+}
+
+// keySourceEnv reports whether any environment variable naming a master
+// key source is set. If so, the profile's key source is ignored as a
+// whole, so the two never combine into an ambiguous configuration.
+func keySourceEnv() bool {
+	return slices.ContainsFunc([]string{"MASTER_KEY", "MASTER_KEYS", "VEIL_MASTER_KEY_FILE", "VEIL_MASTER_KEY_COMMAND", "VEIL_KEYRING_FILE"}, func(env string) bool {
+		return getenv(env, "") != ""
+	})
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupConfig points veil at a config file in a fresh directory, clears
+// the environment variables that override it and moves to a directory
+// without a project file.
+func setupConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if content != "" {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("VEIL_CONFIG", path)
+	for _, env := range []string{"VEIL_PROFILE", "MASTER_KEY", "MASTER_KEYS"} {
+		t.Setenv(env, "")
+	}
+	for _, s := range Settings {
+		t.Setenv(s.Env, "")
+	}
+	t.Chdir(dir)
+	return dir
+}
+
+const testConfig = `
+profile = "personal"
+
+[profiles.personal]
+db_path = "personal.db"
+master_key_file = "~/.config/veil/master.key"
+
+[profiles.team]
+db_path = "/mnt/team/veil.db"
+master_key_command = "pass show veil/team"
+`
+
+func TestLoadConfig_Profiles(t *testing.T) {
+	dir := setupConfig(t, testConfig)
+	home, _ := os.UserHomeDir()
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Profile != "personal" || cfg.DbPath != filepath.Join(dir, "personal.db") {
+		t.Errorf("default profile: Profile = %s, DbPath = %s", cfg.Profile, cfg.DbPath)
+	}
+	if cfg.MasterKeyFile != filepath.Join(home, ".config/veil/master.key") {
+		t.Errorf("MasterKeyFile = %s, want ~ expanded", cfg.MasterKeyFile)
+	}
+	if cfg.StoreType != "sqlite" || cfg.Sources["store_type"] != "default" {
+		t.Errorf("StoreType = %s from %s, want default sqlite", cfg.StoreType, cfg.Sources["store_type"])
+	}
+
+	t.Setenv("VEIL_PROFILE", "team")
+	cfg, err = LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Profile != "team" || cfg.DbPath != "/mnt/team/veil.db" || cfg.MasterKeyCommand != "pass show veil/team" || cfg.MasterKeyFile != "" {
+		t.Errorf("VEIL_PROFILE=team: %+v", cfg)
+	}
+
+	cfg, err = LoadConfig("personal")
+	if err != nil || cfg.Profile != "personal" || cfg.ProfileSource != "--profile" {
+		t.Errorf("--profile personal: %+v, %v", cfg, err)
+	}
+
+	if _, err := LoadConfig("staging"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("LoadConfig(staging) error = %v, want ErrUnknownProfile", err)
+	}
+}
+
+func TestLoadConfig_EnvironmentOverridesProfile(t *testing.T) {
+	setupConfig(t, testConfig)
+	t.Setenv("VEIL_DB_PATH", "/tmp/override.db")
+	t.Setenv("MASTER_KEY", "0123")
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.DbPath != "/tmp/override.db" || cfg.Sources["db_path"] != "VEIL_DB_PATH" {
+		t.Errorf("DbPath = %s from %s", cfg.DbPath, cfg.Sources["db_path"])
+	}
+	// The profile's key file would make two key sources.
+	if cfg.MasterKeyFile != "" {
+		t.Errorf("MasterKeyFile = %s, want the profile's key source ignored", cfg.MasterKeyFile)
+	}
+	if err := cfg.ValidateKeySources(); err != nil {
+		t.Errorf("ValidateKeySources: %v", err)
+	}
+}
+
+func TestLoadConfig_ProjectSelectsProfile(t *testing.T) {
+	dir := setupConfig(t, testConfig)
+	if err := os.WriteFile(filepath.Join(dir, ProjectFileName), []byte("profile = \"team\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Profile != "team" || cfg.Project == nil {
+		t.Errorf("Profile = %s, Project = %v; want team from the project file", cfg.Profile, cfg.Project)
+	}
+}
+
+func TestLoadConfig_UnknownProjectProfileIsAProjectError(t *testing.T) {
+	dir := setupConfig(t, testConfig)
+	if err := os.WriteFile(filepath.Join(dir, ProjectFileName), []byte("profile = \"staging\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if !errors.Is(cfg.ProjectErr, ErrUnknownProfile) || cfg.Project != nil {
+		t.Errorf("ProjectErr = %v, Project = %v; want ErrUnknownProfile and no project", cfg.ProjectErr, cfg.Project)
+	}
+	if cfg.Profile != "personal" {
+		t.Errorf("Profile = %s, want personal from the config file", cfg.Profile)
+	}
+
+	// A profile chosen explicitly still has to exist.
+	t.Setenv("VEIL_PROFILE", "staging")
+	if _, err := LoadConfig(""); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("LoadConfig with VEIL_PROFILE=staging error = %v, want ErrUnknownProfile", err)
+	}
+}
+
+func TestLoadConfig_NoFile(t *testing.T) {
+	setupConfig(t, "")
+
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	if cfg.Profile != DefaultProfile || cfg.StoreType != "sqlite" || filepath.Base(cfg.DbPath) != ".veil.db" {
+		t.Errorf("LoadConfig without a file = %+v", cfg)
+	}
+	if _, err := LoadConfig("team"); !errors.Is(err, ErrUnknownProfile) {
+		t.Errorf("LoadConfig(team) error = %v, want ErrUnknownProfile", err)
+	}
+}
+
+func TestFile_SaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	f := &File{
+		Path:     filepath.Join(dir, "veil", "config.toml"),
+		Profile:  "team",
+		Profiles: map[string]Profile{"team": {DbPath: "/mnt/team/veil.db"}},
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+
+	loaded, err := LoadFile(f.Path)
+	if err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+	if loaded.Profile != "team" || loaded.Profiles["team"].DbPath != "/mnt/team/veil.db" {
+		t.Errorf("LoadFile = %+v", loaded)
+	}
+}
+
+func TestLoadFile_RejectsUnknownSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[profiles.team]\ndb_pth = \"x\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("LoadFile accepted an unknown setting")
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ProjectFileName is the name of the project file searched for by
+// FindProject.
+const ProjectFileName = ".veil.toml"
+
+// Project holds the settings of a project file, which let commands run in
+// the project omit the vault and filters.
+type Project struct {
+	// Path is the project file the settings were read from.
+	Path string `toml:"-"`
+
+	Vault   string        `toml:"vault"`
+	Include []string      `toml:"include"`
+	Exclude []string      `toml:"exclude"`
+	Export  ProjectExport `toml:"export"`
+
+	// Profile selects a profile from the global config file, unless
+	// another one is given with --profile or VEIL_PROFILE.
+	Profile string `toml:"profile"`
+}
+
+// ProjectExport holds the defaults for 'veil export'. To is relative to
+// the directory of the project file and may not leave it, since project
+// files are committed to repositories that anyone may have written.
+type ProjectExport struct {
+	To     string `toml:"to"`
+	Format string `toml:"format"`
+}
+
+// Dir returns the directory holding the project file.
+func (p *Project) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// ExportPath returns the export target resolved against the project
+// directory, or "" if none is set.
+func (p *Project) ExportPath() string {
+	if p.Export.To == "" {
+		return ""
+	}
+	return filepath.Join(p.Dir(), p.Export.To)
+}
+
+// FindProject looks for a project file in dir and each of its parents and
+// loads the first one found. It returns nil if there is none.
+func FindProject(dir string) (*Project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, ProjectFileName)
+		// Directories that cannot be searched are passed over like
+		// those without a project file.
+		if _, err := os.Stat(path); err == nil {
+			return LoadProject(path)
+		} else if !errors.Is(err, os.ErrNotExist) && !errors.Is(err, os.ErrPermission) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads the project file at path. Unknown settings are
+// rejected so that typos do not go unnoticed.
+func LoadProject(path string) (*Project, error) {
+	p := &Project{Path: path}
+	md, err := toml.DecodeFile(path, p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project file %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("invalid project file %s: unknown settings %s", path, strings.Join(keys, ", "))
+	}
+	if p.Export.To != "" && !filepath.IsLocal(p.Export.To) {
+		return nil, fmt.Errorf("invalid project file %s: export.to %q must be a relative path inside the project directory (use --to for other targets)", path, p.Export.To)
+	}
+	return p, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindProject_WalksUp(t *testing.T) {
+	root := t.TempDir()
+	content := `
+vault = "dev"
+exclude = ["LOCAL_*"]
+
+[export]
+to = "config/.env"
+format = "env"
+`
+	if err := os.WriteFile(filepath.Join(root, ProjectFileName), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(root, "src", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := FindProject(nested)
+	if err != nil {
+		t.Fatalf("FindProject error: %v", err)
+	}
+	if p == nil {
+		t.Fatal("FindProject found no project file")
+	}
+	if p.Vault != "dev" || !slices.Equal(p.Exclude, []string{"LOCAL_*"}) || p.Export.Format != "env" {
+		t.Errorf("project = %+v", p)
+	}
+	if want := filepath.Join(root, "config", ".env"); p.ExportPath() != want {
+		t.Errorf("ExportPath = %s, want %s", p.ExportPath(), want)
+	}
+}
+
+func TestFindProject_None(t *testing.T) {
+	p, err := FindProject(t.TempDir())
+	if err != nil || p != nil {
+		t.Errorf("FindProject = %+v, %v, want nil", p, err)
+	}
+}
+
+func TestLoadProject_RejectsExportOutsideProject(t *testing.T) {
+	for _, to := range []string{"/tmp/.env", "../.env", "config/../../.env"} {
+		path := filepath.Join(t.TempDir(), ProjectFileName)
+		if err := os.WriteFile(path, []byte("[export]\nto = \""+to+"\"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadProject(path); err == nil {
+			t.Errorf("LoadProject accepted export.to = %q", to)
+		}
+	}
+}
+
+func TestLoadProject_RejectsUnknownSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ProjectFileName)
+	if err := os.WriteFile(path, []byte("vault = \"dev\"\nvualt = \"prod\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := LoadProject(path)
+	if err == nil || !strings.Contains(err.Error(), "vualt") {
+		t.Errorf("LoadProject error = %v, want unknown setting vualt", err)
+	}
+}
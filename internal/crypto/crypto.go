@@ -4,38 +4,55 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
+	"strings"
 )
 
+// formatV1Prefix marks ciphertexts sealed with associated data before the
+// envelope format existed.
+const formatV1Prefix = "v1:"
+
+const gcmNonceSize = 12
+
+// Engine encrypts with its primary key and decrypts with any key in its
+// ring. An engine created by NewEngine holds a single key.
 type Engine struct {
-	key []byte
+	key   []byte
+	keyID string
+	keys  map[string][]byte
+	order []string
 }
 
 func NewEngine(keyHex string) (*Engine, error) {
-	key, err := hex.DecodeString(keyHex)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFormat, err)
-	}
+	return NewKeyRing([]NamedKey{{Hex: keyHex}})
+}
 
-	if len(key) != 32 {
-		return nil, fmt.Errorf("%w: expected 32 bytes, got %d", ErrInvalidKeyLength, len(key))
-	}
-	return &Engine{key: key}, nil
+// Fingerprint returns a short, non-secret identifier for a key: the first
+// four bytes of its SHA-256 hash, hex encoded.
+func Fingerprint(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
 }
 
-func (e *Engine) Encrypt(value string) (string, error) {
-	plaintext := []byte(value)
+// KeyID returns the ID of the primary key, recorded in envelopes produced
+// by this engine.
+func (e *Engine) KeyID() string {
+	return e.keyID
+}
 
-	block, err := aes.NewCipher(e.key)
-	if err != nil {
-		return "", fmt.Errorf("error creating aes block cipher: %w", err)
-	}
+func (e *Engine) Encrypt(value string) (string, error) {
+	return e.EncryptWithAAD(value, nil)
+}
 
-	gcm, err := cipher.NewGCM(block)
+// EncryptWithAAD encrypts value into an envelope bound to aad: the result
+// only decrypts when the same associated data is supplied.
+func (e *Engine) EncryptWithAAD(value string, aad []byte) (string, error) {
+	gcm, err := newGCM(e.key)
 	if err != nil {
-		return "", fmt.Errorf("error setting gcm mode: %w", err)
+		return "", err
 	}
 
 	nonce := make([]byte, gcm.NonceSize())
@@ -43,36 +60,109 @@ func (e *Engine) Encrypt(value string) (string, error) {
 		return "", fmt.Errorf("error generating the nonce: %w", err)
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
-	return hex.EncodeToString(ciphertext), nil
+	env := envelope{
+		version:   envelopeVersion,
+		algorithm: AlgAES256GCM,
+		keyID:     e.keyID,
+		nonce:     nonce,
+	}
+	env.ciphertext = gcm.Seal(nil, nonce, []byte(value), env.associatedData(aad))
+	return env.encode(), nil
 }
 
 func (e *Engine) Decrypt(encryptedValue string) (string, error) {
-	ciphertext, err := hex.DecodeString(encryptedValue)
-	if err != nil {
-		return "", fmt.Errorf("error decoding hex: %w", err)
+	return e.DecryptWithAAD(encryptedValue, nil)
+}
+
+// DecryptWithAAD decrypts a value with the associated data it was sealed
+// with. Envelopes, "v1:" values and legacy hex values are all accepted;
+// legacy values carry no associated data and are opened without it.
+func (e *Engine) DecryptWithAAD(encryptedValue string, aad []byte) (string, error) {
+	switch FormatOf(encryptedValue) {
+	case FormatEnvelope:
+		return e.openEnvelope(encryptedValue, aad)
+	case FormatV1:
+		return e.openHex(strings.TrimPrefix(encryptedValue, formatV1Prefix), aad)
+	default:
+		return e.openHex(encryptedValue, nil)
+	}
+}
+
+// NeedsUpgrade reports whether a stored value should be rewritten to be in
+// the current envelope format under the primary key. A value naming the
+// primary key by its fingerprint alias is already under it.
+func (e *Engine) NeedsUpgrade(encryptedValue string) bool {
+	id, ok := KeyIDOf(encryptedValue)
+	if !ok {
+		return true
 	}
+	id, ok = e.ResolveKeyID(id)
+	return !ok || id != e.keyID
+}
+
+// IsLegacy reports whether a stored value predates associated data and is
+// therefore not bound to the secret it is stored under.
+func IsLegacy(encryptedValue string) bool {
+	return FormatOf(encryptedValue) == FormatLegacy
+}
 
-	block, err := aes.NewCipher(e.key)
+func (e *Engine) openEnvelope(value string, aad []byte) (string, error) {
+	env, err := parseEnvelope(value)
 	if err != nil {
-		return "", fmt.Errorf("error creating aes block cipher: %w", err)
+		return "", err
 	}
+	key, ok := e.keys[env.keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKeyID, env.keyID)
+	}
+	return open(key, env.nonce, env.ciphertext, env.associatedData(aad))
+}
 
-	gcm, err := cipher.NewGCM(block)
+func (e *Engine) openHex(encoded string, aad []byte) (string, error) {
+	ciphertext, err := hex.DecodeString(encoded)
 	if err != nil {
-		return "", fmt.Errorf("error setting gcm mode: %w", err)
+		return "", fmt.Errorf("error decoding hex: %w", err)
 	}
 
-	nonceSize := gcm.NonceSize()
-	if len(ciphertext) < nonceSize {
+	if len(ciphertext) < gcmNonceSize {
 		return "", ErrCiphertextTooShort
 	}
 
-	nonce, ciphertext := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	// Values without an envelope don't say which key sealed them, so try
+	// the primary key first and then the rest of the ring.
+	nonce, ciphertext := ciphertext[:gcmNonceSize], ciphertext[gcmNonceSize:]
+	for _, id := range e.order {
+		var plaintext string
+		if plaintext, err = open(e.keys[id], nonce, ciphertext, aad); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", err
+}
+
+func open(key, nonce, ciphertext, aad []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", ErrDecryptionFailed, err)
 	}
 
 	return string(plaintext), nil
 }
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating aes block cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error setting gcm mode: %w", err)
+	}
+	return gcm, nil
+}
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"encoding/hex"
+	"errors"
 	"strings"
 	"testing"
 )
@@ -124,9 +125,12 @@ func TestEngine_EncryptDecrypt(t *testing.T) {
 				return
 			}
 
-			// Verify encrypted value is hex
-			if _, err := hex.DecodeString(encrypted); err != nil {
-				t.Errorf("Encrypt() returned non-hex string: %v", err)
+			// Verify encrypted value is an envelope under this key
+			if FormatOf(encrypted) != FormatEnvelope {
+				t.Errorf("Encrypt() returned value not in envelope format: %q", encrypted)
+			}
+			if id, ok := KeyIDOf(encrypted); !ok || id != engine.KeyID() {
+				t.Errorf("KeyIDOf() = %q, %v; want %q", id, ok, engine.KeyID())
 			}
 
 			// Encrypted value should be different from plaintext
@@ -215,14 +219,41 @@ func TestEngine_DecryptInvalid(t *testing.T) {
 			wantErr: true,
 			errMsg:  "ciphertext too short",
 		},
+		{
+			name:    "truncated envelope",
+			input:   "VgIBAA",
+			wantErr: true,
+			errMsg:  "ciphertext too short",
+		},
+		{
+			name:    "unsupported algorithm",
+			input:   envelope{version: envelopeVersion, algorithm: 9, nonce: make([]byte, 12)}.encode(),
+			wantErr: true,
+			errMsg:  "unsupported encryption algorithm",
+		},
+		{
+			name:    "unknown envelope version",
+			input:   envelope{version: 7, algorithm: AlgAES256GCM, nonce: make([]byte, 12)}.encode(),
+			wantErr: true,
+			errMsg:  "invalid ciphertext envelope",
+		},
+		{
+			name: "wrong key - envelope from a different key",
+			input: func() string {
+				otherEngine, _ := NewEngine("fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210")
+				enc, _ := otherEngine.Encrypt("test")
+				return enc
+			}(),
+			wantErr: true,
+			errMsg:  "unknown key",
+		},
 		{
 			name: "wrong key - valid hex but different key",
 			input: func() string {
 				// Create engine with different key and encrypt
 				otherKey := "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
 				otherEngine, _ := NewEngine(otherKey)
-				enc, _ := otherEngine.Encrypt("test")
-				return enc
+				return sealHex(t, otherEngine, "test", nil)
 			}(),
 			wantErr: true,
 			errMsg:  "decryption failed",
@@ -287,3 +318,85 @@ func TestEngine_DifferentKeys(t *testing.T) {
 		t.Errorf("Decrypt() = %v, want %v", decrypted, value)
 	}
 }
+
+func TestEngine_AssociatedData(t *testing.T) {
+	engine, err := NewEngine("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	encrypted, err := engine.EncryptWithAAD("hunter2", []byte("production/DB_PASSWORD"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD() error = %v", err)
+	}
+	if IsLegacy(encrypted) {
+		t.Error("IsLegacy() = true for a value sealed with associated data")
+	}
+
+	got, err := engine.DecryptWithAAD(encrypted, []byte("production/DB_PASSWORD"))
+	if err != nil || got != "hunter2" {
+		t.Errorf("DecryptWithAAD() = %q, %v; want %q", got, err, "hunter2")
+	}
+
+	if _, err := engine.DecryptWithAAD(encrypted, []byte("dev/DB_PASSWORD")); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("DecryptWithAAD() with other associated data error = %v, want ErrDecryptionFailed", err)
+	}
+}
+
+func TestEngine_DecryptLegacy(t *testing.T) {
+	engine, err := NewEngine("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+
+	// Pre-v1 values are bare hex of nonce||ciphertext sealed without
+	// associated data; they must keep decrypting whatever AAD is supplied.
+	legacy := sealHex(t, engine, "legacy", nil)
+	if !IsLegacy(legacy) {
+		t.Error("IsLegacy() = false for a bare hex value")
+	}
+	if !engine.NeedsUpgrade(legacy) {
+		t.Error("NeedsUpgrade() = false for a bare hex value")
+	}
+
+	got, err := engine.DecryptWithAAD(legacy, []byte("production/KEY"))
+	if err != nil || got != "legacy" {
+		t.Errorf("DecryptWithAAD() on legacy value = %q, %v; want %q", got, err, "legacy")
+	}
+
+	// v1 values are the same body behind a prefix, sealed with AAD.
+	v1 := formatV1Prefix + sealHex(t, engine, "bound", []byte("production/KEY"))
+	if FormatOf(v1) != FormatV1 || IsLegacy(v1) || !engine.NeedsUpgrade(v1) {
+		t.Errorf("v1 value misclassified: format %v", FormatOf(v1))
+	}
+	got, err = engine.DecryptWithAAD(v1, []byte("production/KEY"))
+	if err != nil || got != "bound" {
+		t.Errorf("DecryptWithAAD() on v1 value = %q, %v; want %q", got, err, "bound")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	engine, err := NewEngine("0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	if len(engine.KeyID()) != 8 {
+		t.Errorf("KeyID() = %q, want 8 hex characters", engine.KeyID())
+	}
+	other, _ := NewEngine("fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210")
+	if engine.KeyID() == other.KeyID() {
+		t.Error("different keys produced the same key ID")
+	}
+}
+
+// sealHex produces hex of nonce||ciphertext, the body of both
+// pre-envelope formats.
+func sealHex(t *testing.T, e *Engine, value string, aad []byte) string {
+	t.Helper()
+	gcm, err := newGCM(e.key)
+	if err != nil {
+		t.Fatalf("newGCM() error = %v", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	return hex.EncodeToString(gcm.Seal(nonce, nonce, []byte(value), aad))
+}
@@ -0,0 +1,124 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// Envelope layout, before base64 encoding:
+//
+//	magic (1) | version (1) | algorithm (1) | key ID length (1) | key ID | nonce | ciphertext
+//
+// The magic byte always encodes to a leading "V" in base64, which
+// distinguishes envelopes from legacy hex values and "v1:" values. The
+// header, everything before the nonce, is authenticated along with the
+// caller's associated data, so it cannot be edited without detection.
+const (
+	envelopeMagic   byte = 'V'
+	envelopeVersion byte = 2
+
+	// AlgAES256GCM is AES-256 in Galois/Counter Mode with a 12-byte nonce.
+	AlgAES256GCM byte = 1
+
+	envelopeHeaderSize = 4
+	envelopeTextPrefix = "V"
+)
+
+var envelopeEncoding = base64.RawStdEncoding
+
+// Format identifies how a stored value was encrypted.
+type Format int
+
+const (
+	// FormatLegacy is hex of nonce||ciphertext without associated data.
+	FormatLegacy Format = iota
+	// FormatV1 is "v1:" followed by hex of nonce||ciphertext, sealed with
+	// associated data.
+	FormatV1
+	// FormatEnvelope is the self-describing envelope.
+	FormatEnvelope
+)
+
+// FormatOf reports the format of a stored value without decrypting it.
+func FormatOf(encryptedValue string) Format {
+	switch {
+	case strings.HasPrefix(encryptedValue, envelopeTextPrefix):
+		return FormatEnvelope
+	case strings.HasPrefix(encryptedValue, formatV1Prefix):
+		return FormatV1
+	default:
+		return FormatLegacy
+	}
+}
+
+type envelope struct {
+	version    byte
+	algorithm  byte
+	keyID      string
+	nonce      []byte
+	ciphertext []byte
+}
+
+// header returns the magic byte, version, algorithm and key ID as encoded
+// in the envelope.
+func (env envelope) header() []byte {
+	buf := make([]byte, 0, envelopeHeaderSize+len(env.keyID))
+	buf = append(buf, envelopeMagic, env.version, env.algorithm, byte(len(env.keyID)))
+	return append(buf, env.keyID...)
+}
+
+// associatedData returns what the ciphertext is sealed with: the header
+// followed by the caller's associated data.
+func (env envelope) associatedData(aad []byte) []byte {
+	return append(env.header(), aad...)
+}
+
+func (env envelope) encode() string {
+	buf := make([]byte, 0, envelopeHeaderSize+len(env.keyID)+len(env.nonce)+len(env.ciphertext))
+	buf = append(buf, env.header()...)
+	buf = append(buf, env.nonce...)
+	buf = append(buf, env.ciphertext...)
+	return envelopeEncoding.EncodeToString(buf)
+}
+
+func parseEnvelope(value string) (envelope, error) {
+	data, err := envelopeEncoding.DecodeString(value)
+	if err != nil {
+		return envelope{}, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if len(data) < envelopeHeaderSize || data[0] != envelopeMagic {
+		return envelope{}, ErrInvalidEnvelope
+	}
+
+	env := envelope{version: data[1], algorithm: data[2]}
+	if env.version != envelopeVersion {
+		return envelope{}, fmt.Errorf("%w: version %d", ErrInvalidEnvelope, env.version)
+	}
+	if env.algorithm != AlgAES256GCM {
+		return envelope{}, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, env.algorithm)
+	}
+
+	keyIDLen := int(data[3])
+	rest := data[envelopeHeaderSize:]
+	if len(rest) < keyIDLen+gcmNonceSize {
+		return envelope{}, ErrCiphertextTooShort
+	}
+	env.keyID = string(rest[:keyIDLen])
+	env.nonce = rest[keyIDLen : keyIDLen+gcmNonceSize]
+	env.ciphertext = rest[keyIDLen+gcmNonceSize:]
+	return env, nil
+}
+
+// KeyIDOf returns the ID of the key a value was encrypted with. Only
+// envelopes record a key ID.
+func KeyIDOf(encryptedValue string) (string, bool) {
+	if FormatOf(encryptedValue) != FormatEnvelope {
+		return "", false
+	}
+	env, err := parseEnvelope(encryptedValue)
+	if err != nil {
+		return "", false
+	}
+	return env.keyID, true
+}
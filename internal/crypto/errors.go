@@ -11,4 +11,22 @@ var (
 	ErrCiphertextTooShort = errors.New("ciphertext too short")
 
 	ErrDecryptionFailed = errors.New("decryption failed")
+
+	ErrInvalidEnvelope = errors.New("invalid ciphertext envelope")
+
+	ErrUnsupportedAlgorithm = errors.New("unsupported encryption algorithm")
+
+	ErrUnknownKeyID = errors.New("value was encrypted with an unknown key")
+
+	ErrInvalidKeyRing = errors.New("invalid key ring")
+
+	ErrEmptyKeyRing = errors.New("key ring has no keys")
+
+	ErrEmptyPassphrase = errors.New("passphrase must not be empty")
+
+	ErrWrongPassphrase = errors.New("incorrect passphrase")
+
+	ErrUnsupportedKDF = errors.New("unsupported key derivation function")
+
+	ErrInvalidKDFParams = errors.New("invalid key derivation parameters")
 )
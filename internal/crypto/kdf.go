@@ -0,0 +1,124 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	kdfArgon2id = "argon2id"
+
+	defaultKDFTime    = 3
+	defaultKDFMemory  = 64 * 1024 // KiB
+	defaultKDFThreads = 4
+	kdfSaltLength     = 16
+
+	// Limits on stored parameters, so that a tampered database can neither
+	// crash argon2 nor force a huge allocation.
+	minKDFSaltLength = 8
+	maxKDFTime       = 64
+	maxKDFMemory     = 1024 * 1024 // KiB, 1 GiB
+	maxKDFThreads    = 64
+
+	// kdfCheckValue is encrypted with the derived key so that a wrong
+	// passphrase is reported as such instead of as a decryption failure.
+	kdfCheckValue = "veil-passphrase-check"
+)
+
+// KDFParams describes how the master key is derived from a passphrase.
+// It holds no secret material and is stored alongside the database.
+type KDFParams struct {
+	Algorithm string `json:"algorithm"`
+	Salt      string `json:"salt"`
+	Time      uint32 `json:"time"`
+	Memory    uint32 `json:"memory"`
+	Threads   uint8  `json:"threads"`
+	Check     string `json:"check,omitempty"`
+}
+
+// NewKDFParams returns Argon2id parameters with a fresh random salt.
+func NewKDFParams() (*KDFParams, error) {
+	salt := make([]byte, kdfSaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("could not generate salt: %w", err)
+	}
+	return &KDFParams{
+		Algorithm: kdfArgon2id,
+		Salt:      hex.EncodeToString(salt),
+		Time:      defaultKDFTime,
+		Memory:    defaultKDFMemory,
+		Threads:   defaultKDFThreads,
+	}, nil
+}
+
+// Validate checks that the parameters name a supported algorithm and are
+// within the limits veil accepts.
+func (p *KDFParams) Validate() error {
+	if p.Algorithm != kdfArgon2id {
+		return fmt.Errorf("%w: %s", ErrUnsupportedKDF, p.Algorithm)
+	}
+	salt, err := hex.DecodeString(p.Salt)
+	if err != nil {
+		return fmt.Errorf("%w: salt: %v", ErrInvalidKDFParams, err)
+	}
+	switch {
+	case len(salt) < minKDFSaltLength:
+		return fmt.Errorf("%w: salt of %d bytes, want at least %d", ErrInvalidKDFParams, len(salt), minKDFSaltLength)
+	case p.Time < 1 || p.Time > maxKDFTime:
+		return fmt.Errorf("%w: time %d, want 1 to %d", ErrInvalidKDFParams, p.Time, maxKDFTime)
+	case p.Threads < 1 || p.Threads > maxKDFThreads:
+		return fmt.Errorf("%w: threads %d, want 1 to %d", ErrInvalidKDFParams, p.Threads, maxKDFThreads)
+	case p.Memory < 8*uint32(p.Threads) || p.Memory > maxKDFMemory:
+		return fmt.Errorf("%w: memory %d KiB, want %d to %d", ErrInvalidKDFParams, p.Memory, 8*uint32(p.Threads), maxKDFMemory)
+	}
+	return nil
+}
+
+// DeriveKey derives a 32-byte key from the passphrase and returns it hex
+// encoded, in the same form as a MASTER_KEY.
+func (p *KDFParams) DeriveKey(passphrase string) (string, error) {
+	if passphrase == "" {
+		return "", ErrEmptyPassphrase
+	}
+	if err := p.Validate(); err != nil {
+		return "", err
+	}
+	salt, _ := hex.DecodeString(p.Salt)
+	key := argon2.IDKey([]byte(passphrase), salt, p.Time, p.Memory, p.Threads, 32)
+	return hex.EncodeToString(key), nil
+}
+
+// Seal records a check value encrypted with the engine so that later
+// derivations can verify the passphrase.
+func (p *KDFParams) Seal(e *Engine) error {
+	check, err := e.Encrypt(kdfCheckValue)
+	if err != nil {
+		return err
+	}
+	p.Check = check
+	return nil
+}
+
+// NewEngineFromPassphrase derives the master key from a passphrase and
+// returns an engine using it. ErrWrongPassphrase is returned when the
+// parameters carry a check value that the derived key cannot open.
+func NewEngineFromPassphrase(passphrase string, p *KDFParams) (*Engine, error) {
+	keyHex, err := p.DeriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	engine, err := NewEngine(keyHex)
+	if err != nil {
+		return nil, err
+	}
+	if p.Check != "" {
+		if check, err := engine.Decrypt(p.Check); err != nil || check != kdfCheckValue {
+			return nil, ErrWrongPassphrase
+		}
+	}
+	return engine, nil
+}
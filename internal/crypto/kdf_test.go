@@ -0,0 +1,124 @@
+package crypto
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKDFParams_DeriveKeyDeterministic(t *testing.T) {
+	params, err := NewKDFParams()
+	if err != nil {
+		t.Fatalf("NewKDFParams() error = %v", err)
+	}
+
+	key1, err := params.DeriveKey("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("DeriveKey() error = %v", err)
+	}
+	key2, err := params.DeriveKey("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("DeriveKey() error = %v", err)
+	}
+	if key1 != key2 {
+		t.Error("DeriveKey() should be deterministic for the same passphrase and salt")
+	}
+	if len(key1) != 64 {
+		t.Errorf("DeriveKey() returned %d hex characters, want 64", len(key1))
+	}
+
+	other, err := NewKDFParams()
+	if err != nil {
+		t.Fatalf("NewKDFParams() error = %v", err)
+	}
+	key3, err := other.DeriveKey("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("DeriveKey() error = %v", err)
+	}
+	if key1 == key3 {
+		t.Error("DeriveKey() should depend on the salt")
+	}
+}
+
+func TestNewEngineFromPassphrase_VerifiesCheck(t *testing.T) {
+	params, err := NewKDFParams()
+	if err != nil {
+		t.Fatalf("NewKDFParams() error = %v", err)
+	}
+
+	engine, err := NewEngineFromPassphrase("hunter2", params)
+	if err != nil {
+		t.Fatalf("NewEngineFromPassphrase() error = %v", err)
+	}
+	if err := params.Seal(engine); err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	encrypted, err := engine.Encrypt("secret")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	again, err := NewEngineFromPassphrase("hunter2", params)
+	if err != nil {
+		t.Fatalf("NewEngineFromPassphrase() with correct passphrase error = %v", err)
+	}
+	if got, err := again.Decrypt(encrypted); err != nil || got != "secret" {
+		t.Errorf("Decrypt() = %q, %v; want %q", got, err, "secret")
+	}
+
+	if _, err := NewEngineFromPassphrase("hunter3", params); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("NewEngineFromPassphrase() with wrong passphrase error = %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestKDFParams_Errors(t *testing.T) {
+	params, err := NewKDFParams()
+	if err != nil {
+		t.Fatalf("NewKDFParams() error = %v", err)
+	}
+
+	if _, err := params.DeriveKey(""); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Errorf("DeriveKey(\"\") error = %v, want ErrEmptyPassphrase", err)
+	}
+
+	params.Algorithm = "md5"
+	if _, err := params.DeriveKey("pass"); !errors.Is(err, ErrUnsupportedKDF) {
+		t.Errorf("DeriveKey() with unknown algorithm error = %v, want ErrUnsupportedKDF", err)
+	}
+}
+
+func TestKDFParams_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*KDFParams)
+	}{
+		{"zero time", func(p *KDFParams) { p.Time = 0 }},
+		{"huge time", func(p *KDFParams) { p.Time = 1 << 20 }},
+		{"zero threads", func(p *KDFParams) { p.Threads = 0 }},
+		{"too many threads", func(p *KDFParams) { p.Threads = 255 }},
+		{"too little memory", func(p *KDFParams) { p.Memory = 8 }},
+		{"huge memory", func(p *KDFParams) { p.Memory = 1 << 31 }},
+		{"short salt", func(p *KDFParams) { p.Salt = "abcd" }},
+		{"bad salt", func(p *KDFParams) { p.Salt = "not hex" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := NewKDFParams()
+			if err != nil {
+				t.Fatalf("NewKDFParams() error = %v", err)
+			}
+			if err := params.Validate(); err != nil {
+				t.Fatalf("Validate() of default parameters error = %v", err)
+			}
+
+			tt.modify(params)
+			if err := params.Validate(); !errors.Is(err, ErrInvalidKDFParams) {
+				t.Errorf("Validate() error = %v, want ErrInvalidKDFParams", err)
+			}
+			// Must fail cleanly rather than panic inside argon2.
+			if _, err := params.DeriveKey("pass"); err == nil {
+				t.Error("DeriveKey() succeeded with invalid parameters")
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package crypto
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxKeyIDLength keeps key IDs within the one-byte length field of the
+// envelope and short enough to read in listings.
+const maxKeyIDLength = 64
+
+// NamedKey is a master key and the ID recorded in envelopes it produces.
+// An empty ID defaults to the key's fingerprint.
+type NamedKey struct {
+	ID  string
+	Hex string
+}
+
+// ParseKeyList parses a MASTER_KEYS value: comma-separated "id:hexkey"
+// entries, or bare hex keys identified by their fingerprint. The first
+// entry is the primary key.
+func ParseKeyList(list string) ([]NamedKey, error) {
+	var keys []NamedKey
+	for entry := range strings.SplitSeq(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, keyHex, found := strings.Cut(entry, ":")
+		if !found {
+			id, keyHex = "", entry
+		}
+		keys = append(keys, NamedKey{ID: strings.TrimSpace(id), Hex: strings.TrimSpace(keyHex)})
+	}
+	if len(keys) == 0 {
+		return nil, ErrEmptyKeyRing
+	}
+	return keys, nil
+}
+
+// ParseKeyFile parses a key ring file with one "id=hexkey" entry per line.
+// Blank lines and lines starting with # are ignored. The first entry is
+// the primary key.
+func ParseKeyFile(r io.Reader) ([]NamedKey, error) {
+	var keys []NamedKey
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, keyHex, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: line %d: expected id=key", ErrInvalidKeyRing, lineNum)
+		}
+		keys = append(keys, NamedKey{ID: strings.TrimSpace(id), Hex: strings.TrimSpace(keyHex)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading key file: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, ErrEmptyKeyRing
+	}
+	return keys, nil
+}
+
+// NewKeyRing creates an engine holding several master keys. It encrypts
+// with the first key and decrypts with whichever key a value names. Every
+// key can also be found by its fingerprint, so values written while it was
+// the only MASTER_KEY keep decrypting after it joins a ring.
+func NewKeyRing(keys []NamedKey) (*Engine, error) {
+	if len(keys) == 0 {
+		return nil, ErrEmptyKeyRing
+	}
+
+	e := &Engine{keys: make(map[string][]byte, len(keys)*2)}
+	for i, nk := range keys {
+		key, err := decodeKey(nk.Hex)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i+1, err)
+		}
+
+		id := nk.ID
+		if id == "" {
+			id = Fingerprint(key)
+		}
+		if err := validateKeyID(id); err != nil {
+			return nil, err
+		}
+		if _, dup := e.keys[id]; dup {
+			return nil, fmt.Errorf("%w: duplicate key ID %q", ErrInvalidKeyRing, id)
+		}
+
+		e.keys[id] = key
+		e.order = append(e.order, id)
+		if i == 0 {
+			e.key, e.keyID = key, id
+		}
+	}
+
+	// Fingerprint aliases never shadow an explicit ID.
+	for _, id := range e.order {
+		key := e.keys[id]
+		if fp := Fingerprint(key); !e.HasKey(fp) {
+			e.keys[fp] = key
+		}
+	}
+
+	return e, nil
+}
+
+// KeyIDs returns the IDs of the keys in the ring, primary first.
+func (e *Engine) KeyIDs() []string {
+	return append([]string(nil), e.order...)
+}
+
+// HasKey reports whether the ring can decrypt values naming id.
+func (e *Engine) HasKey(id string) bool {
+	_, ok := e.keys[id]
+	return ok
+}
+
+// ResolveKeyID maps a key ID recorded in an envelope, which may be a
+// fingerprint alias, to the ID of the ring entry it names.
+func (e *Engine) ResolveKeyID(id string) (string, bool) {
+	for _, ringID := range e.order {
+		if id == ringID || id == Fingerprint(e.keys[ringID]) {
+			return ringID, true
+		}
+	}
+	return "", false
+}
+
+func validateKeyID(id string) error {
+	if len(id) > maxKeyIDLength {
+		return fmt.Errorf("%w: key ID %q is longer than %d characters", ErrInvalidKeyRing, id, maxKeyIDLength)
+	}
+	if strings.ContainsAny(id, " \t,:=#") {
+		return fmt.Errorf("%w: key ID %q contains whitespace or one of , : = #", ErrInvalidKeyRing, id)
+	}
+	return nil
+}
+
+func decodeKey(keyHex string) ([]byte, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFormat, err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w: expected 32 bytes, got %d", ErrInvalidKeyLength, len(key))
+	}
+	return key, nil
+}
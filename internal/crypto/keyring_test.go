@@ -0,0 +1,172 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	oldKeyHex = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	newKeyHex = "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+)
+
+func TestParseKeyList(t *testing.T) {
+	keys, err := ParseKeyList("2025:" + newKeyHex + ", " + oldKeyHex)
+	if err != nil {
+		t.Fatalf("ParseKeyList() error = %v", err)
+	}
+	want := []NamedKey{{ID: "2025", Hex: newKeyHex}, {ID: "", Hex: oldKeyHex}}
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] {
+		t.Errorf("ParseKeyList() = %+v, want %+v", keys, want)
+	}
+
+	if _, err := ParseKeyList(" , "); !errors.Is(err, ErrEmptyKeyRing) {
+		t.Errorf("ParseKeyList() of empty list error = %v, want ErrEmptyKeyRing", err)
+	}
+}
+
+func TestParseKeyFile(t *testing.T) {
+	file := "# rotated 2025-01\n\nnew = " + newKeyHex + "\nold=" + oldKeyHex + "\n"
+	keys, err := ParseKeyFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ParseKeyFile() error = %v", err)
+	}
+	if len(keys) != 2 || keys[0].ID != "new" || keys[1].ID != "old" || keys[1].Hex != oldKeyHex {
+		t.Errorf("ParseKeyFile() = %+v", keys)
+	}
+
+	_, err = ParseKeyFile(strings.NewReader("new " + newKeyHex))
+	if !errors.Is(err, ErrInvalidKeyRing) || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("ParseKeyFile() of malformed line error = %v, want ErrInvalidKeyRing at line 1", err)
+	}
+}
+
+func TestNewKeyRing_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []NamedKey
+		want error
+	}{
+		{"no keys", nil, ErrEmptyKeyRing},
+		{"bad key", []NamedKey{{ID: "a", Hex: "abc"}}, ErrInvalidKeyFormat},
+		{"duplicate ID", []NamedKey{{ID: "a", Hex: oldKeyHex}, {ID: "a", Hex: newKeyHex}}, ErrInvalidKeyRing},
+		{"ID with separator", []NamedKey{{ID: "a:b", Hex: oldKeyHex}}, ErrInvalidKeyRing},
+		{"ID too long", []NamedKey{{ID: strings.Repeat("k", 65), Hex: oldKeyHex}}, ErrInvalidKeyRing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewKeyRing(tt.keys); !errors.Is(err, tt.want) {
+				t.Errorf("NewKeyRing() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyRing_DecryptsWithAnyKey(t *testing.T) {
+	oldEngine, _ := NewEngine(oldKeyHex)
+	fromOld, _ := oldEngine.EncryptWithAAD("before", []byte("aad"))
+	legacy := sealHex(t, oldEngine, "ancient", nil)
+
+	ring, err := NewKeyRing([]NamedKey{{ID: "new", Hex: newKeyHex}, {Hex: oldKeyHex}})
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	if ring.KeyID() != "new" {
+		t.Errorf("KeyID() = %q, want the primary key's ID", ring.KeyID())
+	}
+	if ids := ring.KeyIDs(); len(ids) != 2 || ids[1] != oldEngine.KeyID() {
+		t.Errorf("KeyIDs() = %v, want [new %s]", ids, oldEngine.KeyID())
+	}
+
+	fromNew, _ := ring.EncryptWithAAD("after", []byte("aad"))
+	if id, _ := KeyIDOf(fromNew); id != "new" {
+		t.Errorf("ring encrypted with key %q, want primary", id)
+	}
+
+	for value, want := range map[string]string{fromOld: "before", fromNew: "after", legacy: "ancient"} {
+		var aad []byte
+		if !IsLegacy(value) {
+			aad = []byte("aad")
+		}
+		if got, err := ring.DecryptWithAAD(value, aad); err != nil || got != want {
+			t.Errorf("DecryptWithAAD() = %q, %v; want %q", got, err, want)
+		}
+	}
+
+	if !ring.NeedsUpgrade(fromOld) || ring.NeedsUpgrade(fromNew) {
+		t.Error("NeedsUpgrade() should select values not under the primary key")
+	}
+
+	if _, err := oldEngine.Decrypt(fromNew); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("Decrypt() with a key missing from the ring error = %v, want ErrUnknownKeyID", err)
+	}
+}
+
+func TestKeyRing_FingerprintAlias(t *testing.T) {
+	// Values written with a lone MASTER_KEY name its fingerprint; they must
+	// still decrypt once the key is given an explicit ID in a ring.
+	single, _ := NewEngine(oldKeyHex)
+	encrypted, _ := single.Encrypt("value")
+
+	ring, err := NewKeyRing([]NamedKey{{ID: "new", Hex: newKeyHex}, {ID: "old", Hex: oldKeyHex}})
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	if !ring.HasKey(single.KeyID()) {
+		t.Errorf("HasKey(%q) = false, want fingerprint alias", single.KeyID())
+	}
+	if id, ok := ring.ResolveKeyID(single.KeyID()); !ok || id != "old" {
+		t.Errorf("ResolveKeyID(%q) = %q, %v; want %q", single.KeyID(), id, ok, "old")
+	}
+	if got, err := ring.Decrypt(encrypted); err != nil || got != "value" {
+		t.Errorf("Decrypt() = %q, %v; want %q", got, err, "value")
+	}
+}
+
+func TestKeyRing_AliasOfPrimaryNeedsNoUpgrade(t *testing.T) {
+	single, _ := NewEngine(oldKeyHex)
+	encrypted, _ := single.Encrypt("value")
+
+	ring, err := NewKeyRing([]NamedKey{{ID: "old", Hex: oldKeyHex}, {ID: "new", Hex: newKeyHex}})
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	if ring.NeedsUpgrade(encrypted) {
+		t.Error("NeedsUpgrade() = true for a value under the primary key's fingerprint alias")
+	}
+
+	demoted, err := NewKeyRing([]NamedKey{{ID: "new", Hex: newKeyHex}, {ID: "old", Hex: oldKeyHex}})
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	if !demoted.NeedsUpgrade(encrypted) {
+		t.Error("NeedsUpgrade() = false for a value under a secondary key's alias")
+	}
+}
+
+func TestEnvelope_HeaderIsAuthenticated(t *testing.T) {
+	// "old" and its fingerprint name the same key, so swapping one for the
+	// other in the header would still decrypt if the header were not
+	// authenticated.
+	ring, err := NewKeyRing([]NamedKey{{ID: "old", Hex: oldKeyHex}})
+	if err != nil {
+		t.Fatalf("NewKeyRing() error = %v", err)
+	}
+	encrypted, err := ring.EncryptWithAAD("value", []byte("prod/KEY"))
+	if err != nil {
+		t.Fatalf("EncryptWithAAD() error = %v", err)
+	}
+
+	env, err := parseEnvelope(encrypted)
+	if err != nil {
+		t.Fatalf("parseEnvelope() error = %v", err)
+	}
+	key, _ := hex.DecodeString(oldKeyHex)
+	env.keyID = Fingerprint(key)
+	if _, err := ring.DecryptWithAAD(env.encode(), []byte("prod/KEY")); !errors.Is(err, ErrDecryptionFailed) {
+		t.Errorf("DecryptWithAAD() of an edited header error = %v, want ErrDecryptionFailed", err)
+	}
+}
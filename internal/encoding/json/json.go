@@ -0,0 +1,58 @@
+// Package json reads and writes secrets as a flat JSON object.
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Parse reads a JSON object of secrets, which must be the only value in
+// data. Numbers and booleans are kept in
+// their textual form; nested objects, arrays and nulls are rejected.
+func Parse(data []byte) (map[string]string, error) {
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	// Anything after the object, such as a second object, would otherwise
+	// be dropped without notice.
+	if err := dec.Decode(new(any)); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid JSON: unexpected data after the object")
+	}
+
+	result := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			result[key] = v
+		case stdjson.Number:
+			result[key] = v.String()
+		case bool:
+			result[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("key %q: only strings, numbers and booleans are supported", key)
+		}
+	}
+
+	return result, nil
+}
+
+// Marshal writes secrets as an indented JSON object with sorted keys.
+func Marshal(secrets map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := stdjson.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(secrets); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package json
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestMarshalParse_RoundTrip(t *testing.T) {
+	secrets := map[string]string{
+		"DATABASE_URL": "postgres://u:p@h/db?x=<1>&y=2",
+		"MULTILINE":    "line one\nline two",
+		"EMPTY":        "",
+		"QUOTES":       `say "hi"`,
+	}
+
+	data, err := Marshal(secrets)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if !maps.Equal(parsed, secrets) {
+		t.Errorf("round trip = %v, want %v", parsed, secrets)
+	}
+}
+
+func TestParse_Scalars(t *testing.T) {
+	parsed, err := Parse([]byte(`{"PORT": 8080, "RATIO": 0.50, "DEBUG": true, "NAME": "x"}`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	want := map[string]string{"PORT": "8080", "RATIO": "0.50", "DEBUG": "true", "NAME": "x"}
+	if !maps.Equal(parsed, want) {
+		t.Errorf("Parse = %v, want %v", parsed, want)
+	}
+}
+
+func TestParse_RejectsNestedValues(t *testing.T) {
+	for _, input := range []string{
+		`{"A": {"B": "c"}}`,
+		`{"A": [1, 2]}`,
+		`{"A": null}`,
+		`["A"]`,
+		`not json`,
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", input)
+		}
+	}
+}
+
+func TestParse_RejectsTrailingData(t *testing.T) {
+	for _, input := range []string{
+		`{"A": "1"}{"B": "2"}`,
+		`{"A": "1"} garbage`,
+		`{"A": "1"} "B"`,
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want error", input)
+		}
+	}
+	if _, err := Parse([]byte("{\"A\": \"1\"}\n\n")); err != nil {
+		t.Errorf("Parse with trailing whitespace error: %v", err)
+	}
+}
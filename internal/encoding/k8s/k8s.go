@@ -0,0 +1,139 @@
+// Package k8s reads and writes secrets as a Kubernetes v1 Secret manifest.
+package k8s
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Options describes the Secret written by Marshal.
+type Options struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+	// Type is the Secret type, Opaque if empty.
+	Type string
+	// StringData writes values as plain text under stringData instead of
+	// base64 encoded under data.
+	StringData bool
+}
+
+// Secret is the subset of a Kubernetes Secret veil reads and writes.
+type Secret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   Metadata          `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+// Metadata is the object metadata of a Secret.
+type Metadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+var (
+	// nameFormat is a DNS subdomain name, which Kubernetes requires for
+	// Secret names.
+	nameFormat = regexp.MustCompile(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`)
+	// keyFormat is the set of keys Kubernetes accepts in a Secret.
+	keyFormat = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// ParseSecret reads a Secret manifest, in YAML or JSON.
+func ParseSecret(data []byte) (*Secret, error) {
+	var s Secret
+	if err := yamlv3.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if s.Kind != "Secret" {
+		return nil, fmt.Errorf("invalid manifest: kind is %q, want Secret", s.Kind)
+	}
+	return &s, nil
+}
+
+// Parse reads the values of a Secret manifest, decoding data and letting
+// stringData override it as the API server does.
+func Parse(data []byte) (map[string]string, error) {
+	s, err := ParseSecret(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(s.Data)+len(s.StringData))
+	for key, value := range s.Data {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid base64: %w", key, err)
+		}
+		result[key] = string(decoded)
+	}
+	for key, value := range s.StringData {
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+// Marshal writes secrets as a Secret manifest in YAML, of type Opaque
+// unless opts says otherwise.
+func Marshal(secrets map[string]string, opts Options) ([]byte, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("a Secret name is required")
+	}
+	if !nameFormat.MatchString(opts.Name) || len(opts.Name) > 253 {
+		return nil, fmt.Errorf("invalid Secret name %q (use lowercase letters, digits, '-' and '.')", opts.Name)
+	}
+
+	s := Secret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: Metadata{
+			Name:        opts.Name,
+			Namespace:   opts.Namespace,
+			Labels:      opts.Labels,
+			Annotations: opts.Annotations,
+		},
+		Type: opts.Type,
+	}
+	if s.Type == "" {
+		s.Type = "Opaque"
+	}
+
+	values := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		if !keyFormat.MatchString(key) {
+			return nil, fmt.Errorf("key %q is not a valid Secret key (use letters, digits, '-', '_' and '.')", key)
+		}
+		if opts.StringData {
+			values[key] = value
+		} else {
+			values[key] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+	}
+	if opts.StringData {
+		s.StringData = values
+	} else {
+		s.Data = values
+	}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
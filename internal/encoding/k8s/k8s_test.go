@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestMarshalParse_RoundTrip(t *testing.T) {
+	secrets := map[string]string{
+		"DATABASE_URL": "postgres://u:p@h/db",
+		"tls.crt":      "-----BEGIN CERTIFICATE-----\nabc\n",
+		"EMPTY":        "",
+	}
+
+	for _, stringData := range []bool{false, true} {
+		data, err := Marshal(secrets, Options{Name: "app", Namespace: "web", Labels: map[string]string{"team": "payments"}, StringData: stringData})
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+
+		s, err := ParseSecret(data)
+		if err != nil {
+			t.Fatalf("ParseSecret error: %v\n%s", err, data)
+		}
+		if s.APIVersion != "v1" || s.Metadata.Name != "app" || s.Metadata.Namespace != "web" || s.Metadata.Labels["team"] != "payments" {
+			t.Errorf("manifest = %+v", s)
+		}
+		if stringData && len(s.Data) != 0 || !stringData && len(s.StringData) != 0 {
+			t.Errorf("stringData=%v wrote data=%v stringData=%v", stringData, s.Data, s.StringData)
+		}
+
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		if !maps.Equal(parsed, secrets) {
+			t.Errorf("round trip = %v, want %v", parsed, secrets)
+		}
+	}
+}
+
+func TestMarshal_EncodesBase64(t *testing.T) {
+	data, err := Marshal(map[string]string{"PASSWORD": "hunter2"}, Options{Name: "db"})
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if !strings.Contains(string(data), "PASSWORD: aHVudGVyMg==") {
+		t.Errorf("manifest does not hold the base64 value:\n%s", data)
+	}
+}
+
+func TestMarshal_Type(t *testing.T) {
+	for _, tt := range []struct{ typ, want string }{
+		{"", "type: Opaque"},
+		{"kubernetes.io/tls", "type: kubernetes.io/tls"},
+	} {
+		data, err := Marshal(map[string]string{"tls.crt": "cert"}, Options{Name: "tls", Type: tt.typ})
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("manifest with type %q does not hold %q:\n%s", tt.typ, tt.want, data)
+		}
+	}
+}
+
+func TestMarshal_Validation(t *testing.T) {
+	for _, name := range []string{"", "Production", "my_app", "-app"} {
+		if _, err := Marshal(nil, Options{Name: name}); err == nil {
+			t.Errorf("Marshal with name %q succeeded, want error", name)
+		}
+	}
+	if _, err := Marshal(map[string]string{"BAD KEY": "x"}, Options{Name: "app"}); err == nil {
+		t.Error("Marshal with an invalid key succeeded, want error")
+	}
+}
+
+func TestParse_StringDataOverridesData(t *testing.T) {
+	manifest := `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  A: YQ==
+  B: Yg==
+stringData:
+  B: override
+`
+	parsed, err := Parse([]byte(manifest))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if want := map[string]string{"A": "a", "B": "override"}; !maps.Equal(parsed, want) {
+		t.Errorf("Parse = %v, want %v", parsed, want)
+	}
+
+	if _, err := Parse([]byte("apiVersion: v1\nkind: ConfigMap\n")); err == nil {
+		t.Error("Parse of a ConfigMap succeeded, want error")
+	}
+	if _, err := Parse([]byte("kind: Secret\ndata:\n  A: not-base64!\n")); err == nil {
+		t.Error("Parse with invalid base64 succeeded, want error")
+	}
+}
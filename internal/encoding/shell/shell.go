@@ -0,0 +1,91 @@
+// Package shell writes secrets as statements that set environment
+// variables when evaluated by a shell.
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Shells lists the supported shells. zsh and sh share bash's syntax and
+// pwsh is PowerShell.
+var Shells = []string{"bash", "zsh", "sh", "fish", "powershell", "pwsh"}
+
+var nameFormat = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidName reports whether name can be used as an environment variable
+// in every supported shell.
+func ValidName(name string) bool {
+	return nameFormat.MatchString(name)
+}
+
+// Marshal writes one statement per secret, sorted by name, setting it in
+// the environment of shell. Values are quoted so that any value,
+// including newlines and quotes, is set exactly.
+func Marshal(shell string, secrets map[string]string) ([]byte, error) {
+	format, ok := formats[shell]
+	if !ok {
+		return nil, fmt.Errorf("unsupported shell %q (use %s)", shell, strings.Join(Shells, ", "))
+	}
+
+	var buf bytes.Buffer
+	for _, name := range slices.Sorted(maps.Keys(secrets)) {
+		value := secrets[name]
+		if !ValidName(name) {
+			return nil, fmt.Errorf("%q is not a valid environment variable name", name)
+		}
+		if strings.IndexByte(value, 0) >= 0 {
+			return nil, fmt.Errorf("%s: value contains a NUL byte", name)
+		}
+		buf.WriteString(format(name, value))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+var formats = map[string]func(name, value string) string{
+	"bash":       posix,
+	"zsh":        posix,
+	"sh":         posix,
+	"fish":       fish,
+	"powershell": powershell,
+	"pwsh":       powershell,
+}
+
+func posix(name, value string) string {
+	return "export " + name + "=" + QuotePOSIX(value)
+}
+
+// QuotePOSIX single-quotes value for sh, bash and zsh. Nothing is special
+// inside single quotes but the closing quote, so embedded quotes are
+// written as
+//
+//	'\''
+func QuotePOSIX(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// fish single-quotes the value, in which only \' and \\ are escapes.
+func fish(name, value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "'", `\'`)
+	return "set -gx " + name + " '" + value + "'"
+}
+
+// powershellQuotes are the characters PowerShell accepts as single quotes;
+// inside a single-quoted string each is escaped by doubling it.
+var powershellQuotes = strings.NewReplacer(
+	"'", "''",
+	"‘", "‘‘",
+	"’", "’’",
+	"‚", "‚‚",
+	"‛", "‛‛",
+)
+
+func powershell(name, value string) string {
+	return "$env:" + name + " = '" + powershellQuotes.Replace(value) + "'"
+}
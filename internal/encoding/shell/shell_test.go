@@ -0,0 +1,88 @@
+package shell
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var tricky = map[string]string{
+	"SIMPLE":   "value",
+	"QUOTES":   `it's "quoted" \ back\slash`,
+	"NEWLINES": "line one\nline two\n",
+	"DOLLAR":   "$HOME `id` $(id) ${X}",
+	"EMPTY":    "",
+}
+
+func TestMarshal_Formats(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{"bash", `export A='it'\''s'`},
+		{"fish", `set -gx A 'it\'s \\n'`},
+		{"powershell", `$env:A = 'it''s ’’'`},
+	}
+	values := map[string]string{"bash": "it's", "fish": `it's \n`, "powershell": "it's ’"}
+
+	for _, tt := range tests {
+		out, err := Marshal(tt.shell, map[string]string{"A": values[tt.shell]})
+		if err != nil {
+			t.Fatalf("Marshal(%s) error: %v", tt.shell, err)
+		}
+		if got := strings.TrimSuffix(string(out), "\n"); got != tt.want {
+			t.Errorf("Marshal(%s) = %s, want %s", tt.shell, got, tt.want)
+		}
+	}
+}
+
+func TestMarshal_Rejects(t *testing.T) {
+	if _, err := Marshal("tcsh", map[string]string{"A": "x"}); err == nil {
+		t.Error("Marshal with an unsupported shell succeeded")
+	}
+	if _, err := Marshal("bash", map[string]string{"tls.crt": "x"}); err == nil {
+		t.Error("Marshal with an invalid name succeeded")
+	}
+	if _, err := Marshal("bash", map[string]string{"A": "x\x00y"}); err == nil {
+		t.Error("Marshal with a NUL byte succeeded")
+	}
+}
+
+// TestMarshal_Evaluates checks the output with the real shells that are
+// installed: every value must come back exactly.
+func TestMarshal_Evaluates(t *testing.T) {
+	shells := map[string][]string{
+		"sh":   {"sh", "-c"},
+		"bash": {"bash", "-c"},
+		"zsh":  {"zsh", "-c"},
+		"fish": {"fish", "-c"},
+		"pwsh": {"pwsh", "-NoProfile", "-Command"},
+	}
+
+	for name, argv := range shells {
+		if _, err := exec.LookPath(argv[0]); err != nil {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			out, err := Marshal(name, tricky)
+			if err != nil {
+				t.Fatalf("Marshal error: %v", err)
+			}
+			for key, want := range tricky {
+				var script string
+				if name == "pwsh" {
+					script = string(out) + "[Console]::Out.Write($env:" + key + ")"
+				} else {
+					script = string(out) + "printf '%s' \"$" + key + "\""
+				}
+				got, err := exec.Command(argv[0], append(argv[1:], script)...).Output()
+				if err != nil {
+					t.Fatalf("%s error: %v", name, err)
+				}
+				if string(got) != want {
+					t.Errorf("%s %s = %q, want %q", name, key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Package yaml reads and writes secrets as a flat YAML mapping.
+package yaml
+
+import (
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Parse reads a YAML mapping of secrets. Scalars are kept in their
+// textual form, so 0755 stays 0755; nested mappings, sequences and nulls
+// are rejected. An empty document holds no secrets.
+func Parse(data []byte) (map[string]string, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+
+	result := make(map[string]string)
+	if len(doc.Content) == 0 {
+		return result, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("invalid YAML: expected a mapping of names to values")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if value.Kind != yamlv3.ScalarNode || value.Tag == "!!null" {
+			return nil, fmt.Errorf("key %q: only strings, numbers and booleans are supported", key.Value)
+		}
+		result[key.Value] = value.Value
+	}
+
+	return result, nil
+}
+
+// Marshal writes secrets as a YAML mapping with sorted keys. Values that
+// would read back as another type, such as "true" or "0755", are quoted.
+func Marshal(secrets map[string]string) ([]byte, error) {
+	return yamlv3.Marshal(secrets)
+}
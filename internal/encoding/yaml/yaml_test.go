@@ -0,0 +1,58 @@
+package yaml
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestMarshalParse_RoundTrip(t *testing.T) {
+	secrets := map[string]string{
+		"DATABASE_URL": "postgres://u:p@h/db",
+		"MULTILINE":    "line one\nline two\n",
+		"EMPTY":        "",
+		"BOOLISH":      "true",
+		"OCTAL":        "0755",
+		"NULLISH":      "null",
+		"COLON":        "a: b",
+	}
+
+	data, err := Marshal(secrets)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if !maps.Equal(parsed, secrets) {
+		t.Errorf("round trip = %v, want %v\n%s", parsed, secrets, data)
+	}
+}
+
+func TestParse_Scalars(t *testing.T) {
+	parsed, err := Parse([]byte("PORT: 8080\nMODE: 0755\nDEBUG: yes\nNAME: 'x'\n"))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	want := map[string]string{"PORT": "8080", "MODE": "0755", "DEBUG": "yes", "NAME": "x"}
+	if !maps.Equal(parsed, want) {
+		t.Errorf("Parse = %v, want %v", parsed, want)
+	}
+
+	if parsed, err := Parse(nil); err != nil || len(parsed) != 0 {
+		t.Errorf("Parse(empty) = %v, %v", parsed, err)
+	}
+}
+
+func TestParse_RejectsNestedValues(t *testing.T) {
+	for _, input := range []string{
+		"A:\n  B: c\n",
+		"A: [1, 2]\n",
+		"A: ~\n",
+		"- A\n",
+	} {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+	}
+}
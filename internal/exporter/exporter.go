@@ -3,10 +3,15 @@ package exporter
 import (
 	"errors"
 	"fmt"
+
+	"github.com/ossydotpy/veil/internal/encoding/k8s"
 )
 
 var formats = map[string]Exporter{
-	"env": &EnvExporter{},
+	"env":  &EnvExporter{},
+	"json": NewJSONExporter(),
+	"yaml": NewYAMLExporter(),
+	"k8s":  NewK8sExporter(),
 }
 
 var ErrUnsupportedFormat = errors.New("unsupported export format")
@@ -38,6 +43,9 @@ type ExportOptions struct {
 	Exclude    []string
 	DryRun     bool
 	Format     string
+	Raw        bool
+	// Manifest describes the Secret written by the k8s format.
+	Manifest k8s.Options
 }
 
 type Preview struct {
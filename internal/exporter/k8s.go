@@ -0,0 +1,88 @@
+package exporter
+
+import (
+	"fmt"
+	"maps"
+	"os"
+
+	"github.com/ossydotpy/veil/internal/encoding/k8s"
+	"github.com/ossydotpy/veil/internal/fsutil"
+)
+
+// K8sExporter writes secrets as a Kubernetes Secret manifest. Appending
+// merges the secrets into an existing manifest and keeps its metadata and
+// type unless the options override them.
+type K8sExporter struct {
+	StructuredExporter
+}
+
+// NewK8sExporter returns an exporter writing a v1 Secret manifest.
+func NewK8sExporter() *K8sExporter {
+	return &K8sExporter{StructuredExporter{
+		format: "k8s",
+		parse:  k8s.Parse,
+		marshal: func(secrets map[string]string, opts ExportOptions) ([]byte, error) {
+			return k8s.Marshal(secrets, opts.Manifest)
+		},
+	}}
+}
+
+func (e *K8sExporter) Export(secrets map[string]string, opts ExportOptions) error {
+	opts, err := e.withExistingMetadata(opts)
+	if err != nil {
+		return err
+	}
+	return e.StructuredExporter.Export(secrets, opts)
+}
+
+func (e *K8sExporter) Preview(secrets map[string]string, opts ExportOptions) (*Preview, error) {
+	opts, err := e.withExistingMetadata(opts)
+	if err != nil {
+		return nil, err
+	}
+	return e.StructuredExporter.Preview(secrets, opts)
+}
+
+// withExistingMetadata fills in the metadata of the manifest being
+// appended to where opts leaves it unset. Labels and annotations are
+// merged, those in opts winning.
+func (e *K8sExporter) withExistingMetadata(opts ExportOptions) (ExportOptions, error) {
+	if !opts.Append || !fsutil.FileExists(opts.TargetPath) {
+		return opts, nil
+	}
+
+	data, err := os.ReadFile(opts.TargetPath)
+	if err != nil {
+		return opts, fmt.Errorf("failed to read %s: %w", opts.TargetPath, err)
+	}
+	existing, err := k8s.ParseSecret(data)
+	if err != nil {
+		return opts, fmt.Errorf("%s: %w", opts.TargetPath, err)
+	}
+
+	m := &opts.Manifest
+	if m.Name == "" {
+		m.Name = existing.Metadata.Name
+	}
+	if m.Namespace == "" {
+		m.Namespace = existing.Metadata.Namespace
+	}
+	if m.Type == "" {
+		m.Type = existing.Type
+	}
+	m.Labels = mergeMetadata(existing.Metadata.Labels, m.Labels)
+	m.Annotations = mergeMetadata(existing.Metadata.Annotations, m.Annotations)
+	if len(existing.StringData) > 0 && len(existing.Data) == 0 {
+		m.StringData = true
+	}
+	return opts, nil
+}
+
+func mergeMetadata(existing, override map[string]string) map[string]string {
+	if len(existing) == 0 {
+		return override
+	}
+	merged := maps.Clone(existing)
+	maps.Copy(merged, override)
+	return merged
+}
@@ -0,0 +1,103 @@
+package exporter
+
+import (
+	"fmt"
+	"maps"
+	"os"
+
+	"github.com/ossydotpy/veil/internal/encoding/json"
+	"github.com/ossydotpy/veil/internal/encoding/yaml"
+	"github.com/ossydotpy/veil/internal/filter"
+	"github.com/ossydotpy/veil/internal/fsutil"
+)
+
+// StructuredExporter writes secrets as a single document, such as a JSON
+// object. Appending merges the secrets into the keys already in the file.
+type StructuredExporter struct {
+	format  string
+	parse   func([]byte) (map[string]string, error)
+	marshal func(map[string]string, ExportOptions) ([]byte, error)
+}
+
+// NewJSONExporter returns an exporter writing a flat JSON object.
+func NewJSONExporter() *StructuredExporter {
+	return &StructuredExporter{format: "json", parse: json.Parse, marshal: ignoreOptions(json.Marshal)}
+}
+
+// NewYAMLExporter returns an exporter writing a flat YAML mapping.
+func NewYAMLExporter() *StructuredExporter {
+	return &StructuredExporter{format: "yaml", parse: yaml.Parse, marshal: ignoreOptions(yaml.Marshal)}
+}
+
+func ignoreOptions(marshal func(map[string]string) ([]byte, error)) func(map[string]string, ExportOptions) ([]byte, error) {
+	return func(secrets map[string]string, _ ExportOptions) ([]byte, error) {
+		return marshal(secrets)
+	}
+}
+
+func (e *StructuredExporter) Format() string {
+	return e.format
+}
+
+func (e *StructuredExporter) Export(secrets map[string]string, opts ExportOptions) error {
+	if !opts.Append && !opts.Force && fsutil.FileExists(opts.TargetPath) {
+		return fmt.Errorf("file %s already exists (use --force to overwrite or --append to add to it)", opts.TargetPath)
+	}
+
+	preview, err := e.Preview(secrets, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		return nil
+	}
+
+	if opts.Append && len(preview.NewKeys) == 0 && len(preview.UpdatedKeys) == 0 {
+		return nil
+	}
+
+	return fsutil.SafeWriteFile(opts.TargetPath, []byte(preview.Content), 0600, opts.Backup, opts.BackupDir)
+}
+
+func (e *StructuredExporter) Preview(secrets map[string]string, opts ExportOptions) (*Preview, error) {
+	preview := &Preview{
+		NewKeys:     make([]string, 0),
+		UpdatedKeys: make([]string, 0),
+		SkippedKeys: make([]string, 0),
+	}
+
+	merged := make(map[string]string)
+	if opts.Append && fsutil.FileExists(opts.TargetPath) {
+		data, err := os.ReadFile(opts.TargetPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", opts.TargetPath, err)
+		}
+		existing, err := e.parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", opts.TargetPath, err)
+		}
+		maps.Copy(merged, existing)
+	}
+
+	for _, key := range filter.SortKeys(secrets) {
+		_, exists := merged[key]
+		switch {
+		case !exists:
+			preview.NewKeys = append(preview.NewKeys, key)
+		case opts.Force:
+			preview.UpdatedKeys = append(preview.UpdatedKeys, key)
+		default:
+			preview.SkippedKeys = append(preview.SkippedKeys, key)
+			continue
+		}
+		merged[key] = secrets[key]
+	}
+
+	content, err := e.marshal(merged, opts)
+	if err != nil {
+		return nil, err
+	}
+	preview.Content = string(content)
+	return preview, nil
+}
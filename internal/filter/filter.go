@@ -3,6 +3,7 @@ package filter
 import (
 	"maps"
 	"slices"
+	"strings"
 )
 
 func FilterSecrets(secrets map[string]string, include []string, exclude []string) map[string]string {
@@ -57,6 +58,19 @@ func MatchPattern(key, pattern string) bool {
 	return false
 }
 
+// FilterEnv returns the entries of env, in "KEY=value" form, whose key
+// matches the include and exclude patterns.
+func FilterEnv(env []string, include []string, exclude []string) []string {
+	var result []string
+	for _, entry := range env {
+		key, _, _ := strings.Cut(entry, "=")
+		if MatchesFilters(key, include, exclude) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
 func SortKeys(m map[string]string) []string {
 	return slices.Sorted(maps.Keys(m))
 }
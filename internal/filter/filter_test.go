@@ -1,6 +1,7 @@
 package filter
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -137,3 +138,19 @@ func TestFilterSecrets_IncludeAndExcludeConflict(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterEnv(t *testing.T) {
+	env := []string{"PATH=/bin", "MASTER_KEY=abc", "VEIL_DB_PATH=/tmp/db", "AWS_REGION=eu-west-1", "EMPTY="}
+
+	got := FilterEnv(env, nil, []string{"MASTER_KEY", "VEIL_*"})
+	want := []string{"PATH=/bin", "AWS_REGION=eu-west-1", "EMPTY="}
+	if !slices.Equal(got, want) {
+		t.Errorf("FilterEnv exclude = %v, want %v", got, want)
+	}
+
+	got = FilterEnv(env, []string{"PATH", "AWS_*"}, nil)
+	want = []string{"PATH=/bin", "AWS_REGION=eu-west-1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("FilterEnv include = %v, want %v", got, want)
+	}
+}
@@ -6,7 +6,10 @@ import (
 )
 
 var importers = map[string]Importer{
-	"env": &EnvImporter{},
+	"env":  &EnvImporter{},
+	"json": NewJSONImporter(),
+	"yaml": NewYAMLImporter(),
+	"k8s":  NewK8sImporter(),
 }
 
 var ErrUnsupportedFormat = errors.New("unsupported import format")
@@ -0,0 +1,9 @@
+package importer
+
+import "github.com/ossydotpy/veil/internal/encoding/k8s"
+
+// NewK8sImporter returns an importer reading the data and stringData of a
+// Kubernetes Secret manifest.
+func NewK8sImporter() *StructuredImporter {
+	return &StructuredImporter{format: "k8s", parse: k8s.Parse}
+}
@@ -0,0 +1,45 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ossydotpy/veil/internal/encoding/json"
+	"github.com/ossydotpy/veil/internal/encoding/yaml"
+	"github.com/ossydotpy/veil/internal/filter"
+)
+
+// StructuredImporter reads secrets from a single document, such as a JSON
+// object of names to values.
+type StructuredImporter struct {
+	format string
+	parse  func([]byte) (map[string]string, error)
+}
+
+// NewJSONImporter returns an importer reading a flat JSON object.
+func NewJSONImporter() *StructuredImporter {
+	return &StructuredImporter{format: "json", parse: json.Parse}
+}
+
+// NewYAMLImporter returns an importer reading a flat YAML mapping.
+func NewYAMLImporter() *StructuredImporter {
+	return &StructuredImporter{format: "yaml", parse: yaml.Parse}
+}
+
+func (i *StructuredImporter) Format() string {
+	return i.format
+}
+
+func (i *StructuredImporter) Import(opts ImportOptions) (map[string]string, error) {
+	data, err := os.ReadFile(opts.SourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", opts.SourcePath, err)
+	}
+
+	secrets, err := i.parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", opts.SourcePath, err)
+	}
+
+	return filter.FilterSecrets(secrets, opts.Include, opts.Exclude), nil
+}
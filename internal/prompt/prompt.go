@@ -0,0 +1,89 @@
+// Package prompt reads passphrases from files, pipes or the terminal.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/ossydotpy/veil/internal/config"
+)
+
+var ErrPassphraseMismatch = errors.New("passphrases do not match")
+
+// Passphrase reads a passphrase. If path is set the passphrase is read from
+// that file ("-" means stdin), which like a key file must not be readable by
+// other users. Otherwise the user is prompted on the
+// terminal with echo disabled, or, when stdin is not a terminal, the first
+// line of stdin is used. With confirm set, terminal users must type the
+// passphrase twice.
+func Passphrase(path string, stdin io.Reader, stderr io.Writer, confirm bool) (string, error) {
+	switch {
+	case path == "-":
+		return readLine(stdin)
+	case path != "":
+		data, err := config.ReadKeyFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase file: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	f, ok := stdin.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return readLine(stdin)
+	}
+
+	passphrase, err := readHidden(f, stderr, "Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := readHidden(f, stderr, "Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", ErrPassphraseMismatch
+		}
+	}
+	return passphrase, nil
+}
+
+func readHidden(f *os.File, stderr io.Writer, label string) (string, error) {
+	fmt.Fprint(stderr, label)
+	data, err := term.ReadPassword(int(f.Fd()))
+	fmt.Fprintln(stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(data), nil
+}
+
+// readLine reads one line from r a byte at a time, so that nothing after
+// it is consumed: r is usually stdin, which later prompts and the children
+// of 'veil run' still read from.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
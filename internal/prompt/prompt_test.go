@@ -0,0 +1,23 @@
+package prompt
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadLine_LeavesTheRestUnread(t *testing.T) {
+	r := strings.NewReader("first\r\nsecond\nrest")
+
+	for _, want := range []string{"first", "second"} {
+		if got, err := readLine(r); err != nil || got != want {
+			t.Errorf("readLine() = %q, %v; want %q", got, err, want)
+		}
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "rest" {
+		t.Errorf("unread input = %q, want %q", rest, "rest")
+	}
+	if got, err := readLine(r); err != nil || got != "" {
+		t.Errorf("readLine() at EOF = %q, %v; want empty", got, err)
+	}
+}
@@ -0,0 +1,623 @@
+// Package blind provides a store decorator that keeps vault and secret
+// names encrypted at rest.
+//
+// Names are replaced by deterministic tokens: the nonce of an AES-256-GCM
+// encryption of the name is an HMAC-SHA256 of the name, so the same name
+// always yields the same token. Tokens can therefore be looked up with an
+// equality match in the underlying store and decrypted again for listing.
+// A secret name's token also depends on its vault, so the same name in two
+// vaults cannot be linked.
+//
+// Metadata is sealed as a whole with the same key and a random nonce,
+// bound to the secret's vault and name, and kept in the description
+// column; only the creation and update times stay readable.
+package blind
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+// KeySize is the size of a name key: 32 bytes of AES key followed by 32
+// bytes of HMAC key.
+const KeySize = 64
+
+var (
+	ErrInvalidKey = errors.New("invalid name key")
+
+	ErrInvalidToken = errors.New("invalid name token")
+
+	ErrInvalidMetadata = errors.New("invalid sealed metadata")
+)
+
+// sealedPrefix marks a description holding sealed metadata. Descriptions
+// without it were written before metadata was sealed.
+const sealedPrefix = "sealed:"
+
+var tokenEncoding = base64.RawURLEncoding
+
+// Store encrypts vault and secret names before passing them to the
+// wrapped store and decrypts them on the way back.
+type Store struct {
+	inner store.Store
+	aead  cipher.AEAD
+	mac   []byte
+}
+
+// New wraps inner so that names are encrypted with key, which must be
+// KeySize bytes.
+func New(inner store.Store, key []byte) (*Store, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidKey, KeySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key[:32])
+	if err != nil {
+		return nil, fmt.Errorf("error creating aes block cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error setting gcm mode: %w", err)
+	}
+
+	return &Store{inner: inner, aead: aead, mac: key[32:]}, nil
+}
+
+// Inner returns the wrapped store, in which names are tokens.
+func (s *Store) Inner() store.Store {
+	return s.inner
+}
+
+// WithInner returns a store using the same key over another store,
+// typically the transactional store passed to an Atomic callback.
+func (s *Store) WithInner(inner store.Store) *Store {
+	clone := *s
+	clone.inner = inner
+	return &clone
+}
+
+// VaultToken returns the token stored in place of a vault name.
+func (s *Store) VaultToken(vault string) string {
+	return s.seal(vaultDomain(), vault)
+}
+
+// NameToken returns the token stored in place of a secret name.
+func (s *Store) NameToken(vault, name string) string {
+	return s.seal(nameDomain(vault), name)
+}
+
+func vaultDomain() []byte {
+	return []byte("veil:vault")
+}
+
+func nameDomain(vault string) []byte {
+	return fmt.Appendf(nil, "veil:name:%d:%s", len(vault), vault)
+}
+
+func (s *Store) seal(domain []byte, plaintext string) string {
+	nonce := s.nonce(domain, plaintext)
+	return tokenEncoding.EncodeToString(s.aead.Seal(nonce, nonce, []byte(plaintext), domain))
+}
+
+func (s *Store) open(domain []byte, token string) (string, error) {
+	data, err := tokenEncoding.DecodeString(token)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidToken, token)
+	}
+
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, domain)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	// A token is only valid with the nonce its plaintext derives.
+	if !hmac.Equal(nonce, s.nonce(domain, string(plaintext))) {
+		return "", fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	return string(plaintext), nil
+}
+
+func (s *Store) nonce(domain []byte, plaintext string) []byte {
+	h := hmac.New(sha256.New, s.mac)
+	h.Write(domain)
+	h.Write([]byte{0})
+	h.Write([]byte(plaintext))
+	return h.Sum(nil)[:s.aead.NonceSize()]
+}
+
+func (s *Store) openVault(token string) (string, error) {
+	return s.open(vaultDomain(), token)
+}
+
+func (s *Store) openName(vault, token string) (string, error) {
+	return s.open(nameDomain(vault), token)
+}
+
+// Save stores a value. The wrapped store clears the expiry of a changed
+// value, but cannot see one inside sealed metadata, so it is cleared here.
+func (s *Store) Save(vault, name, value string) error {
+	if err := s.inner.Save(s.VaultToken(vault), s.NameToken(vault, name), value); err != nil {
+		return err
+	}
+	m, err := s.GetMetadata(vault, name)
+	if err != nil || m.ExpiresAt.IsZero() {
+		return err
+	}
+	m.ExpiresAt = time.Time{}
+	return s.SetMetadata(vault, name, m)
+}
+
+func (s *Store) Get(vault, name string) (string, error) {
+	return s.inner.Get(s.VaultToken(vault), s.NameToken(vault, name))
+}
+
+func (s *Store) Delete(vault, name string) error {
+	return s.inner.Delete(s.VaultToken(vault), s.NameToken(vault, name))
+}
+
+// List returns the names in a vault in alphabetical order. Tokens sort
+// differently from the names they hide, so all names are decrypted first.
+func (s *Store) List(vault string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		names, err := s.names(vault)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		for _, name := range names {
+			if !yield(name, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Store) names(vault string) ([]string, error) {
+	var names []string
+	for token, err := range s.inner.List(s.VaultToken(vault)) {
+		if err != nil {
+			return nil, err
+		}
+		name, err := s.openName(vault, token)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// ListVaults returns the vault names in alphabetical order.
+func (s *Store) ListVaults() iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		vaults, err := s.vaults()
+		if err != nil {
+			yield("", err)
+			return
+		}
+		for _, vault := range vaults {
+			if !yield(vault, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Store) vaults() ([]string, error) {
+	var vaults []string
+	for token, err := range s.inner.ListVaults() {
+		if err != nil {
+			return nil, err
+		}
+		vault, err := s.openVault(token)
+		if err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, vault)
+	}
+	slices.Sort(vaults)
+	return vaults, nil
+}
+
+// Search matches secret names against pattern after decrypting them, with
+// the same semantics as the SQLite store: a case-insensitive match of the
+// whole name where * and % match any run of characters and ? and _ match
+// one character.
+func (s *Store) Search(pattern string) iter.Seq2[store.SecretRef, error] {
+	return func(yield func(store.SecretRef, error) bool) {
+		re := compilePattern(pattern)
+
+		vaults, err := s.vaults()
+		if err != nil {
+			yield(store.SecretRef{}, err)
+			return
+		}
+		for _, vault := range vaults {
+			names, err := s.names(vault)
+			if err != nil {
+				yield(store.SecretRef{}, err)
+				return
+			}
+			for _, name := range names {
+				if !re.MatchString(name) {
+					continue
+				}
+				if !yield(store.SecretRef{Vault: vault, Name: name}, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func compilePattern(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`(?is)^`)
+	for _, r := range pattern {
+		switch r {
+		case '*', '%':
+			b.WriteString(`.*`)
+		case '?', '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+func (s *Store) ListVersions(vault, name string) iter.Seq2[store.Version, error] {
+	return s.inner.ListVersions(s.VaultToken(vault), s.NameToken(vault, name))
+}
+
+func (s *Store) GetVersion(vault, name string, version int) (string, error) {
+	return s.inner.GetVersion(s.VaultToken(vault), s.NameToken(vault, name), version)
+}
+
+func (s *Store) GetMetadata(vault, name string) (store.Metadata, error) {
+	m, err := s.inner.GetMetadata(s.VaultToken(vault), s.NameToken(vault, name))
+	if err != nil {
+		return store.Metadata{}, err
+	}
+	return s.openMetadata(vault, name, m)
+}
+
+func (s *Store) SetMetadata(vault, name string, m store.Metadata) error {
+	sealed, err := s.sealMetadata(vault, name, m)
+	if err != nil {
+		return err
+	}
+	return s.inner.SetMetadata(s.VaultToken(vault), s.NameToken(vault, name), sealed)
+}
+
+// sealedMetadata holds the fields of store.Metadata that are sealed.
+type sealedMetadata struct {
+	Description string        `json:"description,omitempty"`
+	Owner       string        `json:"owner,omitempty"`
+	Tags        []string      `json:"tags,omitempty"`
+	ExpiresAt   time.Time     `json:"expires_at,omitzero"`
+	RotateEvery time.Duration `json:"rotate_every,omitempty"`
+	Recipe      string        `json:"recipe,omitempty"`
+	Template    bool          `json:"template,omitempty"`
+}
+
+func metadataDomain(vault, name string) []byte {
+	return fmt.Appendf(nil, "veil:metadata:%d:%s:%s", len(vault), vault, name)
+}
+
+// sealMetadata returns the metadata to store in place of m: every field
+// but the timestamps, encrypted into the description.
+func (s *Store) sealMetadata(vault, name string, m store.Metadata) (store.Metadata, error) {
+	plaintext, err := json.Marshal(sealedMetadata{
+		Description: m.Description,
+		Owner:       m.Owner,
+		Tags:        m.Tags,
+		ExpiresAt:   m.ExpiresAt,
+		RotateEvery: m.RotateEvery,
+		Recipe:      m.Recipe,
+		Template:    m.Template,
+	})
+	if err != nil {
+		return store.Metadata{}, err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return store.Metadata{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, plaintext, metadataDomain(vault, name))
+	return store.Metadata{Description: sealedPrefix + tokenEncoding.EncodeToString(sealed)}, nil
+}
+
+// openMetadata decrypts metadata read from the wrapped store. Metadata
+// stored before it was sealed is returned as it is, until it is next set.
+func (s *Store) openMetadata(vault, name string, m store.Metadata) (store.Metadata, error) {
+	encoded, ok := strings.CutPrefix(m.Description, sealedPrefix)
+	if !ok {
+		return m, nil
+	}
+
+	data, err := tokenEncoding.DecodeString(encoded)
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return store.Metadata{}, fmt.Errorf("%w: %s/%s", ErrInvalidMetadata, vault, name)
+	}
+	nonce, ciphertext := data[:s.aead.NonceSize()], data[s.aead.NonceSize():]
+	plaintext, err := s.aead.Open(nil, nonce, ciphertext, metadataDomain(vault, name))
+	if err != nil {
+		return store.Metadata{}, fmt.Errorf("%w: %s/%s: %v", ErrInvalidMetadata, vault, name, err)
+	}
+	var sm sealedMetadata
+	if err := json.Unmarshal(plaintext, &sm); err != nil {
+		return store.Metadata{}, fmt.Errorf("%w: %s/%s: %v", ErrInvalidMetadata, vault, name, err)
+	}
+
+	return store.Metadata{
+		Description: sm.Description,
+		Owner:       sm.Owner,
+		Tags:        sm.Tags,
+		ExpiresAt:   sm.ExpiresAt,
+		RotateEvery: sm.RotateEvery,
+		Recipe:      sm.Recipe,
+		Template:    sm.Template,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}, nil
+}
+
+func (s *Store) GetMeta(key string) (string, error) {
+	return s.inner.GetMeta(key)
+}
+
+func (s *Store) SetMeta(key, value string) error {
+	return s.inner.SetMeta(key, value)
+}
+
+func (s *Store) DeleteMeta(key string) error {
+	return s.inner.DeleteMeta(key)
+}
+
+// Rename moves a secret and seals its metadata again for the new name,
+// to which it is bound.
+func (s *Store) Rename(vault, name, newVault, newName string) error {
+	m, err := s.GetMetadata(vault, name)
+	if err != nil {
+		return err
+	}
+	if err := s.inner.Rename(s.VaultToken(vault), s.NameToken(vault, name), s.VaultToken(newVault), s.NameToken(newVault, newName)); err != nil {
+		return err
+	}
+	return s.SetMetadata(newVault, newName, m)
+}
+
+func (s *Store) GetVaultKey(vault string) (string, error) {
+	return s.inner.GetVaultKey(s.VaultToken(vault))
+}
+
+func (s *Store) SetVaultKey(vault, wrappedKey string) error {
+	return s.inner.SetVaultKey(s.VaultToken(vault), wrappedKey)
+}
+
+func (s *Store) DeleteVaultKey(vault string) error {
+	return s.inner.DeleteVaultKey(s.VaultToken(vault))
+}
+
+// ListVaultKeys returns the vault keys sorted by vault name.
+func (s *Store) ListVaultKeys() iter.Seq2[store.VaultKey, error] {
+	return func(yield func(store.VaultKey, error) bool) {
+		var keys []store.VaultKey
+		for vk, err := range s.inner.ListVaultKeys() {
+			if err != nil {
+				yield(store.VaultKey{}, err)
+				return
+			}
+			vault, err := s.openVault(vk.Vault)
+			if err != nil {
+				yield(store.VaultKey{}, err)
+				return
+			}
+			keys = append(keys, store.VaultKey{Vault: vault, Key: vk.Key})
+		}
+		slices.SortFunc(keys, func(a, b store.VaultKey) int { return strings.Compare(a.Vault, b.Vault) })
+		for _, vk := range keys {
+			if !yield(vk, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Store) GetVaultParents(vault string) ([]string, error) {
+	tokens, err := s.inner.GetVaultParents(s.VaultToken(vault))
+	if err != nil {
+		return nil, err
+	}
+	return s.openVaults(tokens)
+}
+
+func (s *Store) SetVaultParents(vault string, parents []string) error {
+	tokens := make([]string, len(parents))
+	for i, parent := range parents {
+		tokens[i] = s.VaultToken(parent)
+	}
+	return s.inner.SetVaultParents(s.VaultToken(vault), tokens)
+}
+
+// ListVaultParents returns the parents of each vault sorted by vault name.
+func (s *Store) ListVaultParents() iter.Seq2[store.VaultParents, error] {
+	return func(yield func(store.VaultParents, error) bool) {
+		var all []store.VaultParents
+		for vp, err := range s.inner.ListVaultParents() {
+			if err == nil {
+				vp.Vault, err = s.openVault(vp.Vault)
+			}
+			if err == nil {
+				vp.Parents, err = s.openVaults(vp.Parents)
+			}
+			if err != nil {
+				yield(store.VaultParents{}, err)
+				return
+			}
+			all = append(all, vp)
+		}
+		slices.SortFunc(all, func(a, b store.VaultParents) int { return strings.Compare(a.Vault, b.Vault) })
+		for _, vp := range all {
+			if !yield(vp, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Store) openVaults(tokens []string) ([]string, error) {
+	var vaults []string
+	for _, token := range tokens {
+		vault, err := s.openVault(token)
+		if err != nil {
+			return nil, err
+		}
+		vaults = append(vaults, vault)
+	}
+	return vaults, nil
+}
+
+func (s *Store) RewriteValue(vault, name string, version int, value string) error {
+	return s.inner.RewriteValue(s.VaultToken(vault), s.NameToken(vault, name), version, value)
+}
+
+func (s *Store) Trash(vault, name string) error {
+	return s.inner.Trash(s.VaultToken(vault), s.NameToken(vault, name))
+}
+
+// ListTrash decrypts the names of the trashed secrets. The order of the
+// wrapped store, by deletion time, is kept.
+func (s *Store) ListTrash() iter.Seq2[store.TrashedSecret, error] {
+	return func(yield func(store.TrashedSecret, error) bool) {
+		for t, err := range s.inner.ListTrash() {
+			if err == nil {
+				t.Vault, err = s.openVault(t.Vault)
+			}
+			if err == nil {
+				t.Name, err = s.openName(t.Vault, t.Name)
+			}
+			if err != nil {
+				yield(store.TrashedSecret{}, err)
+				return
+			}
+			if !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *Store) RestoreTrash(id int64) error {
+	return s.inner.RestoreTrash(id)
+}
+
+func (s *Store) PurgeTrash(before time.Time) (int, error) {
+	return s.inner.PurgeTrash(before)
+}
+
+func (s *Store) TrashValues(id int64) iter.Seq2[store.Version, error] {
+	return s.inner.TrashValues(id)
+}
+
+func (s *Store) RewriteTrashValue(id int64, version int, value string) error {
+	return s.inner.RewriteTrashValue(id, version, value)
+}
+
+// RenameTrash renames a trashed secret and seals its metadata again for
+// the new name.
+func (s *Store) RenameTrash(id int64, vault, name string) error {
+	m, err := s.GetTrashMetadata(id)
+	if err != nil {
+		return err
+	}
+	if err := s.inner.RenameTrash(id, s.VaultToken(vault), s.NameToken(vault, name)); err != nil {
+		return err
+	}
+	return s.SetTrashMetadata(id, m)
+}
+
+func (s *Store) GetTrashMetadata(id int64) (store.Metadata, error) {
+	vault, name, err := s.trashName(id)
+	if err != nil {
+		return store.Metadata{}, err
+	}
+	m, err := s.inner.GetTrashMetadata(id)
+	if err != nil {
+		return store.Metadata{}, err
+	}
+	return s.openMetadata(vault, name, m)
+}
+
+func (s *Store) SetTrashMetadata(id int64, m store.Metadata) error {
+	vault, name, err := s.trashName(id)
+	if err != nil {
+		return err
+	}
+	sealed, err := s.sealMetadata(vault, name, m)
+	if err != nil {
+		return err
+	}
+	return s.inner.SetTrashMetadata(id, sealed)
+}
+
+// trashName returns the vault and name of a trashed secret, which its
+// metadata is bound to. Only this entry's names are decrypted, so the
+// others may still be plaintext while names are being converted.
+func (s *Store) trashName(id int64) (vault, name string, err error) {
+	for t, err := range s.inner.ListTrash() {
+		if err != nil {
+			return "", "", err
+		}
+		if t.ID != id {
+			continue
+		}
+		if vault, err = s.openVault(t.Vault); err != nil {
+			return "", "", err
+		}
+		name, err = s.openName(vault, t.Name)
+		return vault, name, err
+	}
+	return "", "", store.ErrTrashNotFound
+}
+
+func (s *Store) Atomic(fn func(store.Store) error) error {
+	return s.inner.Atomic(func(tx store.Store) error {
+		return fn(s.WithInner(tx))
+	})
+}
+
+// Compact compacts the wrapped store if it supports it.
+func (s *Store) Compact() error {
+	if c, ok := s.inner.(store.Compacter); ok {
+		return c.Compact()
+	}
+	return nil
+}
+
+func (s *Store) Nuke() error {
+	return s.inner.Nuke()
+}
+
+func (s *Store) Close() error {
+	return s.inner.Close()
+}
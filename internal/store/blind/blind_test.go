@@ -0,0 +1,197 @@
+package blind
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+	"github.com/ossydotpy/veil/internal/testhelpers"
+)
+
+func newTestStore(t *testing.T) (*Store, *testhelpers.MemStore) {
+	t.Helper()
+	inner := testhelpers.NewMemStore()
+	s, err := New(inner, bytes.Repeat([]byte{7}, KeySize))
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return s, inner
+}
+
+func collect[T any](t *testing.T, seq func(func(T, error) bool)) []T {
+	t.Helper()
+	var out []T
+	for v, err := range seq {
+		if err != nil {
+			t.Fatalf("iteration error: %v", err)
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestNew_RejectsWrongKeySize(t *testing.T) {
+	if _, err := New(testhelpers.NewMemStore(), make([]byte, 32)); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("New error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestStore_HidesNames(t *testing.T) {
+	s, inner := newTestStore(t)
+	s.Save("stripe-api", "STRIPE_KEY", "ciphertext")
+
+	if got, err := s.Get("stripe-api", "STRIPE_KEY"); err != nil || got != "ciphertext" {
+		t.Fatalf("Get = %q, %v; want stored value", got, err)
+	}
+
+	for _, vault := range collect(t, inner.ListVaults()) {
+		if strings.Contains(vault, "stripe") {
+			t.Errorf("inner store holds plaintext vault name %q", vault)
+		}
+		for _, name := range collect(t, inner.List(vault)) {
+			if strings.Contains(name, "STRIPE") {
+				t.Errorf("inner store holds plaintext secret name %q", name)
+			}
+		}
+	}
+}
+
+func TestStore_TokensAreDeterministicPerVault(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	if s.VaultToken("prod") != s.VaultToken("prod") {
+		t.Error("VaultToken is not deterministic")
+	}
+	if s.NameToken("prod", "KEY") == s.NameToken("dev", "KEY") {
+		t.Error("the same name in two vaults produced the same token")
+	}
+	if _, err := s.openName("dev", s.NameToken("prod", "KEY")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("opening a token under another vault error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestStore_ListAndSearchSorted(t *testing.T) {
+	s, _ := newTestStore(t)
+	for _, ref := range []store.SecretRef{
+		{Vault: "prod", Name: "DB_PASSWORD"},
+		{Vault: "prod", Name: "API_KEY"},
+		{Vault: "dev", Name: "DB_PASSWORD"},
+		{Vault: "dev", Name: "db_user"},
+	} {
+		s.Save(ref.Vault, ref.Name, "v")
+	}
+
+	if vaults := collect(t, s.ListVaults()); !slices.Equal(vaults, []string{"dev", "prod"}) {
+		t.Errorf("ListVaults = %v, want [dev prod]", vaults)
+	}
+	if names := collect(t, s.List("prod")); !slices.Equal(names, []string{"API_KEY", "DB_PASSWORD"}) {
+		t.Errorf("List = %v, want [API_KEY DB_PASSWORD]", names)
+	}
+
+	tests := []struct {
+		pattern string
+		want    []store.SecretRef
+	}{
+		{"db_*", []store.SecretRef{{Vault: "dev", Name: "DB_PASSWORD"}, {Vault: "dev", Name: "db_user"}, {Vault: "prod", Name: "DB_PASSWORD"}}},
+		{"API?KEY", []store.SecretRef{{Vault: "prod", Name: "API_KEY"}}},
+		{"KEY", nil},
+		{"%key", []store.SecretRef{{Vault: "prod", Name: "API_KEY"}}},
+	}
+	for _, tt := range tests {
+		if got := collect(t, s.Search(tt.pattern)); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestStore_VaultKeysAndRename(t *testing.T) {
+	s, _ := newTestStore(t)
+	s.SetVaultKey("prod", "wrapped")
+	s.Save("prod", "OLD", "v")
+
+	if got, err := s.GetVaultKey("prod"); err != nil || got != "wrapped" {
+		t.Errorf("GetVaultKey = %q, %v; want stored key", got, err)
+	}
+	if keys := collect(t, s.ListVaultKeys()); len(keys) != 1 || keys[0].Vault != "prod" {
+		t.Errorf("ListVaultKeys = %+v, want prod", keys)
+	}
+
+	if err := s.Rename("prod", "OLD", "dev", "NEW"); err != nil {
+		t.Fatalf("Rename error: %v", err)
+	}
+	if got, err := s.Get("dev", "NEW"); err != nil || got != "v" {
+		t.Errorf("Get after rename = %q, %v; want moved value", got, err)
+	}
+}
+
+func TestStore_AtomicUsesTokens(t *testing.T) {
+	s, _ := newTestStore(t)
+
+	err := s.Atomic(func(tx store.Store) error {
+		return tx.Save("prod", "KEY", "v")
+	})
+	if err != nil {
+		t.Fatalf("Atomic error: %v", err)
+	}
+	if got, err := s.Get("prod", "KEY"); err != nil || got != "v" {
+		t.Errorf("Get after Atomic = %q, %v; want saved value", got, err)
+	}
+}
+
+func TestStore_SealsMetadata(t *testing.T) {
+	s, inner := newTestStore(t)
+	s.Save("prod", "STRIPE_KEY", "v1")
+	s.Save("prod", "OTHER", "v")
+
+	expires := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	m := store.Metadata{
+		Description: "Stripe live key",
+		Owner:       "payments",
+		Tags:        []string{"pci"},
+		ExpiresAt:   expires,
+		RotateEvery: 90 * 24 * time.Hour,
+		Recipe:      `{"type":"apikey"}`,
+		Template:    true,
+	}
+	if err := s.SetMetadata("prod", "STRIPE_KEY", m); err != nil {
+		t.Fatalf("SetMetadata error: %v", err)
+	}
+
+	raw, _ := inner.GetMetadata(s.VaultToken("prod"), s.NameToken("prod", "STRIPE_KEY"))
+	if strings.Contains(raw.Description, "Stripe") || raw.Owner != "" || raw.Tags != nil || !raw.ExpiresAt.IsZero() || raw.RotateEvery != 0 || raw.Recipe != "" || raw.Template {
+		t.Errorf("inner store holds plaintext metadata %+v", raw)
+	}
+	got, err := s.GetMetadata("prod", "STRIPE_KEY")
+	if err != nil || got.Owner != "payments" || !got.ExpiresAt.Equal(expires) || got.RotateEvery != m.RotateEvery || got.Recipe != m.Recipe || !got.Template {
+		t.Errorf("GetMetadata = %+v, %v; want the metadata set", got, err)
+	}
+
+	// Sealed metadata is bound to its secret.
+	inner.SetMetadata(s.VaultToken("prod"), s.NameToken("prod", "OTHER"), raw)
+	if _, err := s.GetMetadata("prod", "OTHER"); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("GetMetadata of copied metadata error = %v, want ErrInvalidMetadata", err)
+	}
+
+	// A new value clears the expiry, as it does in the wrapped store.
+	s.Save("prod", "STRIPE_KEY", "v2")
+	if got, _ := s.GetMetadata("prod", "STRIPE_KEY"); !got.ExpiresAt.IsZero() || got.Owner != "payments" {
+		t.Errorf("metadata after Save = %+v, want owner kept and expiry cleared", got)
+	}
+
+	if err := s.Rename("prod", "STRIPE_KEY", "payments", "STRIPE_KEY"); err != nil {
+		t.Fatalf("Rename error: %v", err)
+	}
+	if got, err := s.GetMetadata("payments", "STRIPE_KEY"); err != nil || got.Owner != "payments" {
+		t.Errorf("GetMetadata after Rename = %+v, %v; want metadata moved", got, err)
+	}
+
+	s.Trash("payments", "STRIPE_KEY")
+	trashed := collect(t, s.ListTrash())
+	if got, err := s.GetTrashMetadata(trashed[0].ID); err != nil || got.Description != "Stripe live key" {
+		t.Errorf("GetTrashMetadata = %+v, %v; want the trashed metadata", got, err)
+	}
+}
@@ -2,11 +2,13 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"iter"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ossydotpy/veil/internal/store"
 	_ "modernc.org/sqlite"
@@ -14,6 +16,17 @@ import (
 
 type SqliteStore struct {
 	db *sql.DB
+
+	// tx is set on the store passed to an Atomic callback. All queries then
+	// run inside that transaction.
+	tx *sql.Tx
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
 func NewSqliteStore(dbPath string) (store.Store, error) {
@@ -44,18 +57,128 @@ CREATE TABLE IF NOT EXISTS secrets (
 vault TEXT NOT NULL,
 name TEXT NOT NULL,
 value TEXT NOT NULL,
+created_at TEXT NOT NULL DEFAULT '',
+updated_at TEXT NOT NULL DEFAULT '',
+description TEXT NOT NULL DEFAULT '',
+owner TEXT NOT NULL DEFAULT '',
+tags TEXT NOT NULL DEFAULT '',
+expires_at TEXT NOT NULL DEFAULT '',
+rotate_every TEXT NOT NULL DEFAULT '',
+recipe TEXT NOT NULL DEFAULT '',
+template TEXT NOT NULL DEFAULT '',
 PRIMARY KEY (vault, name)
+);
+CREATE TABLE IF NOT EXISTS secret_versions (
+vault TEXT NOT NULL,
+name TEXT NOT NULL,
+version INTEGER NOT NULL,
+value TEXT NOT NULL,
+archived_at TEXT NOT NULL,
+PRIMARY KEY (vault, name, version)
+);
+CREATE TABLE IF NOT EXISTS meta (
+key TEXT PRIMARY KEY,
+value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS vault_keys (
+vault TEXT PRIMARY KEY,
+key TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS vault_parents (
+vault TEXT NOT NULL,
+position INTEGER NOT NULL,
+parent TEXT NOT NULL,
+PRIMARY KEY (vault, position)
+);
+CREATE TABLE IF NOT EXISTS trash (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+vault TEXT NOT NULL,
+name TEXT NOT NULL,
+value TEXT NOT NULL,
+deleted_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS trash_versions (
+trash_id INTEGER NOT NULL,
+version INTEGER NOT NULL,
+value TEXT NOT NULL,
+archived_at TEXT NOT NULL,
+PRIMARY KEY (trash_id, version)
 );`
 	_, err := s.db.Exec(query)
 	if err != nil {
 		return fmt.Errorf("%w: %v", store.ErrMigrationFailed, err)
 	}
+	for _, table := range []string{"secrets", "trash"} {
+		if err := s.addColumns(table, secretMetadataColumns); err != nil {
+			return fmt.Errorf("%w: %v", store.ErrMigrationFailed, err)
+		}
+	}
 	return nil
 }
 
+// secretMetadataColumns were added to the secrets table after its first
+// release. Databases created before then get them on open. The trash table
+// always gets them this way, so that it keeps every column of a secret.
+var secretMetadataColumns = []string{"created_at", "updated_at", "description", "owner", "tags", "expires_at", "rotate_every", "recipe", "template"}
+
+// addColumns adds the missing columns of table as empty text columns.
+func (s *SqliteStore) addColumns(table string, columns []string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid, notNull, pk int
+			name, typ        string
+			dflt             sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existing[column] {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s TEXT NOT NULL DEFAULT '';", table, column)
+		if _, err := s.db.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save stores a secret. If the secret already exists, its current value is
+// archived in secret_versions before being replaced; its metadata and
+// creation time are kept, except for the expiry of the replaced value.
 func (s *SqliteStore) Save(vault, name, value string) error {
-	query := `INSERT OR REPLACE INTO secrets (vault, name, value) VALUES (?, ?, ?);`
-	_, err := s.db.Exec(query, vault, name, value)
+	err := s.inTx(func(q querier) error {
+		archive := `
+INSERT INTO secret_versions (vault, name, version, value, archived_at)
+SELECT vault, name,
+	COALESCE((SELECT MAX(version) FROM secret_versions WHERE vault = ? AND name = ?), 0) + 1,
+	value, ?
+FROM secrets WHERE vault = ? AND name = ?;`
+		now := time.Now().UTC().Format(time.RFC3339)
+		if _, err := q.Exec(archive, vault, name, now, vault, name); err != nil {
+			return err
+		}
+
+		query := `
+INSERT INTO secrets (vault, name, value, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+ON CONFLICT (vault, name) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at, expires_at = '';`
+		_, err := q.Exec(query, vault, name, value, now, now)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
 	}
@@ -65,7 +188,7 @@ func (s *SqliteStore) Save(vault, name, value string) error {
 func (s *SqliteStore) Get(vault, name string) (string, error) {
 	var value string
 	query := `SELECT value FROM secrets WHERE vault = ? AND name = ?;`
-	err := s.db.QueryRow(query, vault, name).Scan(&value)
+	err := s.conn().QueryRow(query, vault, name).Scan(&value)
 	if err == sql.ErrNoRows {
 		return "", store.ErrNotFound
 	}
@@ -75,19 +198,61 @@ func (s *SqliteStore) Get(vault, name string) (string, error) {
 	return value, nil
 }
 
+// Delete removes a secret together with its version history.
 func (s *SqliteStore) Delete(vault, name string) error {
-	query := `DELETE FROM secrets WHERE vault = ? AND name = ?;`
-	_, err := s.db.Exec(query, vault, name)
+	err := s.inTx(func(q querier) error {
+		for _, query := range []string{
+			`DELETE FROM secrets WHERE vault = ? AND name = ?;`,
+			`DELETE FROM secret_versions WHERE vault = ? AND name = ?;`,
+		} {
+			if _, err := q.Exec(query, vault, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("%w: %v", store.ErrDeleteFailed, err)
 	}
 	return nil
 }
 
+// Rename moves a secret together with its version history.
+func (s *SqliteStore) Rename(vault, name, newVault, newName string) error {
+	err := s.inTx(func(q querier) error {
+		var exists int
+		err := q.QueryRow(`SELECT COUNT(*) FROM secrets WHERE vault = ? AND name = ?;`, newVault, newName).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			return store.ErrExists
+		}
+
+		res, err := q.Exec(`UPDATE secrets SET vault = ?, name = ? WHERE vault = ? AND name = ?;`, newVault, newName, vault, name)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return store.ErrNotFound
+		}
+
+		_, err = q.Exec(`UPDATE secret_versions SET vault = ?, name = ? WHERE vault = ? AND name = ?;`, newVault, newName, vault, name)
+		return err
+	})
+	if errors.Is(err, store.ErrExists) || errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	return nil
+}
+
 func (s *SqliteStore) List(vault string) iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
 		query := `SELECT name FROM secrets WHERE vault = ? ORDER BY name ASC;`
-		rows, err := s.db.Query(query, vault)
+		rows, err := s.conn().Query(query, vault)
 		if err != nil {
 			yield("", fmt.Errorf("%w: %v", store.ErrListFailed, err))
 			return
@@ -112,7 +277,7 @@ func (s *SqliteStore) List(vault string) iter.Seq2[string, error] {
 func (s *SqliteStore) ListVaults() iter.Seq2[string, error] {
 	return func(yield func(string, error) bool) {
 		query := `SELECT DISTINCT vault FROM secrets ORDER BY vault ASC;`
-		rows, err := s.db.Query(query)
+		rows, err := s.conn().Query(query)
 		if err != nil {
 			yield("", fmt.Errorf("%w: %v", store.ErrListFailed, err))
 			return
@@ -138,7 +303,7 @@ func (s *SqliteStore) Search(pattern string) iter.Seq2[store.SecretRef, error] {
 	return func(yield func(store.SecretRef, error) bool) {
 		sqlPattern := convertPattern(pattern)
 		query := `SELECT vault, name FROM secrets WHERE LOWER(name) LIKE LOWER(?) ORDER BY vault ASC, name ASC;`
-		rows, err := s.db.Query(query, sqlPattern)
+		rows, err := s.conn().Query(query, sqlPattern)
 		if err != nil {
 			yield(store.SecretRef{}, fmt.Errorf("failed to search secrets: %w", err))
 			return
@@ -160,6 +325,499 @@ func (s *SqliteStore) Search(pattern string) iter.Seq2[store.SecretRef, error] {
 	}
 }
 
+// ListVersions returns the archived versions of a secret, newest first.
+func (s *SqliteStore) ListVersions(vault, name string) iter.Seq2[store.Version, error] {
+	return func(yield func(store.Version, error) bool) {
+		query := `SELECT version, value, archived_at FROM secret_versions WHERE vault = ? AND name = ? ORDER BY version DESC;`
+		rows, err := s.conn().Query(query, vault, name)
+		if err != nil {
+			yield(store.Version{}, fmt.Errorf("%w: %v", store.ErrListFailed, err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var v store.Version
+			var archivedAt string
+			if err := rows.Scan(&v.Number, &v.Value, &archivedAt); err != nil {
+				if !yield(store.Version{}, err) {
+					return
+				}
+				continue
+			}
+			v.ArchivedAt, _ = time.Parse(time.RFC3339, archivedAt)
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *SqliteStore) GetVersion(vault, name string, version int) (string, error) {
+	var value string
+	query := `SELECT value FROM secret_versions WHERE vault = ? AND name = ? AND version = ?;`
+	err := s.conn().QueryRow(query, vault, name, version).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", store.ErrVersionNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", store.ErrGetFailed, err)
+	}
+	return value, nil
+}
+
+func (s *SqliteStore) GetMetadata(vault, name string) (store.Metadata, error) {
+	return s.readMetadata(fmt.Sprintf("%s/%s", vault, name), store.ErrNotFound, "secrets", `vault = ? AND name = ?`, vault, name)
+}
+
+// SetMetadata replaces the metadata of a secret. Tags are stored
+// comma-separated, so they must not contain commas.
+func (s *SqliteStore) SetMetadata(vault, name string, m store.Metadata) error {
+	return s.writeMetadata(m, store.ErrNotFound, "secrets", `vault = ? AND name = ?`, vault, name)
+}
+
+func (s *SqliteStore) GetTrashMetadata(id int64) (store.Metadata, error) {
+	return s.readMetadata(fmt.Sprintf("trash entry %d", id), store.ErrTrashNotFound, "trash", `id = ?`, id)
+}
+
+func (s *SqliteStore) SetTrashMetadata(id int64, m store.Metadata) error {
+	return s.writeMetadata(m, store.ErrTrashNotFound, "trash", `id = ?`, id)
+}
+
+// readMetadata reads the metadata columns of the row of table matching
+// where. what describes the row in errors, and notFound is returned if
+// there is no such row.
+func (s *SqliteStore) readMetadata(what string, notFound error, table, where string, args ...any) (store.Metadata, error) {
+	var m store.Metadata
+	var tags, expiresAt, rotateEvery, template, createdAt, updatedAt string
+	query := `SELECT description, owner, tags, expires_at, rotate_every, recipe, template, created_at, updated_at FROM ` + table + ` WHERE ` + where + `;`
+	err := s.conn().QueryRow(query, args...).Scan(&m.Description, &m.Owner, &tags, &expiresAt, &rotateEvery, &m.Recipe, &template, &createdAt, &updatedAt)
+	if err == sql.ErrNoRows {
+		return store.Metadata{}, notFound
+	}
+	if err != nil {
+		return store.Metadata{}, fmt.Errorf("%w: %v", store.ErrGetFailed, err)
+	}
+
+	if tags != "" {
+		m.Tags = strings.Split(tags, ",")
+	}
+	// A corrupted expiry or interval must not read as "never expires",
+	// which would make 'veil due' and --fail-expired fail open.
+	if rotateEvery != "" {
+		if m.RotateEvery, err = time.ParseDuration(rotateEvery); err != nil {
+			return store.Metadata{}, fmt.Errorf("%w: %s has an invalid rotation interval %q", store.ErrGetFailed, what, rotateEvery)
+		}
+	}
+	if expiresAt != "" {
+		if m.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt); err != nil {
+			return store.Metadata{}, fmt.Errorf("%w: %s has an invalid expiry %q", store.ErrGetFailed, what, expiresAt)
+		}
+	}
+	m.Template = template == "true"
+	m.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	m.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return m, nil
+}
+
+// writeMetadata replaces the metadata columns of the row of table
+// matching where, returning notFound if there is no such row.
+func (s *SqliteStore) writeMetadata(m store.Metadata, notFound error, table, where string, args ...any) error {
+	var expiresAt, rotateEvery, template string
+	if !m.ExpiresAt.IsZero() {
+		expiresAt = m.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if m.RotateEvery > 0 {
+		rotateEvery = m.RotateEvery.String()
+	}
+	if m.Template {
+		template = "true"
+	}
+
+	query := `UPDATE ` + table + ` SET description = ?, owner = ?, tags = ?, expires_at = ?, rotate_every = ?, recipe = ?, template = ? WHERE ` + where + `;`
+	values := []any{m.Description, m.Owner, strings.Join(m.Tags, ","), expiresAt, rotateEvery, m.Recipe, template}
+	res, err := s.conn().Exec(query, append(values, args...)...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func (s *SqliteStore) GetMeta(key string) (string, error) {
+	var value string
+	query := `SELECT value FROM meta WHERE key = ?;`
+	err := s.conn().QueryRow(query, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", store.ErrMetaNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", store.ErrGetFailed, err)
+	}
+	return value, nil
+}
+
+func (s *SqliteStore) SetMeta(key, value string) error {
+	query := `INSERT OR REPLACE INTO meta (key, value) VALUES (?, ?);`
+	if _, err := s.conn().Exec(query, key, value); err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	return nil
+}
+
+func (s *SqliteStore) DeleteMeta(key string) error {
+	query := `DELETE FROM meta WHERE key = ?;`
+	if _, err := s.conn().Exec(query, key); err != nil {
+		return fmt.Errorf("%w: %v", store.ErrDeleteFailed, err)
+	}
+	return nil
+}
+
+func (s *SqliteStore) GetVaultKey(vault string) (string, error) {
+	var key string
+	query := `SELECT key FROM vault_keys WHERE vault = ?;`
+	err := s.conn().QueryRow(query, vault).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", store.ErrVaultKeyNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", store.ErrGetFailed, err)
+	}
+	return key, nil
+}
+
+func (s *SqliteStore) SetVaultKey(vault, wrappedKey string) error {
+	query := `INSERT OR REPLACE INTO vault_keys (vault, key) VALUES (?, ?);`
+	if _, err := s.conn().Exec(query, vault, wrappedKey); err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	return nil
+}
+
+func (s *SqliteStore) DeleteVaultKey(vault string) error {
+	query := `DELETE FROM vault_keys WHERE vault = ?;`
+	if _, err := s.conn().Exec(query, vault); err != nil {
+		return fmt.Errorf("%w: %v", store.ErrDeleteFailed, err)
+	}
+	return nil
+}
+
+func (s *SqliteStore) ListVaultKeys() iter.Seq2[store.VaultKey, error] {
+	return func(yield func(store.VaultKey, error) bool) {
+		query := `SELECT vault, key FROM vault_keys ORDER BY vault ASC;`
+		rows, err := s.conn().Query(query)
+		if err != nil {
+			yield(store.VaultKey{}, fmt.Errorf("%w: %v", store.ErrListFailed, err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var vk store.VaultKey
+			if err := rows.Scan(&vk.Vault, &vk.Key); err != nil {
+				if !yield(store.VaultKey{}, err) {
+					return
+				}
+				continue
+			}
+			if !yield(vk, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *SqliteStore) GetVaultParents(vault string) ([]string, error) {
+	query := `SELECT parent FROM vault_parents WHERE vault = ? ORDER BY position ASC;`
+	rows, err := s.conn().Query(query, vault)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", store.ErrGetFailed, err)
+	}
+	defer rows.Close()
+
+	var parents []string
+	for rows.Next() {
+		var parent string
+		if err := rows.Scan(&parent); err != nil {
+			return nil, fmt.Errorf("%w: %v", store.ErrGetFailed, err)
+		}
+		parents = append(parents, parent)
+	}
+	return parents, rows.Err()
+}
+
+// SetVaultParents replaces the parents of vault, keeping their order.
+func (s *SqliteStore) SetVaultParents(vault string, parents []string) error {
+	err := s.inTx(func(q querier) error {
+		if _, err := q.Exec(`DELETE FROM vault_parents WHERE vault = ?;`, vault); err != nil {
+			return err
+		}
+		for i, parent := range parents {
+			query := `INSERT INTO vault_parents (vault, position, parent) VALUES (?, ?, ?);`
+			if _, err := q.Exec(query, vault, i, parent); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	return nil
+}
+
+func (s *SqliteStore) ListVaultParents() iter.Seq2[store.VaultParents, error] {
+	return func(yield func(store.VaultParents, error) bool) {
+		query := `SELECT vault, parent FROM vault_parents ORDER BY vault ASC, position ASC;`
+		rows, err := s.conn().Query(query)
+		if err != nil {
+			yield(store.VaultParents{}, fmt.Errorf("%w: %v", store.ErrListFailed, err))
+			return
+		}
+		defer rows.Close()
+
+		var current store.VaultParents
+		for rows.Next() {
+			var vault, parent string
+			if err := rows.Scan(&vault, &parent); err != nil {
+				yield(store.VaultParents{}, err)
+				return
+			}
+			if vault != current.Vault && current.Vault != "" {
+				if !yield(current, nil) {
+					return
+				}
+				current = store.VaultParents{}
+			}
+			current.Vault = vault
+			current.Parents = append(current.Parents, parent)
+		}
+		if current.Vault != "" {
+			yield(current, nil)
+		}
+	}
+}
+
+// Trash moves a secret to the trash table and its archived versions to
+// trash_versions, keeping every metadata column.
+func (s *SqliteStore) Trash(vault, name string) error {
+	columns := strings.Join(secretMetadataColumns, ", ")
+	err := s.inTx(func(q querier) error {
+		query := fmt.Sprintf(`
+INSERT INTO trash (vault, name, value, %s, deleted_at)
+SELECT vault, name, value, %s, ? FROM secrets WHERE vault = ? AND name = ?;`, columns, columns)
+		now := time.Now().UTC().Format(time.RFC3339)
+		res, err := q.Exec(query, now, vault, name)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return store.ErrNotFound
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		versions := `
+INSERT INTO trash_versions (trash_id, version, value, archived_at)
+SELECT ?, version, value, archived_at FROM secret_versions WHERE vault = ? AND name = ?;`
+		if _, err := q.Exec(versions, id, vault, name); err != nil {
+			return err
+		}
+		for _, query := range []string{
+			`DELETE FROM secrets WHERE vault = ? AND name = ?;`,
+			`DELETE FROM secret_versions WHERE vault = ? AND name = ?;`,
+		} {
+			if _, err := q.Exec(query, vault, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrDeleteFailed, err)
+	}
+	return nil
+}
+
+func (s *SqliteStore) ListTrash() iter.Seq2[store.TrashedSecret, error] {
+	return func(yield func(store.TrashedSecret, error) bool) {
+		query := `SELECT id, vault, name, deleted_at FROM trash ORDER BY deleted_at DESC, id DESC;`
+		rows, err := s.conn().Query(query)
+		if err != nil {
+			yield(store.TrashedSecret{}, fmt.Errorf("%w: %v", store.ErrListFailed, err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var t store.TrashedSecret
+			var deletedAt string
+			if err := rows.Scan(&t.ID, &t.Vault, &t.Name, &deletedAt); err != nil {
+				if !yield(store.TrashedSecret{}, err) {
+					return
+				}
+				continue
+			}
+			t.DeletedAt, _ = time.Parse(time.RFC3339, deletedAt)
+			if !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
+
+// RestoreTrash moves a trashed secret, its archived versions and its
+// metadata back to the secrets tables.
+func (s *SqliteStore) RestoreTrash(id int64) error {
+	columns := strings.Join(secretMetadataColumns, ", ")
+	err := s.inTx(func(q querier) error {
+		var vault, name string
+		err := q.QueryRow(`SELECT vault, name FROM trash WHERE id = ?;`, id).Scan(&vault, &name)
+		if err == sql.ErrNoRows {
+			return store.ErrTrashNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var exists int
+		err = q.QueryRow(`SELECT COUNT(*) FROM secrets WHERE vault = ? AND name = ?;`, vault, name).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists > 0 {
+			return store.ErrExists
+		}
+
+		restore := fmt.Sprintf(`
+INSERT INTO secrets (vault, name, value, %s)
+SELECT vault, name, value, %s FROM trash WHERE id = ?;`, columns, columns)
+		if _, err := q.Exec(restore, id); err != nil {
+			return err
+		}
+		versions := `
+INSERT INTO secret_versions (vault, name, version, value, archived_at)
+SELECT ?, ?, version, value, archived_at FROM trash_versions WHERE trash_id = ?;`
+		if _, err := q.Exec(versions, vault, name, id); err != nil {
+			return err
+		}
+		return deleteTrash(q, `id = ?`, id)
+	})
+	if errors.Is(err, store.ErrTrashNotFound) || errors.Is(err, store.ErrExists) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	return nil
+}
+
+// PurgeTrash deletes the secrets trashed before the given time, or all of
+// them if it is zero.
+func (s *SqliteStore) PurgeTrash(before time.Time) (int, error) {
+	where, args := `1 = 1`, []any{}
+	if !before.IsZero() {
+		where, args = `deleted_at < ?`, []any{before.UTC().Format(time.RFC3339)}
+	}
+
+	var purged int
+	err := s.inTx(func(q querier) error {
+		err := q.QueryRow(`SELECT COUNT(*) FROM trash WHERE `+where+`;`, args...).Scan(&purged)
+		if err != nil {
+			return err
+		}
+		return deleteTrash(q, where, args...)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", store.ErrDeleteFailed, err)
+	}
+	return purged, nil
+}
+
+// deleteTrash removes the trash entries matching where, and their versions.
+func deleteTrash(q querier, where string, args ...any) error {
+	for _, query := range []string{
+		`DELETE FROM trash_versions WHERE trash_id IN (SELECT id FROM trash WHERE ` + where + `);`,
+		`DELETE FROM trash WHERE ` + where + `;`,
+	} {
+		if _, err := q.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SqliteStore) TrashValues(id int64) iter.Seq2[store.Version, error] {
+	return func(yield func(store.Version, error) bool) {
+		query := `
+SELECT 1 AS current, 0 AS version, value, '' FROM trash WHERE id = ?
+UNION ALL
+SELECT 0, version, value, archived_at FROM trash_versions WHERE trash_id = ?
+ORDER BY current DESC, version DESC;`
+		rows, err := s.conn().Query(query, id, id)
+		if err != nil {
+			yield(store.Version{}, fmt.Errorf("%w: %v", store.ErrListFailed, err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var v store.Version
+			var current bool
+			var archivedAt string
+			if err := rows.Scan(&current, &v.Number, &v.Value, &archivedAt); err != nil {
+				if !yield(store.Version{}, err) {
+					return
+				}
+				continue
+			}
+			v.ArchivedAt, _ = time.Parse(time.RFC3339, archivedAt)
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (s *SqliteStore) RewriteTrashValue(id int64, version int, value string) error {
+	query := `UPDATE trash SET value = ? WHERE id = ?;`
+	args := []any{value, id}
+	if version > 0 {
+		query = `UPDATE trash_versions SET value = ? WHERE trash_id = ? AND version = ?;`
+		args = append(args, version)
+	}
+
+	res, err := s.conn().Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if version > 0 {
+			return store.ErrVersionNotFound
+		}
+		return store.ErrTrashNotFound
+	}
+	return nil
+}
+
+func (s *SqliteStore) RenameTrash(id int64, vault, name string) error {
+	res, err := s.conn().Exec(`UPDATE trash SET vault = ?, name = ? WHERE id = ?;`, vault, name, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrTrashNotFound
+	}
+	return nil
+}
+
 func convertPattern(pattern string) string {
 	pattern = strings.ReplaceAll(pattern, "*", "%")
 	pattern = strings.ReplaceAll(pattern, "?", "_")
@@ -167,15 +825,136 @@ func convertPattern(pattern string) string {
 }
 
 func (s *SqliteStore) Nuke() error {
-	query := `DELETE FROM secrets;`
-	_, err := s.db.Exec(query)
+	err := s.inTx(func(q querier) error {
+		for _, query := range []string{
+			`DELETE FROM secrets;`,
+			`DELETE FROM secret_versions;`,
+			`DELETE FROM meta;`,
+			`DELETE FROM vault_keys;`,
+			`DELETE FROM vault_parents;`,
+			`DELETE FROM trash;`,
+			`DELETE FROM trash_versions;`,
+		} {
+			if _, err := q.Exec(query); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("%w: %v", store.ErrNukeFailed, err)
 	}
-	_, _ = s.db.Exec("VACUUM;")
+	if s.tx == nil {
+		_, _ = s.db.Exec("VACUUM;")
+	}
+	return nil
+}
+
+// Compact rebuilds the database file so that deleted and overwritten rows
+// no longer appear in it. It is a no-op inside a transaction.
+func (s *SqliteStore) Compact() error {
+	if s.tx != nil {
+		return nil
+	}
+	if _, err := s.db.Exec("VACUUM;"); err != nil {
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+	return nil
+}
+
+// Snapshot writes a compacted copy of the database to path, which must not
+// exist yet, readable only by the owner.
+func (s *SqliteStore) Snapshot(path string) error {
+	if s.tx != nil {
+		return errors.New("cannot snapshot inside a transaction")
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("snapshot %s already exists", path)
+	}
+	if _, err := s.db.Exec(`VACUUM INTO ?;`, path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// RewriteValue replaces the ciphertext of a secret (version 0) or of one of
+// its archived versions in place, without archiving anything.
+func (s *SqliteStore) RewriteValue(vault, name string, version int, value string) error {
+	query := `UPDATE secrets SET value = ? WHERE vault = ? AND name = ?;`
+	args := []any{value, vault, name}
+	if version > 0 {
+		query = `UPDATE secret_versions SET value = ? WHERE vault = ? AND name = ? AND version = ?;`
+		args = append(args, version)
+	}
+
+	res, err := s.conn().Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("%w: %v", store.ErrSaveFailed, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if version > 0 {
+			return store.ErrVersionNotFound
+		}
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// Atomic runs fn against a store bound to a single transaction. The
+// transaction is committed if fn returns nil and rolled back otherwise.
+// Nested calls join the outer transaction.
+func (s *SqliteStore) Atomic(fn func(store.Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SqliteStore{db: s.db, tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
 func (s *SqliteStore) Close() error {
+	if s.tx != nil {
+		return nil
+	}
 	return s.db.Close()
 }
+
+func (s *SqliteStore) conn() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// inTx runs fn inside the store's transaction, starting one if the store
+// is not already part of an Atomic call.
+func (s *SqliteStore) inTx(fn func(q querier) error) error {
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
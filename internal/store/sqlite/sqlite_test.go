@@ -0,0 +1,439 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ossydotpy/veil/internal/store"
+)
+
+func newTestStore(t *testing.T) store.Store {
+	t.Helper()
+	s, err := NewSqliteStore(filepath.Join(t.TempDir(), "veil.db"))
+	if err != nil {
+		t.Fatalf("NewSqliteStore error: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestSave_ArchivesPreviousValue(t *testing.T) {
+	s := newTestStore(t)
+
+	for _, value := range []string{"v1", "v2", "v3"} {
+		if err := s.Save("prod", "KEY", value); err != nil {
+			t.Fatalf("Save(%q) error: %v", value, err)
+		}
+	}
+
+	var numbers []int
+	for v, err := range s.ListVersions("prod", "KEY") {
+		if err != nil {
+			t.Fatalf("ListVersions error: %v", err)
+		}
+		numbers = append(numbers, v.Number)
+		if v.ArchivedAt.IsZero() {
+			t.Errorf("version %d has no archive timestamp", v.Number)
+		}
+	}
+	if len(numbers) != 2 || numbers[0] != 2 || numbers[1] != 1 {
+		t.Errorf("versions = %v, want [2 1]", numbers)
+	}
+
+	value, err := s.GetVersion("prod", "KEY", 1)
+	if err != nil {
+		t.Fatalf("GetVersion error: %v", err)
+	}
+	if value != "v1" {
+		t.Errorf("version 1 = %q, want %q", value, "v1")
+	}
+
+	current, err := s.Get("prod", "KEY")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if current != "v3" {
+		t.Errorf("current = %q, want %q", current, "v3")
+	}
+}
+
+func TestDelete_RemovesVersions(t *testing.T) {
+	s := newTestStore(t)
+
+	s.Save("prod", "KEY", "v1")
+	s.Save("prod", "KEY", "v2")
+
+	if err := s.Delete("prod", "KEY"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+
+	if _, err := s.GetVersion("prod", "KEY", 1); !errors.Is(err, store.ErrVersionNotFound) {
+		t.Errorf("GetVersion after delete error = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestAtomic_RollsBackOnError(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("prod", "KEY", "original")
+
+	failure := errors.New("boom")
+	err := s.Atomic(func(tx store.Store) error {
+		if err := tx.RewriteValue("prod", "KEY", 0, "rewritten"); err != nil {
+			return err
+		}
+		if err := tx.Save("prod", "OTHER", "new"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Atomic error = %v, want %v", err, failure)
+	}
+
+	if value, _ := s.Get("prod", "KEY"); value != "original" {
+		t.Errorf("KEY = %q after rollback, want %q", value, "original")
+	}
+	if _, err := s.Get("prod", "OTHER"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("OTHER should not exist after rollback, got err = %v", err)
+	}
+}
+
+func TestAtomic_Commits(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("prod", "KEY", "v1")
+	s.Save("prod", "KEY", "v2")
+
+	err := s.Atomic(func(tx store.Store) error {
+		for name, err := range tx.List("prod") {
+			if err != nil {
+				return err
+			}
+			if err := tx.RewriteValue("prod", name, 0, "current"); err != nil {
+				return err
+			}
+		}
+		return tx.RewriteValue("prod", "KEY", 1, "first")
+	})
+	if err != nil {
+		t.Fatalf("Atomic error: %v", err)
+	}
+
+	if value, _ := s.Get("prod", "KEY"); value != "current" {
+		t.Errorf("KEY = %q, want %q", value, "current")
+	}
+	if value, _ := s.GetVersion("prod", "KEY", 1); value != "first" {
+		t.Errorf("KEY version 1 = %q, want %q", value, "first")
+	}
+}
+
+func TestVaultKeys(t *testing.T) {
+	s := newTestStore(t)
+
+	if _, err := s.GetVaultKey("prod"); !errors.Is(err, store.ErrVaultKeyNotFound) {
+		t.Fatalf("GetVaultKey of missing key error = %v, want ErrVaultKeyNotFound", err)
+	}
+
+	s.SetVaultKey("prod", "wrapped-1")
+	s.SetVaultKey("dev", "wrapped-dev")
+	s.SetVaultKey("prod", "wrapped-2")
+
+	if got, err := s.GetVaultKey("prod"); err != nil || got != "wrapped-2" {
+		t.Errorf("GetVaultKey = %q, %v; want replaced key", got, err)
+	}
+
+	var vaults []string
+	for vk, err := range s.ListVaultKeys() {
+		if err != nil {
+			t.Fatalf("ListVaultKeys error: %v", err)
+		}
+		vaults = append(vaults, vk.Vault)
+	}
+	if len(vaults) != 2 || vaults[0] != "dev" || vaults[1] != "prod" {
+		t.Errorf("ListVaultKeys vaults = %v, want [dev prod]", vaults)
+	}
+}
+
+func TestRename_MovesVersions(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("prod", "OLD", "v1")
+	s.Save("prod", "OLD", "v2")
+	s.Save("prod", "TAKEN", "x")
+
+	if err := s.Rename("prod", "OLD", "prod", "TAKEN"); !errors.Is(err, store.ErrExists) {
+		t.Errorf("Rename onto existing secret error = %v, want ErrExists", err)
+	}
+	if err := s.Rename("prod", "MISSING", "dev", "NEW"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Rename of missing secret error = %v, want ErrNotFound", err)
+	}
+
+	if err := s.Rename("prod", "OLD", "dev", "NEW"); err != nil {
+		t.Fatalf("Rename error: %v", err)
+	}
+	if got, err := s.Get("dev", "NEW"); err != nil || got != "v2" {
+		t.Errorf("Get after rename = %q, %v; want %q", got, err, "v2")
+	}
+	if got, err := s.GetVersion("dev", "NEW", 1); err != nil || got != "v1" {
+		t.Errorf("GetVersion after rename = %q, %v; want %q", got, err, "v1")
+	}
+	if _, err := s.Get("prod", "OLD"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Get of old name error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMetadata_KeptAcrossSaves(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("prod", "KEY", "v1")
+
+	m := store.Metadata{Description: "Payment API", Owner: "payments", Tags: []string{"pci", "stripe"}}
+	if err := s.SetMetadata("prod", "KEY", m); err != nil {
+		t.Fatalf("SetMetadata error: %v", err)
+	}
+	created, _ := s.GetMetadata("prod", "KEY")
+
+	s.Save("prod", "KEY", "v2")
+	s.RewriteValue("prod", "KEY", 0, "v2-reencrypted")
+
+	got, err := s.GetMetadata("prod", "KEY")
+	if err != nil {
+		t.Fatalf("GetMetadata error: %v", err)
+	}
+	if got.Description != m.Description || got.Owner != m.Owner || !slices.Equal(got.Tags, m.Tags) {
+		t.Errorf("GetMetadata = %+v, want %+v", got, m)
+	}
+	if got.CreatedAt.IsZero() || !got.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, created.CreatedAt)
+	}
+	if got.UpdatedAt.Before(got.CreatedAt) {
+		t.Errorf("UpdatedAt %v is before CreatedAt %v", got.UpdatedAt, got.CreatedAt)
+	}
+
+	if err := s.SetMetadata("prod", "MISSING", m); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("SetMetadata of missing secret error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMigrate_AddsMetadataColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "veil.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open error: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE secrets (vault TEXT NOT NULL, name TEXT NOT NULL, value TEXT NOT NULL, PRIMARY KEY (vault, name));
+INSERT INTO secrets (vault, name, value) VALUES ('prod', 'OLD', 'v');`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("creating old schema: %v", err)
+	}
+
+	s, err := NewSqliteStore(path)
+	if err != nil {
+		t.Fatalf("NewSqliteStore error: %v", err)
+	}
+	defer s.Close()
+
+	m, err := s.GetMetadata("prod", "OLD")
+	if err != nil {
+		t.Fatalf("GetMetadata error: %v", err)
+	}
+	if !m.CreatedAt.IsZero() || m.Description != "" {
+		t.Errorf("GetMetadata of migrated secret = %+v, want empty metadata", m)
+	}
+	if got, err := s.Get("prod", "OLD"); err != nil || got != "v" {
+		t.Errorf("Get after migration = %q, %v; want %q", got, err, "v")
+	}
+}
+
+func TestMetadata_SaveClearsExpiry(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("prod", "KEY", "v1")
+
+	expires := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	s.SetMetadata("prod", "KEY", store.Metadata{ExpiresAt: expires, RotateEvery: 90 * 24 * time.Hour})
+
+	got, _ := s.GetMetadata("prod", "KEY")
+	if !got.ExpiresAt.Equal(expires) || got.RotateEvery != 90*24*time.Hour {
+		t.Fatalf("GetMetadata = %+v, want expiry and rotation", got)
+	}
+
+	s.Save("prod", "KEY", "v2")
+	got, _ = s.GetMetadata("prod", "KEY")
+	if !got.ExpiresAt.IsZero() || got.RotateEvery != 90*24*time.Hour {
+		t.Errorf("after Save = %+v, want expiry cleared and rotation kept", got)
+	}
+}
+
+func TestMetadata_CorruptExpiryIsAnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "veil.db")
+	s, err := NewSqliteStore(path)
+	if err != nil {
+		t.Fatalf("NewSqliteStore error: %v", err)
+	}
+	defer s.Close()
+	s.Save("prod", "EXPIRY", "v")
+	s.Save("prod", "ROTATE", "v")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open error: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`UPDATE secrets SET expires_at = 'soon' WHERE name = 'EXPIRY'; UPDATE secrets SET rotate_every = '90 days' WHERE name = 'ROTATE';`); err != nil {
+		t.Fatalf("corrupting metadata: %v", err)
+	}
+
+	for _, name := range []string{"EXPIRY", "ROTATE"} {
+		if _, err := s.GetMetadata("prod", name); !errors.Is(err, store.ErrGetFailed) {
+			t.Errorf("GetMetadata(%s) error = %v, want ErrGetFailed", name, err)
+		}
+	}
+}
+
+func TestTrash_RestoresVersionsAndMetadata(t *testing.T) {
+	s := newTestStore(t)
+
+	s.Save("prod", "KEY", "v1")
+	s.Save("prod", "KEY", "v2")
+	s.SetMetadata("prod", "KEY", store.Metadata{Owner: "ops", Tags: []string{"pci"}})
+
+	if err := s.Trash("prod", "KEY"); err != nil {
+		t.Fatalf("Trash error: %v", err)
+	}
+	if _, err := s.Get("prod", "KEY"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("Get after Trash error = %v, want ErrNotFound", err)
+	}
+	if err := s.Trash("prod", "KEY"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("Trash of missing secret error = %v, want ErrNotFound", err)
+	}
+
+	var trashed []store.TrashedSecret
+	for ts, err := range s.ListTrash() {
+		if err != nil {
+			t.Fatalf("ListTrash error: %v", err)
+		}
+		trashed = append(trashed, ts)
+	}
+	if len(trashed) != 1 || trashed[0].Vault != "prod" || trashed[0].Name != "KEY" || trashed[0].DeletedAt.IsZero() {
+		t.Fatalf("ListTrash = %+v", trashed)
+	}
+
+	var values []string
+	for v, err := range s.TrashValues(trashed[0].ID) {
+		if err != nil {
+			t.Fatalf("TrashValues error: %v", err)
+		}
+		values = append(values, v.Value)
+	}
+	if !slices.Equal(values, []string{"v2", "v1"}) {
+		t.Errorf("TrashValues = %v, want [v2 v1]", values)
+	}
+
+	if m, err := s.GetTrashMetadata(trashed[0].ID); err != nil || m.Owner != "ops" {
+		t.Errorf("GetTrashMetadata = %+v, %v; want the secret's metadata", m, err)
+	}
+	if err := s.SetTrashMetadata(trashed[0].ID, store.Metadata{Owner: "sre", Tags: []string{"pci"}}); err != nil {
+		t.Fatalf("SetTrashMetadata error: %v", err)
+	}
+	if _, err := s.GetTrashMetadata(trashed[0].ID + 1); !errors.Is(err, store.ErrTrashNotFound) {
+		t.Errorf("GetTrashMetadata of missing entry error = %v, want ErrTrashNotFound", err)
+	}
+
+	s.Save("prod", "KEY", "other")
+	if err := s.RestoreTrash(trashed[0].ID); !errors.Is(err, store.ErrExists) {
+		t.Fatalf("RestoreTrash over a live secret error = %v, want ErrExists", err)
+	}
+	s.Delete("prod", "KEY")
+
+	if err := s.RestoreTrash(trashed[0].ID); err != nil {
+		t.Fatalf("RestoreTrash error: %v", err)
+	}
+	if value, _ := s.Get("prod", "KEY"); value != "v2" {
+		t.Errorf("restored value = %q, want v2", value)
+	}
+	if value, _ := s.GetVersion("prod", "KEY", 1); value != "v1" {
+		t.Errorf("restored version 1 = %q, want v1", value)
+	}
+	if m, _ := s.GetMetadata("prod", "KEY"); m.Owner != "sre" || !slices.Equal(m.Tags, []string{"pci"}) {
+		t.Errorf("restored metadata = %+v", m)
+	}
+	if err := s.RestoreTrash(trashed[0].ID); !errors.Is(err, store.ErrTrashNotFound) {
+		t.Errorf("second RestoreTrash error = %v, want ErrTrashNotFound", err)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	s := newTestStore(t)
+
+	s.Save("prod", "A", "a")
+	s.Save("prod", "B", "b")
+	s.Trash("prod", "A")
+	s.Trash("prod", "B")
+
+	n, err := s.PurgeTrash(time.Now().Add(-time.Hour))
+	if err != nil || n != 0 {
+		t.Fatalf("PurgeTrash(an hour ago) = %d, %v; want 0", n, err)
+	}
+	n, err = s.PurgeTrash(time.Time{})
+	if err != nil || n != 2 {
+		t.Fatalf("PurgeTrash(zero) = %d, %v; want 2", n, err)
+	}
+	for ts := range s.ListTrash() {
+		t.Errorf("trash still holds %s/%s", ts.Vault, ts.Name)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := newTestStore(t)
+	s.Save("prod", "KEY", "v1")
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := s.(store.Snapshotter).Snapshot(path); err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+	if err := s.(store.Snapshotter).Snapshot(path); err == nil {
+		t.Error("Snapshot over an existing file should fail")
+	}
+	s.Nuke()
+
+	restored, err := NewSqliteStore(path)
+	if err != nil {
+		t.Fatalf("opening snapshot: %v", err)
+	}
+	defer restored.Close()
+	if value, err := restored.Get("prod", "KEY"); err != nil || value != "v1" {
+		t.Errorf("Get from snapshot = %q, %v; want v1", value, err)
+	}
+}
+
+func TestVaultParents(t *testing.T) {
+	s := newTestStore(t)
+
+	if parents, err := s.GetVaultParents("prod"); err != nil || parents != nil {
+		t.Fatalf("GetVaultParents of new vault = %v, %v; want nil", parents, err)
+	}
+	s.SetVaultParents("prod", []string{"shared", "base"})
+	s.SetVaultParents("dev", []string{"base"})
+
+	if parents, _ := s.GetVaultParents("prod"); !slices.Equal(parents, []string{"shared", "base"}) {
+		t.Errorf("GetVaultParents = %v, want [shared base] in order", parents)
+	}
+
+	var all []store.VaultParents
+	for vp, err := range s.ListVaultParents() {
+		if err != nil {
+			t.Fatalf("ListVaultParents error: %v", err)
+		}
+		all = append(all, vp)
+	}
+	if len(all) != 2 || all[0].Vault != "dev" || all[1].Vault != "prod" || len(all[1].Parents) != 2 {
+		t.Errorf("ListVaultParents = %+v", all)
+	}
+
+	s.SetVaultParents("prod", nil)
+	if parents, _ := s.GetVaultParents("prod"); parents != nil {
+		t.Errorf("GetVaultParents after clearing = %v, want nil", parents)
+	}
+}
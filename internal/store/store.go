@@ -3,10 +3,21 @@ package store
 import (
 	"errors"
 	"iter"
+	"time"
 )
 
 var (
 	ErrNotFound = errors.New("secret not found")
+
+	ErrExists = errors.New("secret already exists")
+
+	ErrVersionNotFound = errors.New("secret version not found")
+
+	ErrMetaNotFound = errors.New("setting not found")
+
+	ErrVaultKeyNotFound = errors.New("vault key not found")
+
+	ErrTrashNotFound = errors.New("secret not found in trash")
 )
 
 type SecretRef struct {
@@ -14,6 +25,64 @@ type SecretRef struct {
 	Name  string
 }
 
+// Version is a prior value of a secret, archived when it was overwritten.
+// Version numbers start at 1 and increase with every overwrite.
+type Version struct {
+	Number     int
+	Value      string
+	ArchivedAt time.Time
+}
+
+// Metadata describes a secret. CreatedAt and UpdatedAt are maintained by the
+// store: UpdatedAt changes whenever a new value is saved, but not when an
+// existing value is re-encrypted. Both are zero for secrets saved before
+// timestamps were recorded.
+//
+// ExpiresAt is when the current value stops working, or zero if it does not
+// expire. It belongs to the value, so saving a new value clears it.
+// RotateEvery is how often the secret should be given a new value, or zero
+// for no rotation policy.
+//
+// Recipe records how a generated secret was made so that it can be
+// regenerated. The store keeps it as an opaque string.
+//
+// Template marks a value whose ${NAME} references are expanded when it is
+// read. Other values are always used literally.
+type Metadata struct {
+	Description string
+	Owner       string
+	Tags        []string
+	ExpiresAt   time.Time
+	RotateEvery time.Duration
+	Recipe      string
+	Template    bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TrashedSecret is a deleted secret kept in the trash, with its archived
+// versions and metadata, until it is restored or purged.
+type TrashedSecret struct {
+	ID        int64
+	Vault     string
+	Name      string
+	DeletedAt time.Time
+}
+
+// VaultParents lists the vaults a vault inherits secrets from, nearest
+// first.
+type VaultParents struct {
+	Vault   string
+	Parents []string
+}
+
+// VaultKey is the data encryption key of a vault, wrapped (encrypted) by
+// the master key.
+type VaultKey struct {
+	Vault string
+	Key   string
+}
+
 type Store interface {
 	Save(vault, name, value string) error
 	Get(vault, name string) (string, error)
@@ -21,6 +90,96 @@ type Store interface {
 	List(vault string) iter.Seq2[string, error]
 	ListVaults() iter.Seq2[string, error]
 	Search(pattern string) iter.Seq2[SecretRef, error]
+	ListVersions(vault, name string) iter.Seq2[Version, error]
+	GetVersion(vault, name string, version int) (string, error)
+
+	// GetMetadata and SetMetadata read and replace the metadata of a
+	// secret. SetMetadata ignores CreatedAt and UpdatedAt. Both fail with
+	// ErrNotFound if the secret does not exist.
+	GetMetadata(vault, name string) (Metadata, error)
+	SetMetadata(vault, name string, m Metadata) error
+
+	// GetMeta, SetMeta and DeleteMeta manage database-wide settings such as
+	// key derivation parameters. Values are not secret.
+	GetMeta(key string) (string, error)
+	SetMeta(key, value string) error
+	DeleteMeta(key string) error
+
+	// Rename moves a secret and its archived versions to a new vault and
+	// name without touching their values. It fails with ErrExists if the
+	// destination is taken.
+	Rename(vault, name, newVault, newName string) error
+
+	// GetVaultKey, SetVaultKey, DeleteVaultKey and ListVaultKeys manage the
+	// wrapped data encryption keys of vaults. SetVaultKey replaces an
+	// existing key.
+	GetVaultKey(vault string) (string, error)
+	SetVaultKey(vault, wrappedKey string) error
+	DeleteVaultKey(vault string) error
+	ListVaultKeys() iter.Seq2[VaultKey, error]
+
+	// GetVaultParents, SetVaultParents and ListVaultParents manage the
+	// vaults each vault inherits from. GetVaultParents returns nil for a
+	// vault without parents, and SetVaultParents with no parents removes
+	// them. ListVaultParents returns only vaults that have parents.
+	GetVaultParents(vault string) ([]string, error)
+	SetVaultParents(vault string, parents []string) error
+	ListVaultParents() iter.Seq2[VaultParents, error]
+
+	// RewriteValue replaces the ciphertext of a secret (version 0) or of one
+	// of its archived versions in place without archiving anything. It is
+	// used when re-encrypting existing values.
+	RewriteValue(vault, name string, version int, value string) error
+
+	// Trash moves a secret with its archived versions and metadata to the
+	// trash. It fails with ErrNotFound if the secret does not exist.
+	Trash(vault, name string) error
+
+	// ListTrash returns the trashed secrets, most recently deleted first.
+	ListTrash() iter.Seq2[TrashedSecret, error]
+
+	// RestoreTrash moves a trashed secret back under its vault and name. It
+	// fails with ErrTrashNotFound if there is no such entry and with
+	// ErrExists if the name has been taken since.
+	RestoreTrash(id int64) error
+
+	// PurgeTrash permanently deletes the secrets trashed before the given
+	// time and returns how many there were.
+	PurgeTrash(before time.Time) (int, error)
+
+	// TrashValues returns the stored values of a trashed secret: its
+	// current value as version 0, then its archived versions, newest first.
+	TrashValues(id int64) iter.Seq2[Version, error]
+
+	// RewriteTrashValue and RenameTrash are the trash counterparts of
+	// RewriteValue and Rename, used when re-encrypting values and names.
+	RewriteTrashValue(id int64, version int, value string) error
+	RenameTrash(id int64, vault, name string) error
+
+	// GetTrashMetadata and SetTrashMetadata are the trash counterparts of
+	// GetMetadata and SetMetadata, used when converting names. Both fail
+	// with ErrTrashNotFound if there is no such entry.
+	GetTrashMetadata(id int64) (Metadata, error)
+	SetTrashMetadata(id int64, m Metadata) error
+
+	// Atomic runs fn against a view of the store in which all changes are
+	// applied together: they are committed if fn returns nil and discarded
+	// otherwise.
+	Atomic(fn func(Store) error) error
+
 	Nuke() error
 	Close() error
 }
+
+// Snapshotter is implemented by stores that can write a consistent copy of
+// themselves to a new file, which can later be opened in place of the
+// original.
+type Snapshotter interface {
+	Snapshot(path string) error
+}
+
+// Compacter is implemented by stores that can reclaim the space of deleted
+// and overwritten data, so that old contents do not linger in the file.
+type Compacter interface {
+	Compact() error
+}
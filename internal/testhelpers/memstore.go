@@ -4,7 +4,10 @@ package testhelpers
 
 import (
 	"iter"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/ossydotpy/veil/internal/store"
 )
@@ -20,6 +23,13 @@ type SaveCall struct {
 // It stores data in memory and optionally tracks all Save() calls.
 type MemStore struct {
 	data      map[string]string
+	metadata  map[string]store.Metadata
+	versions  map[string][]store.Version
+	meta      map[string]string
+	vaultKeys map[string]string
+	parents   map[string][]string
+	trash     []trashEntry
+	trashID   int64
 	SaveCalls []SaveCall // Records every Save() call for verification
 	GetErr    error      // Configurable error for Get()
 	SaveErr   error      // Configurable error for Save()
@@ -27,21 +37,49 @@ type MemStore struct {
 	ListVaultsErr error   // Configurable error for ListVaults()
 }
 
+// trashEntry is a deleted secret held by MemStore.
+type trashEntry struct {
+	store.TrashedSecret
+	value    string
+	metadata store.Metadata
+	versions []store.Version
+}
+
 // NewMemStore creates a new MemStore with initialized data map.
 func NewMemStore() *MemStore {
 	return &MemStore{
 		data:      make(map[string]string),
+		metadata:  make(map[string]store.Metadata),
+		versions:  make(map[string][]store.Version),
+		meta:      make(map[string]string),
+		vaultKeys: make(map[string]string),
+		parents:   make(map[string][]string),
 		SaveCalls: make([]SaveCall, 0),
 	}
 }
 
-// Save stores the value and records the call.
+// Save stores the value, archiving any previous value, and records the call.
 func (s *MemStore) Save(vault, name, value string) error {
 	if s.SaveErr != nil {
 		return s.SaveErr
 	}
 	s.SaveCalls = append(s.SaveCalls, SaveCall{Vault: vault, Name: name, Value: value})
-	s.data[vault+"/"+name] = value
+	key := vault + "/" + name
+	now := time.Now().UTC()
+	m := s.metadata[key]
+	if old, ok := s.data[key]; ok {
+		s.versions[key] = append(s.versions[key], store.Version{
+			Number:     len(s.versions[key]) + 1,
+			Value:      old,
+			ArchivedAt: now,
+		})
+	} else {
+		m = store.Metadata{CreatedAt: now}
+	}
+	m.UpdatedAt = now
+	m.ExpiresAt = time.Time{}
+	s.data[key] = value
+	s.metadata[key] = m
 	return nil
 }
 
@@ -57,9 +95,11 @@ func (s *MemStore) Get(vault, name string) (string, error) {
 	return val, nil
 }
 
-// Delete removes a key from the store.
+// Delete removes a key and its versions from the store.
 func (s *MemStore) Delete(vault, name string) error {
 	delete(s.data, vault+"/"+name)
+	delete(s.metadata, vault+"/"+name)
+	delete(s.versions, vault+"/"+name)
 	return nil
 }
 
@@ -105,9 +145,335 @@ func (s *MemStore) Search(pattern string) iter.Seq2[store.SecretRef, error] {
 	return func(yield func(store.SecretRef, error) bool) {}
 }
 
+// ListVersions returns the archived versions of a secret, newest first.
+func (s *MemStore) ListVersions(vault, name string) iter.Seq2[store.Version, error] {
+	return func(yield func(store.Version, error) bool) {
+		versions := s.versions[vault+"/"+name]
+		for i := len(versions) - 1; i >= 0; i-- {
+			if !yield(versions[i], nil) {
+				return
+			}
+		}
+	}
+}
+
+// GetVersion returns the value of an archived version.
+func (s *MemStore) GetVersion(vault, name string, version int) (string, error) {
+	versions := s.versions[vault+"/"+name]
+	if version < 1 || version > len(versions) {
+		return "", store.ErrVersionNotFound
+	}
+	return versions[version-1].Value, nil
+}
+
+// GetMetadata returns the metadata of a secret.
+func (s *MemStore) GetMetadata(vault, name string) (store.Metadata, error) {
+	key := vault + "/" + name
+	if _, ok := s.data[key]; !ok {
+		return store.Metadata{}, store.ErrNotFound
+	}
+	m := s.metadata[key]
+	m.Tags = slices.Clone(m.Tags)
+	return m, nil
+}
+
+// SetMetadata replaces the metadata of a secret, keeping its timestamps.
+func (s *MemStore) SetMetadata(vault, name string, m store.Metadata) error {
+	key := vault + "/" + name
+	if _, ok := s.data[key]; !ok {
+		return store.ErrNotFound
+	}
+	m.Tags = slices.Clone(m.Tags)
+	m.CreatedAt, m.UpdatedAt = s.metadata[key].CreatedAt, s.metadata[key].UpdatedAt
+	s.metadata[key] = m
+	return nil
+}
+
+// GetMeta returns a database-wide setting.
+func (s *MemStore) GetMeta(key string) (string, error) {
+	val, ok := s.meta[key]
+	if !ok {
+		return "", store.ErrMetaNotFound
+	}
+	return val, nil
+}
+
+// SetMeta stores a database-wide setting.
+func (s *MemStore) SetMeta(key, value string) error {
+	s.meta[key] = value
+	return nil
+}
+
+// DeleteMeta removes a database-wide setting.
+func (s *MemStore) DeleteMeta(key string) error {
+	delete(s.meta, key)
+	return nil
+}
+
+// Rename moves a secret and its archived versions.
+func (s *MemStore) Rename(vault, name, newVault, newName string) error {
+	from, to := vault+"/"+name, newVault+"/"+newName
+	val, ok := s.data[from]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if _, taken := s.data[to]; taken {
+		return store.ErrExists
+	}
+	delete(s.data, from)
+	s.data[to] = val
+	s.metadata[to] = s.metadata[from]
+	delete(s.metadata, from)
+	if versions, ok := s.versions[from]; ok {
+		delete(s.versions, from)
+		s.versions[to] = versions
+	}
+	return nil
+}
+
+// GetVaultKey returns the wrapped data key of a vault.
+func (s *MemStore) GetVaultKey(vault string) (string, error) {
+	key, ok := s.vaultKeys[vault]
+	if !ok {
+		return "", store.ErrVaultKeyNotFound
+	}
+	return key, nil
+}
+
+// SetVaultKey stores or replaces the wrapped data key of a vault.
+func (s *MemStore) SetVaultKey(vault, wrappedKey string) error {
+	s.vaultKeys[vault] = wrappedKey
+	return nil
+}
+
+// DeleteVaultKey removes the wrapped data key of a vault.
+func (s *MemStore) DeleteVaultKey(vault string) error {
+	delete(s.vaultKeys, vault)
+	return nil
+}
+
+// ListVaultKeys returns the wrapped data keys of all vaults, sorted by vault.
+func (s *MemStore) ListVaultKeys() iter.Seq2[store.VaultKey, error] {
+	return func(yield func(store.VaultKey, error) bool) {
+		for _, vault := range slices.Sorted(maps.Keys(s.vaultKeys)) {
+			if !yield(store.VaultKey{Vault: vault, Key: s.vaultKeys[vault]}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// GetVaultParents returns the parents of a vault.
+func (s *MemStore) GetVaultParents(vault string) ([]string, error) {
+	return slices.Clone(s.parents[vault]), nil
+}
+
+// SetVaultParents replaces the parents of a vault.
+func (s *MemStore) SetVaultParents(vault string, parents []string) error {
+	if len(parents) == 0 {
+		delete(s.parents, vault)
+		return nil
+	}
+	s.parents[vault] = slices.Clone(parents)
+	return nil
+}
+
+// ListVaultParents returns the parents of each vault, sorted by vault.
+func (s *MemStore) ListVaultParents() iter.Seq2[store.VaultParents, error] {
+	return func(yield func(store.VaultParents, error) bool) {
+		for _, vault := range slices.Sorted(maps.Keys(s.parents)) {
+			if !yield(store.VaultParents{Vault: vault, Parents: slices.Clone(s.parents[vault])}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// RewriteValue replaces a current (version 0) or archived value in place.
+func (s *MemStore) RewriteValue(vault, name string, version int, value string) error {
+	key := vault + "/" + name
+	if version == 0 {
+		if _, ok := s.data[key]; !ok {
+			return store.ErrNotFound
+		}
+		s.data[key] = value
+		return nil
+	}
+	versions := s.versions[key]
+	if version < 1 || version > len(versions) {
+		return store.ErrVersionNotFound
+	}
+	versions[version-1].Value = value
+	return nil
+}
+
+// Trash moves a secret, its versions and its metadata to the trash.
+func (s *MemStore) Trash(vault, name string) error {
+	key := vault + "/" + name
+	val, ok := s.data[key]
+	if !ok {
+		return store.ErrNotFound
+	}
+	s.trashID++
+	s.trash = append(s.trash, trashEntry{
+		TrashedSecret: store.TrashedSecret{ID: s.trashID, Vault: vault, Name: name, DeletedAt: time.Now().UTC()},
+		value:         val,
+		metadata:      s.metadata[key],
+		versions:      s.versions[key],
+	})
+	return s.Delete(vault, name)
+}
+
+// ListTrash returns the trashed secrets, most recently deleted first.
+func (s *MemStore) ListTrash() iter.Seq2[store.TrashedSecret, error] {
+	return func(yield func(store.TrashedSecret, error) bool) {
+		for i := len(s.trash) - 1; i >= 0; i-- {
+			if !yield(s.trash[i].TrashedSecret, nil) {
+				return
+			}
+		}
+	}
+}
+
+// RestoreTrash moves a trashed secret back.
+func (s *MemStore) RestoreTrash(id int64) error {
+	i := s.trashIndex(id)
+	if i < 0 {
+		return store.ErrTrashNotFound
+	}
+	t := s.trash[i]
+	key := t.Vault + "/" + t.Name
+	if _, taken := s.data[key]; taken {
+		return store.ErrExists
+	}
+	s.data[key] = t.value
+	s.metadata[key] = t.metadata
+	if len(t.versions) > 0 {
+		s.versions[key] = t.versions
+	}
+	s.trash = slices.Delete(s.trash, i, i+1)
+	return nil
+}
+
+// PurgeTrash deletes the secrets trashed before the given time, or all of
+// them if it is zero.
+func (s *MemStore) PurgeTrash(before time.Time) (int, error) {
+	n := len(s.trash)
+	s.trash = slices.DeleteFunc(s.trash, func(t trashEntry) bool {
+		return before.IsZero() || t.DeletedAt.Before(before)
+	})
+	return n - len(s.trash), nil
+}
+
+// TrashValues returns the current value of a trashed secret as version 0,
+// then its archived versions, newest first.
+func (s *MemStore) TrashValues(id int64) iter.Seq2[store.Version, error] {
+	return func(yield func(store.Version, error) bool) {
+		i := s.trashIndex(id)
+		if i < 0 {
+			return
+		}
+		t := s.trash[i]
+		if !yield(store.Version{Value: t.value}, nil) {
+			return
+		}
+		for j := len(t.versions) - 1; j >= 0; j-- {
+			if !yield(t.versions[j], nil) {
+				return
+			}
+		}
+	}
+}
+
+// RewriteTrashValue replaces a value of a trashed secret in place.
+func (s *MemStore) RewriteTrashValue(id int64, version int, value string) error {
+	i := s.trashIndex(id)
+	if i < 0 {
+		return store.ErrTrashNotFound
+	}
+	if version == 0 {
+		s.trash[i].value = value
+		return nil
+	}
+	versions := s.trash[i].versions
+	if version < 1 || version > len(versions) {
+		return store.ErrVersionNotFound
+	}
+	versions[version-1].Value = value
+	return nil
+}
+
+// RenameTrash changes the vault and name of a trashed secret.
+func (s *MemStore) RenameTrash(id int64, vault, name string) error {
+	i := s.trashIndex(id)
+	if i < 0 {
+		return store.ErrTrashNotFound
+	}
+	s.trash[i].Vault, s.trash[i].Name = vault, name
+	return nil
+}
+
+// GetTrashMetadata returns the metadata of a trashed secret.
+func (s *MemStore) GetTrashMetadata(id int64) (store.Metadata, error) {
+	i := s.trashIndex(id)
+	if i < 0 {
+		return store.Metadata{}, store.ErrTrashNotFound
+	}
+	m := s.trash[i].metadata
+	m.Tags = slices.Clone(m.Tags)
+	return m, nil
+}
+
+// SetTrashMetadata replaces the metadata of a trashed secret, keeping its
+// timestamps.
+func (s *MemStore) SetTrashMetadata(id int64, m store.Metadata) error {
+	i := s.trashIndex(id)
+	if i < 0 {
+		return store.ErrTrashNotFound
+	}
+	m.Tags = slices.Clone(m.Tags)
+	m.CreatedAt, m.UpdatedAt = s.trash[i].metadata.CreatedAt, s.trash[i].metadata.UpdatedAt
+	s.trash[i].metadata = m
+	return nil
+}
+
+func (s *MemStore) trashIndex(id int64) int {
+	return slices.IndexFunc(s.trash, func(t trashEntry) bool { return t.ID == id })
+}
+
+// Atomic runs fn and restores the previous contents if it fails.
+func (s *MemStore) Atomic(fn func(store.Store) error) error {
+	data := maps.Clone(s.data)
+	metadata := maps.Clone(s.metadata)
+	meta := maps.Clone(s.meta)
+	vaultKeys := maps.Clone(s.vaultKeys)
+	parents := maps.Clone(s.parents)
+	versions := make(map[string][]store.Version, len(s.versions))
+	for k, v := range s.versions {
+		versions[k] = slices.Clone(v)
+	}
+	trash := slices.Clone(s.trash)
+	for i := range trash {
+		trash[i].versions = slices.Clone(trash[i].versions)
+	}
+
+	if err := fn(s); err != nil {
+		s.data, s.metadata, s.meta, s.versions, s.vaultKeys = data, metadata, meta, versions, vaultKeys
+		s.parents, s.trash = parents, trash
+		return err
+	}
+	return nil
+}
+
 // Nuke clears all data.
 func (s *MemStore) Nuke() error {
 	s.data = make(map[string]string)
+	s.metadata = make(map[string]store.Metadata)
+	s.versions = make(map[string][]store.Version)
+	s.meta = make(map[string]string)
+	s.vaultKeys = make(map[string]string)
+	s.parents = make(map[string][]string)
+	s.trash = nil
 	s.SaveCalls = make([]SaveCall, 0)
 	return nil
 }